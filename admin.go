@@ -0,0 +1,218 @@
+package redirects
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// An AdminHandler manages compiled rules at runtime through a JSON API,
+// encoding rules as ExportNDJSON does. Paths are relative to where the
+// handler is mounted, see http.StripPrefix:
+//
+//	GET    /     lists the rules
+//	POST   /     appends a rule, or inserts it before ?index=n
+//	PUT    /     replaces all rules
+//	GET    /n    returns rule n
+//	PUT    /n    replaces rule n
+//	DELETE /n    removes rule n
+//
+// Rules are validated before being applied, responding with 422 and the
+// problems otherwise. The handler does not authenticate requests.
+type AdminHandler struct {
+	// Rules are the managed rules, reloaded on each change.
+	Rules *CompiledRules
+
+	// Persist is optionally called with the rules before each change is
+	// applied, such as to write a _redirects file. An error fails the
+	// request and leaves the rules unchanged.
+	Persist func([]Rule) error
+
+	// mu serializes changes.
+	mu sync.Mutex
+}
+
+// adminError is the JSON response of a failed request.
+type adminError struct {
+	Error  string   `json:"error"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ServeHTTP implementation.
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+
+	if path == "" {
+		switch r.Method {
+		case http.MethodGet:
+			adminJSON(w, http.StatusOK, append([]Rule{}, h.Rules.Rules()...))
+		case http.MethodPost:
+			h.add(w, r)
+		case http.MethodPut:
+			h.replace(w, r)
+		default:
+			w.Header().Set("Allow", "GET, POST, PUT")
+			adminJSON(w, http.StatusMethodNotAllowed, adminError{Error: "method not allowed"})
+		}
+		return
+	}
+
+	i, err := strconv.Atoi(path)
+	if err != nil || i < 0 {
+		adminJSON(w, http.StatusNotFound, adminError{Error: "not found"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		rules := h.Rules.Rules()
+		if i >= len(rules) {
+			adminJSON(w, http.StatusNotFound, adminError{Error: fmt.Sprintf("no rule %d", i)})
+			return
+		}
+		adminJSON(w, http.StatusOK, rules[i])
+	case http.MethodPut:
+		h.update(w, r, i)
+	case http.MethodDelete:
+		h.delete(w, i)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		adminJSON(w, http.StatusMethodNotAllowed, adminError{Error: "method not allowed"})
+	}
+}
+
+// add appends or inserts a rule.
+func (h *AdminHandler) add(w http.ResponseWriter, r *http.Request) {
+	rule, ok := decodeRule(w, r)
+	if !ok {
+		return
+	}
+
+	h.change(w, http.StatusCreated, func(rules []Rule) ([]Rule, error) {
+		i := len(rules)
+		if s := r.URL.Query().Get("index"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 || n > len(rules) {
+				return nil, fmt.Errorf("invalid index %q", s)
+			}
+			i = n
+		}
+
+		rules = append(rules, Rule{})
+		copy(rules[i+1:], rules[i:])
+		rules[i] = rule
+		return rules, nil
+	})
+}
+
+// replace replaces all rules.
+func (h *AdminHandler) replace(w http.ResponseWriter, r *http.Request) {
+	var list []Rule
+	if err := json.NewDecoder(r.Body).Decode(&list); err != nil {
+		adminJSON(w, http.StatusBadRequest, adminError{Error: fmt.Sprintf("decoding: %s", err)})
+		return
+	}
+
+	for i := range list {
+		defaultStatus(&list[i])
+	}
+
+	h.change(w, http.StatusOK, func([]Rule) ([]Rule, error) {
+		return list, nil
+	})
+}
+
+// update replaces rule i.
+func (h *AdminHandler) update(w http.ResponseWriter, r *http.Request, i int) {
+	rule, ok := decodeRule(w, r)
+	if !ok {
+		return
+	}
+
+	h.change(w, http.StatusOK, func(rules []Rule) ([]Rule, error) {
+		if i >= len(rules) {
+			return nil, errNoRule(i)
+		}
+		rules[i] = rule
+		return rules, nil
+	})
+}
+
+// delete removes rule i.
+func (h *AdminHandler) delete(w http.ResponseWriter, i int) {
+	h.change(w, http.StatusOK, func(rules []Rule) ([]Rule, error) {
+		if i >= len(rules) {
+			return nil, errNoRule(i)
+		}
+		return append(rules[:i], rules[i+1:]...), nil
+	})
+}
+
+// errNoRule is the error of a missing rule index.
+type errNoRule int
+
+// Error implementation.
+func (e errNoRule) Error() string {
+	return fmt.Sprintf("no rule %d", int(e))
+}
+
+// change applies fn to a copy of the rules, validating and persisting the
+// result before reloading, and responds with the rules.
+func (h *AdminHandler) change(w http.ResponseWriter, status int, fn func([]Rule) ([]Rule, error)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	current := h.Rules.Rules()
+	rules, err := fn(append([]Rule(nil), current...))
+
+	if e, ok := err.(errNoRule); ok {
+		adminJSON(w, http.StatusNotFound, adminError{Error: e.Error()})
+		return
+	}
+
+	if err != nil {
+		adminJSON(w, http.StatusBadRequest, adminError{Error: err.Error()})
+		return
+	}
+
+	if errs := Validate(rules); len(errs) > 0 {
+		res := adminError{Error: "invalid rules"}
+		for _, e := range errs {
+			res.Errors = append(res.Errors, e.Error())
+		}
+		adminJSON(w, http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	if h.Persist != nil {
+		if err := h.Persist(rules); err != nil {
+			adminJSON(w, http.StatusInternalServerError, adminError{Error: fmt.Sprintf("persisting: %s", err)})
+			return
+		}
+	}
+
+	h.Rules.Reload(rules)
+	adminJSON(w, status, append([]Rule{}, rules...))
+}
+
+// decodeRule decodes the rule of a request body, with a missing status
+// defaulting to 301, responding with an error when it is malformed.
+func decodeRule(w http.ResponseWriter, r *http.Request) (rule Rule, ok bool) {
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		adminJSON(w, http.StatusBadRequest, adminError{Error: fmt.Sprintf("decoding: %s", err)})
+		return rule, false
+	}
+
+	defaultStatus(&rule)
+	return rule, true
+}
+
+// adminJSON writes v as a JSON response with status.
+func adminJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
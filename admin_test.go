@@ -0,0 +1,127 @@
+package redirects_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/pkg/errors"
+	"github.com/tj/assert"
+)
+
+func TestAdminHandler(t *testing.T) {
+	compiled := redirects.Compile(redirects.Must(redirects.ParseString(`
+		/home  /
+		/news  /blog  302
+	`)))
+
+	var persisted []redirects.Rule
+	admin := &redirects.AdminHandler{
+		Rules: compiled,
+		Persist: func(rules []redirects.Rule) error {
+			persisted = rules
+			return nil
+		},
+	}
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		admin.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	froms := func() (v []string) {
+		for _, r := range compiled.Rules() {
+			v = append(v, r.From)
+		}
+		return
+	}
+
+	t.Run("list", func(t *testing.T) {
+		w := serve("GET", "/", "")
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var rules []redirects.Rule
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &rules))
+		assert.Len(t, rules, 2)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		w := serve("GET", "/1", "")
+		assert.Equal(t, 200, w.Code)
+		assert.Contains(t, w.Body.String(), `"From":"/news"`)
+
+		w = serve("GET", "/5", "")
+		assert.Equal(t, 404, w.Code)
+	})
+
+	t.Run("add", func(t *testing.T) {
+		w := serve("POST", "/", `{"From": "/docs", "To": "/manual"}`)
+		assert.Equal(t, 201, w.Code)
+		assert.Equal(t, []string{"/home", "/news", "/docs"}, froms())
+		assert.Equal(t, 301, compiled.Rules()[2].Status)
+		assert.Len(t, persisted, 3)
+
+		w = serve("POST", "/?index=0", `{"From": "/first", "To": "/"}`)
+		assert.Equal(t, 201, w.Code)
+		assert.Equal(t, []string{"/first", "/home", "/news", "/docs"}, froms())
+	})
+
+	t.Run("update", func(t *testing.T) {
+		w := serve("PUT", "/0", `{"From": "/start", "To": "/", "Status": 302}`)
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, []string{"/start", "/home", "/news", "/docs"}, froms())
+
+		res, ok := compiled.Match(redirects.Request{Path: "/start"})
+		assert.True(t, ok)
+		assert.Equal(t, 302, res.Rule.Status)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		w := serve("DELETE", "/0", "")
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, []string{"/home", "/news", "/docs"}, froms())
+
+		w = serve("DELETE", "/9", "")
+		assert.Equal(t, 404, w.Code)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		w := serve("PUT", "/", `[{"From": "/a", "To": "/b"}]`)
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, []string{"/a"}, froms())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		w := serve("POST", "/", `{"From": "/x", "To": "/:missing"}`)
+		assert.Equal(t, 422, w.Code)
+		assert.Contains(t, w.Body.String(), "destination placeholder :missing is not bound")
+		assert.Equal(t, []string{"/a"}, froms())
+
+		w = serve("POST", "/", `{`)
+		assert.Equal(t, 400, w.Code)
+	})
+
+	t.Run("persist error", func(t *testing.T) {
+		admin.Persist = func([]redirects.Rule) error {
+			return errors.New("disk full")
+		}
+		defer func() { admin.Persist = nil }()
+
+		w := serve("DELETE", "/0", "")
+		assert.Equal(t, 500, w.Code)
+		assert.Contains(t, w.Body.String(), "persisting: disk full")
+		assert.Equal(t, []string{"/a"}, froms())
+	})
+
+	t.Run("served by a handler", func(t *testing.T) {
+		h := &redirects.Handler{Compiled: compiled}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/a", nil))
+		assert.Equal(t, 301, w.Code)
+		assert.Equal(t, "/b", w.Header().Get("Location"))
+	})
+}
@@ -0,0 +1,243 @@
+package redirects
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// apacheStatuses are the named statuses of the Redirect directives.
+var apacheStatuses = map[string]int{
+	"permanent": 301,
+	"temp":      302,
+	"seeother":  303,
+}
+
+// ImportApache reads the redirects of an Apache configuration or .htaccess
+// file, converting Redirect, RedirectMatch and RewriteRule directives into
+// rules in the order they appear. Regular expressions are limited to those
+// described by simple path patterns, and RewriteRule flags to R, L and P.
+// Unconvertible directives, such as those following a RewriteCond or within
+// an <If> section, are skipped and reported as a *ParseError wrapping
+// ErrUnsupported with their line number. Other directives are ignored.
+func ImportApache(r io.Reader) (rules []Rule, skipped []*ParseError, err error) {
+	s := bufio.NewScanner(r)
+
+	var text string
+	var start int
+	conditional := 0
+	cond := false
+
+	for line := 1; s.Scan(); line++ {
+		l := strings.TrimSpace(s.Text())
+
+		if text == "" {
+			start = line
+		}
+
+		if strings.HasSuffix(l, `\`) {
+			text += strings.TrimSuffix(l, `\`) + " "
+			continue
+		}
+
+		text += l
+		directive := text
+		text = ""
+
+		if directive == "" || strings.HasPrefix(directive, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(directive, "<") {
+			switch name := strings.ToLower(strings.Trim(strings.Fields(directive)[0], "<>")); name {
+			case "if", "elseif", "else":
+				conditional++
+			case "/if", "/elseif", "/else":
+				conditional--
+			}
+			continue
+		}
+
+		args := apacheFields(directive)
+		name := strings.ToLower(args[0])
+
+		var rule Rule
+		var ok bool
+		var err error
+
+		switch {
+		case name == "rewritecond":
+			cond = true
+			continue
+		case name != "redirect" && name != "redirectmatch" && name != "redirectpermanent" && name != "redirecttemp" && name != "rewriterule":
+			continue
+		case conditional > 0:
+			err = fmt.Errorf("%w: directive in an <If> section", ErrUnsupported)
+		case name == "rewriterule" && cond:
+			err = fmt.Errorf("%w: RewriteRule with a RewriteCond", ErrUnsupported)
+		case name == "rewriterule":
+			rule, ok, err = apacheRewriteRule(args[1:])
+		default:
+			rule, ok, err = apacheRedirect(name, args[1:])
+		}
+
+		if name == "rewriterule" {
+			cond = false
+		}
+
+		if err != nil {
+			skipped = append(skipped, &ParseError{Line: start, Text: directive, Err: err})
+			continue
+		}
+
+		if ok {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, skipped, s.Err()
+}
+
+// apacheRedirect returns the rule of a Redirect, RedirectPermanent,
+// RedirectTemp or RedirectMatch directive.
+func apacheRedirect(name string, args []string) (rule Rule, ok bool, err error) {
+	status := 302
+
+	switch name {
+	case "redirectpermanent":
+		status = 301
+	case "redirect", "redirectmatch":
+		if len(args) == 0 {
+			break
+		}
+
+		code, named := apacheStatuses[strings.ToLower(args[0])]
+		if !named {
+			var err error
+			if code, err = strconv.Atoi(args[0]); err != nil && strings.ToLower(args[0]) != "gone" {
+				break
+			}
+		}
+
+		if code < 300 || code > 399 {
+			return rule, false, fmt.Errorf("%w: status %s", ErrUnsupported, args[0])
+		}
+
+		status = code
+		args = args[1:]
+	}
+
+	if len(args) != 2 {
+		return rule, false, fmt.Errorf("%w: redirect without a destination", ErrUnsupported)
+	}
+
+	if name == "redirectmatch" {
+		from, names, err := regexpPattern(args[0])
+		if err != nil {
+			return rule, false, err
+		}
+
+		to, err := regexpReplacement(args[1], names)
+		if err != nil {
+			return rule, false, err
+		}
+
+		return Rule{From: from, To: to, Status: status}, true, nil
+	}
+
+	from := trimSlash(args[0])
+	if from == "/" {
+		from = ""
+	}
+
+	to := strings.TrimSuffix(args[1], "/")
+	return Rule{From: from + "/*", To: to + "/:splat", Status: status}, true, nil
+}
+
+// apacheRewriteRule returns the rule of a RewriteRule directive.
+func apacheRewriteRule(args []string) (rule Rule, ok bool, err error) {
+	if len(args) < 2 || len(args) > 3 {
+		return rule, false, fmt.Errorf("%w: malformed RewriteRule", ErrUnsupported)
+	}
+
+	pattern := args[0]
+	if strings.HasPrefix(pattern, "^") && !strings.HasPrefix(pattern, "^/") {
+		pattern = "^/" + pattern[1:]
+	}
+
+	from, names, err := regexpPattern(pattern)
+	if err != nil {
+		return rule, false, err
+	}
+
+	if args[1] == "-" {
+		return rule, false, fmt.Errorf("%w: RewriteRule without a substitution", ErrUnsupported)
+	}
+
+	to, err := regexpReplacement(strings.TrimSuffix(args[1], "?"), names)
+	if err != nil {
+		return rule, false, err
+	}
+
+	if !strings.HasPrefix(to, "/") && !isAbsolute(to) {
+		to = "/" + to
+	}
+
+	rule = Rule{From: from, To: to, Status: 200}
+	proxy := false
+
+	if len(args) == 3 {
+		for _, flag := range strings.Split(strings.Trim(args[2], "[]"), ",") {
+			flag = strings.TrimSpace(flag)
+			key, value, _ := strings.Cut(flag, "=")
+
+			switch strings.ToUpper(key) {
+			case "R", "REDIRECT":
+				rule.Status = 302
+				if value != "" {
+					code, err := strconv.Atoi(value)
+					if err != nil || code < 300 || code > 399 {
+						return rule, false, fmt.Errorf("%w: flag %s", ErrUnsupported, flag)
+					}
+					rule.Status = code
+				}
+			case "P", "PROXY":
+				proxy = true
+			case "L", "LAST":
+			default:
+				return rule, false, fmt.Errorf("%w: flag %s", ErrUnsupported, flag)
+			}
+		}
+	}
+
+	if rule.Status == 200 && isAbsolute(to) && !proxy {
+		rule.Status = 302
+	}
+
+	return rule, true, nil
+}
+
+// apacheFields returns the arguments of a directive, which may be quoted.
+func apacheFields(s string) (fields []string) {
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		if s[0] == '"' {
+			if end := strings.IndexByte(s[1:], '"'); end >= 0 {
+				fields = append(fields, s[1:end+1])
+				s = s[end+2:]
+				continue
+			}
+		}
+
+		end := strings.IndexAny(s, " \t")
+		if end < 0 {
+			end = len(s)
+		}
+
+		fields = append(fields, s[:end])
+		s = s[end:]
+	}
+
+	return
+}
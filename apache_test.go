@@ -0,0 +1,75 @@
+package redirects_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/pkg/errors"
+	"github.com/tj/assert"
+)
+
+func TestImportApache(t *testing.T) {
+	conf := `# Legacy URLs
+Redirect /old-section /new-section
+Redirect permanent /docs https://docs.example.com/
+Redirect 307 /tmp /temporary
+Redirect gone /removed
+RedirectPermanent /about /company
+RedirectMatch 301 ^/blog/([^/]+)/(.*)$ /posts/$1/$2
+RedirectMatch (.*)\.gif$ /images$1.png
+
+<IfModule mod_rewrite.c>
+  RewriteEngine On
+  RewriteRule ^shop/(.*)$ /store/$1 [R=301,L]
+  RewriteRule ^app/(.*)$ index.html [L]
+  RewriteRule ^api/(.*)$ https://api.example.com/$1 [P]
+  RewriteRule ^legacy$ https://legacy.example.com/ [L]
+  RewriteCond %{HTTP_HOST} ^www\. [NC]
+  RewriteRule ^(.*)$ https://example.com/$1 [R=301,L]
+  RewriteRule ^case$ /insensitive [NC,R]
+</IfModule>
+
+<If "%{HTTP_HOST} == 'beta.example.com'">
+  Redirect /beta /
+</If>
+
+RedirectMatch 302 \
+  ^/line/continued$ /joined
+`
+
+	rules, skipped, err := redirects.ImportApache(strings.NewReader(conf))
+	assert.NoError(t, err)
+
+	var lines []string
+	for _, r := range rules {
+		lines = append(lines, r.String())
+	}
+
+	assert.Equal(t, []string{
+		"/old-section/* /new-section/:splat 302",
+		"/docs/* https://docs.example.com/:splat 301",
+		"/tmp/* /temporary/:splat 307",
+		"/about/* /company/:splat 301",
+		"/blog/:p1/* /posts/:p1/:splat 301",
+		"/shop/* /store/:splat 301",
+		"/app/* /index.html 200",
+		"/api/* https://api.example.com/:splat 200",
+		"/legacy https://legacy.example.com/ 302",
+		"/line/continued /joined 302",
+	}, lines)
+
+	var reasons []string
+	for _, s := range skipped {
+		assert.True(t, errors.Is(s, redirects.ErrUnsupported))
+		reasons = append(reasons, s.Error())
+	}
+
+	assert.Equal(t, []string{
+		`line 5: unsupported: status gone: "Redirect gone /removed"`,
+		`line 8: unsupported: unanchored regular expression in "(.*)\\.gif$": "RedirectMatch (.*)\\.gif$ /images$1.png"`,
+		`line 17: unsupported: RewriteRule with a RewriteCond: "RewriteRule ^(.*)$ https://example.com/$1 [R=301,L]"`,
+		`line 18: unsupported: flag NC: "RewriteRule ^case$ /insensitive [NC,R]"`,
+		`line 22: unsupported: directive in an <If> section: "Redirect /beta /"`,
+	}, reasons)
+}
@@ -0,0 +1,112 @@
+package redirects
+
+// An Action is what a Response does with a request.
+type Action int
+
+// Actions.
+const (
+	// ActionNone serves the request as is, as no rule applies.
+	ActionNone Action = iota
+
+	// ActionRedirect redirects the client to To with Status.
+	ActionRedirect
+
+	// ActionRewrite serves the content of the path To with Status, for
+	// rewrites and 404, 410 and 451 rules.
+	ActionRewrite
+
+	// ActionProxy forwards the request to the absolute URL To.
+	ActionProxy
+)
+
+// String returns the name of the action.
+func (a Action) String() string {
+	switch a {
+	case ActionRedirect:
+		return "redirect"
+	case ActionRewrite:
+		return "rewrite"
+	case ActionProxy:
+		return "proxy"
+	default:
+		return "none"
+	}
+}
+
+// A Response is the outcome of applying rules to a request.
+type Response struct {
+	// Result is the result of the applied rule, with a nil Rule and an
+	// Index of -1 when the action is ActionNone.
+	Result
+
+	// Action is what is done with the request.
+	Action Action
+
+	// Status is the status code of the response, or zero for ActionNone
+	// and ActionProxy, which respond with the status of the content or
+	// upstream.
+	Status int
+}
+
+// Apply returns the response to req as Netlify would serve it, without an
+// HTTP server, for simulating a CDN or testing rules deterministically.
+//
+// Unlike Match, when req.FileExists is true rules which are not forced are
+// skipped, as content shadows them, as a Handler with Content does.
+// Exclusion rules still apply.
+func Apply(rules []Rule, req Request) Response {
+	var res Result
+	var ok bool
+
+	if req.FileExists {
+		res, ok = matchForced(rules, req)
+	} else {
+		res, ok = Match(rules, req)
+	}
+
+	return newResponse(res, ok)
+}
+
+// newResponse returns the response of a match result.
+func newResponse(res Result, ok bool) Response {
+	if !ok {
+		return Response{Result: Result{Index: -1}}
+	}
+
+	r := Response{Result: res, Status: res.Rule.status()}
+
+	switch {
+	case res.Rule.IsRewrite() && res.Rule.IsProxy():
+		r.Action = ActionProxy
+		r.Status = 0
+	case res.Rule.IsContent():
+		r.Action = ActionRewrite
+	default:
+		r.Action = ActionRedirect
+	}
+
+	return r
+}
+
+// matchForced returns the result of the first forced or exclusion rule
+// matching req.
+func matchForced(rules []Rule, req Request) (Result, bool) {
+	var forced []Rule
+	var index []int
+
+	for i, r := range rules {
+		if r.Force || isExclusion(r.From) {
+			forced = append(forced, r)
+			index = append(index, i)
+		}
+	}
+
+	res, ok := Match(forced, req)
+	if !ok {
+		return res, false
+	}
+
+	res.Index = index[res.Index]
+	res.Rule = &rules[res.Index]
+	return res, true
+}
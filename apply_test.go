@@ -0,0 +1,59 @@
+package redirects_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestApply(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		!/api/health
+		/home         /
+		/api/*        https://api.example.com/:splat  200!
+		/app/*        /app/index.html  200
+		/gone         /410.html  410!
+		/             /de  302  Country=de
+		/*            /404.html  404
+	`, redirects.WithExclusionRules()))
+
+	cases := []struct {
+		name   string
+		req    redirects.Request
+		action redirects.Action
+		status int
+		index  int
+		to     string
+	}{
+		{"redirect", redirects.Request{Path: "/home", Query: url.Values{"a": {"b"}}}, redirects.ActionRedirect, 301, 1, "/?a=b"},
+		{"proxy", redirects.Request{Path: "/api/users"}, redirects.ActionProxy, 0, 2, "https://api.example.com/users"},
+		{"rewrite", redirects.Request{Path: "/app/settings"}, redirects.ActionRewrite, 200, 3, "/app/index.html"},
+		{"content", redirects.Request{Path: "/gone"}, redirects.ActionRewrite, 410, 4, "/410.html"},
+		{"country", redirects.Request{Path: "/", Country: "DE"}, redirects.ActionRedirect, 302, 5, "/de"},
+		{"not found", redirects.Request{Path: "/missing"}, redirects.ActionRewrite, 404, 6, "/404.html"},
+		{"excluded", redirects.Request{Path: "/api/health"}, redirects.ActionNone, 0, -1, ""},
+		{"shadowed", redirects.Request{Path: "/home", FileExists: true}, redirects.ActionNone, 0, -1, ""},
+		{"shadowed rewrite", redirects.Request{Path: "/app/settings", FileExists: true}, redirects.ActionNone, 0, -1, ""},
+		{"forced", redirects.Request{Path: "/gone", FileExists: true}, redirects.ActionRewrite, 410, 4, "/410.html"},
+		{"forced proxy", redirects.Request{Path: "/api/users", FileExists: true}, redirects.ActionProxy, 0, 2, "https://api.example.com/users"},
+		{"excluded existing", redirects.Request{Path: "/api/health", FileExists: true}, redirects.ActionNone, 0, -1, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := redirects.Apply(rules, c.req)
+			assert.Equal(t, c.action, res.Action, res.Action.String())
+			assert.Equal(t, c.status, res.Status)
+			assert.Equal(t, c.index, res.Index)
+			assert.Equal(t, c.to, res.To)
+
+			if c.index >= 0 {
+				assert.Same(t, &rules[c.index], res.Rule)
+			} else {
+				assert.Nil(t, res.Rule)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package redirects
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+// arenas are the arenas of released rules.
+var arenas = sync.Pool{
+	New: func() interface{} {
+		return new(arena)
+	},
+}
+
+// arena holds the rule slice and params of parsed rules for reuse.
+type arena struct {
+	rules  []Rule
+	params []Params
+	used   int
+}
+
+// newParams returns empty params, reused from the arena when there is one.
+func (a *arena) newParams() Params {
+	if a == nil {
+		return make(Params)
+	}
+
+	if a.used == len(a.params) {
+		a.params = append(a.params, make(Params))
+	}
+
+	p := a.params[a.used]
+	a.used++
+	return p
+}
+
+// reset clears the arena for reuse.
+func (a *arena) reset() {
+	for i := range a.rules {
+		a.rules[i] = Rule{}
+	}
+	a.rules = a.rules[:0]
+
+	for _, p := range a.params[:a.used] {
+		for k := range p {
+			delete(p, k)
+		}
+	}
+	a.used = 0
+}
+
+// PooledRules are rules parsed by ParsePooled, whose memory is reused by
+// later parses once released.
+type PooledRules struct {
+	// Rules are the parsed rules, which must not be used after Release.
+	Rules []Rule
+
+	arena *arena
+}
+
+// ParsePooled parses the given reader as Parse does, allocating the rules
+// and their params from a pool, for services parsing many files such as
+// per-tenant redirects. Release the rules once they are no longer used.
+func ParsePooled(r io.Reader, opts ...Option) (*PooledRules, error) {
+	a := arenas.Get().(*arena)
+
+	rules, err := parse(r, newConfig(opts), a)
+	if err != nil {
+		a.reset()
+		arenas.Put(a)
+		return nil, err
+	}
+
+	a.rules = rules
+	return &PooledRules{Rules: rules, arena: a}, nil
+}
+
+// ParsePooledString parses the given string as ParsePooled does.
+func ParsePooledString(s string, opts ...Option) (*PooledRules, error) {
+	return ParsePooled(strings.NewReader(s), opts...)
+}
+
+// Release returns the memory of the rules to the pool. Releasing more than
+// once has no effect.
+func (p *PooledRules) Release() {
+	if p.arena == nil {
+		return
+	}
+
+	p.arena.reset()
+	arenas.Put(p.arena)
+	p.arena = nil
+	p.Rules = nil
+}
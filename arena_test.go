@@ -0,0 +1,73 @@
+package redirects_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestParsePooled(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		p, err := redirects.ParsePooledString(`
+			/store id=:id  /blog/:id  301
+			/news          /blog
+		`)
+		assert.NoError(t, err)
+		assert.Equal(t, redirects.Must(redirects.ParseString(`
+			/store id=:id  /blog/:id  301
+			/news          /blog
+		`)), p.Rules)
+
+		p.Release()
+		assert.Nil(t, p.Rules)
+		p.Release()
+	})
+
+	t.Run("reuse", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			p, err := redirects.ParsePooledString(fmt.Sprintf("/a p%d=:v  /b/:v\n/c  /d", i))
+			assert.NoError(t, err)
+			assert.Len(t, p.Rules, 2)
+			assert.Equal(t, redirects.Params{fmt.Sprintf("p%d", i): ":v"}, p.Rules[0].Params)
+			assert.Nil(t, p.Rules[1].Params)
+			p.Release()
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := redirects.ParsePooledString("/a")
+		assert.True(t, errors.Is(err, redirects.ErrMissingDestination))
+	})
+}
+
+// tenantFile returns a _redirects file with n rules.
+func tenantFile(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "/legacy/%d utm_source=:source  /pages/%d?utm_source=:source  301\n", i, i)
+	}
+	return b.String()
+}
+
+func BenchmarkParse(b *testing.B) {
+	file := tenantFile(100)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		redirects.ParseString(file)
+	}
+}
+
+func BenchmarkParsePooled(b *testing.B) {
+	file := tenantFile(100)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		p, _ := redirects.ParsePooledString(file)
+		p.Release()
+	}
+}
@@ -0,0 +1,134 @@
+package redirects
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// An OpenRedirect reports a rule which may send visitors to an arbitrary
+// or untrusted host.
+type OpenRedirect struct {
+	// Index is the position of the rule in the audited slice.
+	Index int
+
+	// Rule is the flagged rule.
+	Rule Rule
+
+	// Reason describes the problem.
+	Reason string
+}
+
+// String returns a description of the finding.
+func (o OpenRedirect) String() string {
+	return fmt.Sprintf("rule %d (%s): %s", o.Index, o.Rule.From, o.Reason)
+}
+
+// AuditOpenRedirects returns the rules whose destination host may be
+// controlled by the visitor, such as "https://:host/" or "/:splat" which
+// yields a protocol-relative "//evil.example" for "/evil.example" splats,
+// along with rules whose destination host is not in allowedHosts. Hosts may
+// use a leading wildcard such as "*.example.com". When allowedHosts is empty
+// only visitor controlled hosts are reported.
+func AuditOpenRedirects(rules []Rule, allowedHosts []string) (found []OpenRedirect) {
+	for i, r := range rules {
+		if reason := auditRule(r, allowedHosts); reason != "" {
+			found = append(found, OpenRedirect{
+				Index:  i,
+				Rule:   r,
+				Reason: reason,
+			})
+		}
+	}
+
+	return
+}
+
+// auditRule returns the reason r is an open redirect, or an empty string.
+func auditRule(r Rule, allowedHosts []string) string {
+	to := r.To
+
+	switch {
+	case strings.HasPrefix(to, ":"):
+		return fmt.Sprintf("destination %q starts with a placeholder", to)
+	case strings.HasPrefix(to, "/:"):
+		name := placeholder.FindString(to[1:])
+		if name == ":splat" || isParamPlaceholder(r, name) {
+			return fmt.Sprintf("destination %q starts with %s which may contain a host such as /evil.example", to, name)
+		}
+		return ""
+	case strings.HasPrefix(to, "/") && !strings.HasPrefix(to, "//"):
+		return ""
+	}
+
+	// absolute or protocol-relative
+	if strings.HasPrefix(to, "//") {
+		to = "http:" + to
+	}
+
+	if host := authority(to); placeholder.MatchString(host) {
+		return fmt.Sprintf("destination host %q contains a placeholder", host)
+	}
+
+	u, err := url.Parse(to)
+	if err != nil {
+		return fmt.Sprintf("destination %q is not a valid URL", r.To)
+	}
+
+	if len(allowedHosts) > 0 && !hostAllowed(u.Hostname(), allowedHosts) {
+		return fmt.Sprintf("destination host %q is not allowed", u.Hostname())
+	}
+
+	return ""
+}
+
+// authority returns the authority section of an absolute URL, without
+// parsing it, as placeholders are not valid URL syntax.
+func authority(s string) string {
+	i := strings.Index(s, "//")
+	if i < 0 {
+		return ""
+	}
+
+	s = s[i+2:]
+	if j := strings.IndexAny(s, "/?#"); j >= 0 {
+		s = s[:j]
+	}
+
+	return s
+}
+
+// isParamPlaceholder returns true if name is bound by a query param of r,
+// which the visitor controls entirely.
+func isParamPlaceholder(r Rule, name string) bool {
+	for _, v := range r.Params {
+		if v == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hostAllowed returns true if host matches one of the allowed hosts,
+// comparing internationalized hosts by their punycode.
+func hostAllowed(host string, allowed []string) bool {
+	host = strings.ToLower(asciiHost(host))
+
+	for _, a := range allowed {
+		a = strings.ToLower(asciiHost(a))
+
+		if strings.HasPrefix(a, "*.") {
+			if strings.HasSuffix(host, a[1:]) {
+				return true
+			}
+			continue
+		}
+
+		if host == a {
+			return true
+		}
+	}
+
+	return false
+}
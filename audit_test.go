@@ -0,0 +1,47 @@
+package redirects_test
+
+import (
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestAuditOpenRedirects(t *testing.T) {
+	rules := []redirects.Rule{
+		{From: "/home", To: "/", Status: 301},
+		{From: "/go/*", To: "/:splat", Status: 302},
+		{From: "/next", To: "/:url", Status: 302, Params: redirects.Params{"url": ":url"}},
+		{From: "/blog/:slug", To: "/:slug", Status: 301},
+		{From: "/out/:host", To: "https://:host/", Status: 302},
+		{From: "/api/*", To: "https://api.example.com/:splat", Status: 200},
+		{From: "/cdn/*", To: "https://assets.cdn.example.com/:splat", Status: 200},
+		{From: "/evil", To: "//evil.example/", Status: 302},
+		{From: "/google", To: "https://www.google.com", Status: 302},
+	}
+
+	t.Run("without allowlist", func(t *testing.T) {
+		found := redirects.AuditOpenRedirects(rules, nil)
+
+		var indexes []int
+		for _, f := range found {
+			indexes = append(indexes, f.Index)
+		}
+
+		assert.Equal(t, []int{1, 2, 4}, indexes)
+		assert.Equal(t, `rule 1 (/go/*): destination "/:splat" starts with :splat which may contain a host such as /evil.example`, found[0].String())
+		assert.Equal(t, `rule 4 (/out/:host): destination host ":host" contains a placeholder`, found[2].String())
+	})
+
+	t.Run("with allowlist", func(t *testing.T) {
+		found := redirects.AuditOpenRedirects(rules, []string{"api.example.com", "*.cdn.example.com"})
+
+		var indexes []int
+		for _, f := range found {
+			indexes = append(indexes, f.Index)
+		}
+
+		assert.Equal(t, []int{1, 2, 4, 7, 8}, indexes)
+		assert.Equal(t, `destination host "www.google.com" is not allowed`, found[4].Reason)
+	})
+}
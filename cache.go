@@ -0,0 +1,94 @@
+package redirects
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// A MatcherCache holds compiled rules keyed by the content hash or CID of
+// their _redirects file, so servers hosting many sites compile each
+// version of a site's rules once. As the key identifies the content,
+// entries never go stale and are only evicted when least recently used.
+//
+// MatcherCache is safe for concurrent use.
+type MatcherCache struct {
+	opts    []CompileOption
+	lru     *lru
+	mu      sync.Mutex
+	loading map[string]*loadCall
+}
+
+// loadCall is a load in progress, shared by concurrent callers.
+type loadCall struct {
+	done  chan struct{}
+	rules *CompiledRules
+	err   error
+}
+
+// NewMatcherCache returns a cache of up to size compiled rule sets, each
+// compiled with opts.
+func NewMatcherCache(size int, opts ...CompileOption) *MatcherCache {
+	return &MatcherCache{
+		opts:    opts,
+		lru:     newLRU(size),
+		loading: make(map[string]*loadCall),
+	}
+}
+
+// Get returns the compiled rules of key.
+func (c *MatcherCache) Get(key string) (*CompiledRules, bool) {
+	v, ok := c.lru.get(key)
+	if !ok {
+		return nil, false
+	}
+
+	return v.(*CompiledRules), true
+}
+
+// Add compiles and caches the rules of key.
+func (c *MatcherCache) Add(key string, rules []Rule) *CompiledRules {
+	compiled := Compile(rules, c.opts...)
+	c.lru.add(key, compiled)
+	return compiled
+}
+
+// Load returns the compiled rules of key, calling load and compiling its
+// rules when they are not cached. Concurrent loads of a key share a single
+// call, and errors are not cached.
+func (c *MatcherCache) Load(key string, load func() ([]Rule, error)) (*CompiledRules, error) {
+	if compiled, ok := c.Get(key); ok {
+		return compiled, nil
+	}
+
+	c.mu.Lock()
+	if call, ok := c.loading[key]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.rules, call.err
+	}
+
+	call := &loadCall{done: make(chan struct{})}
+	c.loading[key] = call
+	c.mu.Unlock()
+
+	rules, err := load()
+	if err == nil {
+		call.rules = c.Add(key, rules)
+	}
+	call.err = err
+
+	c.mu.Lock()
+	delete(c.loading, key)
+	c.mu.Unlock()
+	close(call.done)
+
+	return call.rules, call.err
+}
+
+// ContentHash returns the hex SHA-256 of a _redirects file, for use as a
+// MatcherCache key when there is no CID.
+func ContentHash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
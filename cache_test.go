@@ -0,0 +1,82 @@
+package redirects_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/pkg/errors"
+	"github.com/tj/assert"
+)
+
+func TestMatcherCache(t *testing.T) {
+	t.Run("load", func(t *testing.T) {
+		c := redirects.NewMatcherCache(2)
+		file := []byte("/home  /\n")
+		key := redirects.ContentHash(file)
+
+		var loads int32
+		load := func() ([]redirects.Rule, error) {
+			atomic.AddInt32(&loads, 1)
+			return redirects.ParseString(string(file))
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				compiled, err := c.Load(key, load)
+				assert.NoError(t, err)
+				res, ok := compiled.Match(redirects.Request{Path: "/home"})
+				assert.True(t, ok)
+				assert.Equal(t, "/", res.To)
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), loads)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		c := redirects.NewMatcherCache(2)
+
+		_, err := c.Load("bafyfail", func() ([]redirects.Rule, error) {
+			return nil, errors.New("boom")
+		})
+		assert.EqualError(t, err, "boom")
+
+		_, ok := c.Get("bafyfail")
+		assert.False(t, ok)
+	})
+
+	t.Run("evict", func(t *testing.T) {
+		c := redirects.NewMatcherCache(2)
+		c.Add("a", nil)
+		c.Add("b", nil)
+		c.Get("a")
+		c.Add("c", nil)
+
+		_, ok := c.Get("a")
+		assert.True(t, ok)
+		_, ok = c.Get("b")
+		assert.False(t, ok)
+	})
+
+	t.Run("ipfs", func(t *testing.T) {
+		c := redirects.NewMatcherCache(10, redirects.WithCacheSize(100))
+		fsys := unixFS{"bafyok/_redirects": "/home  /\n"}
+
+		compiled, err := c.Load("bafyok", func() ([]redirects.Rule, error) {
+			return redirects.LoadIPFS(context.Background(), fsys, "bafyok")
+		})
+		assert.NoError(t, err)
+		assert.Len(t, compiled.Rules(), 1)
+	})
+}
+
+func TestContentHash(t *testing.T) {
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", redirects.ContentHash(nil))
+}
@@ -0,0 +1,150 @@
+package redirects
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// WriteCaddyfile writes a Caddyfile route block implementing rules, to be
+// placed in a site block before its file_server directive. Each rule is a
+// handle block so only the first matching rule applies, using redir for
+// redirects, rewrite for rewrites, reverse_proxy for proxies and the
+// file_server status for content responses such as 404. Method, Host and
+// header conditions and query params are supported, while rules with other
+// conditions are skipped and reported.
+func WriteCaddyfile(w io.Writer, rules []Rule) ([]Unsupported, error) {
+	var skipped []Unsupported
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# Generated from _redirects rules.")
+	fmt.Fprintln(bw, "route {")
+
+	for i, r := range rules {
+		if reason := caddyUnsupported(r); reason != "" {
+			skipped = append(skipped, Unsupported{Index: i, Rule: r, Reason: reason})
+			continue
+		}
+
+		name := "r" + strconv.Itoa(i)
+		to := caddyRoute(bw, name, r)
+
+		fmt.Fprintf(bw, "\thandle @%s {\n", name)
+
+		switch {
+		case r.IsRewrite() && r.IsProxy():
+			u, _ := url.Parse(r.To)
+			upstream := u.Scheme + "://" + u.Host
+			path := strings.TrimPrefix(to, upstream)
+			if path == "" {
+				path = "/"
+			}
+			fmt.Fprintf(bw, "\t\trewrite %s\n", caddyQuote(path))
+			fmt.Fprintf(bw, "\t\treverse_proxy %s {\n", upstream)
+			fmt.Fprintln(bw, "\t\t\theader_up Host {upstream_hostport}")
+			fmt.Fprintln(bw, "\t\t}")
+		case r.IsRewrite():
+			fmt.Fprintf(bw, "\t\trewrite %s\n", caddyQuote(to))
+		case r.IsContent():
+			fmt.Fprintf(bw, "\t\trewrite %s\n", caddyQuote(to))
+			fmt.Fprintf(bw, "\t\tfile_server {\n\t\t\tstatus %d\n\t\t}\n", r.Status)
+		default:
+			if len(r.Params) == 0 && !strings.Contains(to, "?") {
+				to += "{?query}"
+			}
+			fmt.Fprintf(bw, "\t\tredir %s %d\n", caddyQuote(to), r.Status)
+		}
+
+		fmt.Fprintln(bw, "\t}")
+	}
+
+	fmt.Fprintln(bw, "}")
+	return skipped, bw.Flush()
+}
+
+// caddyRoute writes the named matcher of r, returning its destination
+// with placeholders replaced by Caddy placeholders.
+func caddyRoute(w io.Writer, name string, r Rule) string {
+	captures := make(map[string]string)
+
+	if placeholder.MatchString(r.From) || wildcards(segments(r.From)) > 0 {
+		pattern, names := patternRegexp(r.From)
+		fmt.Fprintf(w, "\t@%s {\n\t\tpath_regexp %s %s\n", name, name, caddyQuote(pattern))
+		for i, n := range names {
+			captures[n] = fmt.Sprintf("{re.%s.%d}", name, i+1)
+		}
+	} else {
+		p := strings.TrimSuffix(r.From, "/")
+		if p == "" {
+			fmt.Fprintf(w, "\t@%s {\n\t\tpath /\n", name)
+		} else {
+			fmt.Fprintf(w, "\t@%s {\n\t\tpath %s %s\n", name, caddyQuote(p), caddyQuote(p+"/"))
+		}
+	}
+
+	for _, k := range r.Params.keys() {
+		v, ok := r.Params[k].(string)
+		switch {
+		case !ok:
+			fmt.Fprintf(w, "\t\tquery %s\n", caddyQuote(k+"=*"))
+		case strings.HasPrefix(v, ":"):
+			fmt.Fprintf(w, "\t\tquery %s\n", caddyQuote(k+"=*"))
+			captures[v[1:]] = "{query." + k + "}"
+		default:
+			fmt.Fprintf(w, "\t\tquery %s\n", caddyQuote(k+"="+v))
+		}
+	}
+
+	if len(r.Method) > 0 {
+		fmt.Fprintf(w, "\t\tmethod %s\n", strings.ToUpper(strings.Join(r.Method, " ")))
+	}
+
+	if len(r.Host) > 0 {
+		fmt.Fprintf(w, "\t\thost %s\n", strings.Join(r.Host, " "))
+	}
+
+	for _, k := range r.Conditions.keys() {
+		fmt.Fprintf(w, "\t\theader %s %s\n", headerCondition(k), caddyQuote(r.Conditions[k]))
+	}
+
+	fmt.Fprintln(w, "\t}")
+
+	return placeholder.ReplaceAllStringFunc(splatTo(r.From, r.To), func(s string) string {
+		if v, ok := captures[s[1:]]; ok {
+			return v
+		}
+		return s
+	})
+}
+
+// caddyUnsupported returns the reason r cannot be written as a Caddyfile
+// route, or an empty string.
+func caddyUnsupported(r Rule) string {
+	switch {
+	case isExclusion(r.From):
+		return "exclusion rules are not supported"
+	case isRegexp(r.From):
+		return "regular expression sources are not supported"
+	case !strings.HasPrefix(r.From, "/"):
+		return "absolute source URLs are not supported"
+	case len(r.Country) > 0 || len(r.Language) > 0:
+		return "Country and Language conditions are not supported"
+	case !r.ActiveFrom.IsZero() || !r.ActiveUntil.IsZero():
+		return "scheduled rules are not supported"
+	default:
+		return ""
+	}
+}
+
+// caddyQuote returns s quoted as a Caddyfile token when it is empty or
+// contains whitespace or quotes.
+func caddyQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"") {
+		return s
+	}
+
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -0,0 +1,86 @@
+package redirects_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestWriteCaddyfile(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/home              /
+		/blog/:year/:slug  /posts/:year/:slug  302
+		/store id=:id      /item/:id
+		/app/*             /index.html  200
+		/api/*             https://api.example.com/v1/:splat  200  Method=GET,post
+		/closed            /gone.html  410
+		/                  /canary  200  Header:X-Canary=true  Host=*.example.com
+		/                  /anz  302  Country=au
+	`))
+
+	var b strings.Builder
+	skipped, err := redirects.WriteCaddyfile(&b, rules)
+	assert.NoError(t, err)
+
+	assert.Len(t, skipped, 1)
+	assert.Equal(t, "rule 7 (/): Country and Language conditions are not supported", skipped[0].String())
+
+	assert.Equal(t, `# Generated from _redirects rules.
+route {
+	@r0 {
+		path /home /home/
+	}
+	handle @r0 {
+		redir /{?query} 301
+	}
+	@r1 {
+		path_regexp r1 ^/blog/([^/]+)/([^/]+)/?$
+	}
+	handle @r1 {
+		redir /posts/{re.r1.1}/{re.r1.2}{?query} 302
+	}
+	@r2 {
+		path /store /store/
+		query id=*
+	}
+	handle @r2 {
+		redir /item/{query.id} 301
+	}
+	@r3 {
+		path_regexp r3 ^/app(?:/(.*))?/?$
+	}
+	handle @r3 {
+		rewrite /index.html
+	}
+	@r4 {
+		path_regexp r4 ^/api(?:/(.*))?/?$
+		method GET POST
+	}
+	handle @r4 {
+		rewrite /v1/{re.r4.1}
+		reverse_proxy https://api.example.com {
+			header_up Host {upstream_hostport}
+		}
+	}
+	@r5 {
+		path /closed /closed/
+	}
+	handle @r5 {
+		rewrite /gone.html
+		file_server {
+			status 410
+		}
+	}
+	@r6 {
+		path /
+		host *.example.com
+		header X-Canary true
+	}
+	handle @r6 {
+		rewrite /canary
+	}
+}
+`, b.String())
+}
@@ -0,0 +1,130 @@
+// Package caddyredirects is a Caddy HTTP handler module serving a Netlify
+// _redirects file with github.com/fission-suite/go-redirects.
+package caddyredirects
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/caddyserver/caddy/v2/caddyconfig/httpcaddyfile"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/fission-suite/go-redirects"
+)
+
+func init() {
+	caddy.RegisterModule(Redirects{})
+	httpcaddyfile.RegisterHandlerDirective("redirects", parseCaddyfile)
+}
+
+// Redirects serves the rules of a _redirects file in front of the next
+// handler, which serves unmatched requests and the destination of rewrites.
+type Redirects struct {
+	// File is the path of the _redirects file.
+	File string `json:"file,omitempty"`
+
+	// AllowedProxyHosts restricts the hosts proxy rules may forward to.
+	AllowedProxyHosts []string `json:"allowed_proxy_hosts,omitempty"`
+
+	handler *redirects.Handler
+}
+
+// CaddyModule returns the Caddy module information.
+func (Redirects) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID:  "http.handlers.redirects",
+		New: func() caddy.Module { return new(Redirects) },
+	}
+}
+
+// Provision parses the _redirects file.
+func (m *Redirects) Provision(ctx caddy.Context) error {
+	f, err := os.Open(m.File)
+	if err != nil {
+		return fmt.Errorf("opening: %w", err)
+	}
+	defer f.Close()
+
+	rules, err := redirects.Parse(f, redirects.WithAllowedProxyHosts(m.AllowedProxyHosts...))
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", m.File, err)
+	}
+
+	m.handler = &redirects.Handler{
+		Rules:             rules,
+		AllowedProxyHosts: m.AllowedProxyHosts,
+	}
+
+	return nil
+}
+
+// Validate returns an error if no file is configured.
+func (m *Redirects) Validate() error {
+	if m.File == "" {
+		return fmt.Errorf("missing _redirects file")
+	}
+
+	return nil
+}
+
+// ServeHTTP implementation.
+func (m *Redirects) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhttp.Handler) error {
+	var err error
+
+	m.handler.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = next.ServeHTTP(w, r)
+	})).ServeHTTP(w, r)
+
+	return err
+}
+
+// UnmarshalCaddyfile sets up the handler from Caddyfile tokens:
+//
+//	redirects [<file>] {
+//		file <file>
+//		allowed_proxy_hosts <hosts...>
+//	}
+func (m *Redirects) UnmarshalCaddyfile(d *caddyfile.Dispenser) error {
+	for d.Next() {
+		if d.NextArg() {
+			m.File = d.Val()
+		}
+
+		if d.NextArg() {
+			return d.ArgErr()
+		}
+
+		for d.NextBlock(0) {
+			switch d.Val() {
+			case "file":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				m.File = d.Val()
+			case "allowed_proxy_hosts":
+				m.AllowedProxyHosts = append(m.AllowedProxyHosts, d.RemainingArgs()...)
+			default:
+				return d.Errf("unrecognized subdirective %q", d.Val())
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseCaddyfile returns the handler of a redirects directive.
+func parseCaddyfile(h httpcaddyfile.Helper) (caddyhttp.MiddlewareHandler, error) {
+	m := new(Redirects)
+	err := m.UnmarshalCaddyfile(h.Dispenser)
+	return m, err
+}
+
+// Interface guards.
+var (
+	_ caddy.Provisioner           = (*Redirects)(nil)
+	_ caddy.Validator             = (*Redirects)(nil)
+	_ caddyhttp.MiddlewareHandler = (*Redirects)(nil)
+	_ caddyfile.Unmarshaler       = (*Redirects)(nil)
+)
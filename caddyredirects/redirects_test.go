@@ -0,0 +1,122 @@
+package caddyredirects_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/fission-suite/go-redirects/caddyredirects"
+	"github.com/tj/assert"
+)
+
+func TestRedirects_UnmarshalCaddyfile(t *testing.T) {
+	t.Run("argument", func(t *testing.T) {
+		var m caddyredirects.Redirects
+		err := m.UnmarshalCaddyfile(caddyfile.NewTestDispenser(`redirects public/_redirects`))
+		assert.NoError(t, err)
+		assert.Equal(t, "public/_redirects", m.File)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		var m caddyredirects.Redirects
+		err := m.UnmarshalCaddyfile(caddyfile.NewTestDispenser(`redirects {
+			file public/_redirects
+			allowed_proxy_hosts api.example.com *.example.net
+		}`))
+		assert.NoError(t, err)
+		assert.Equal(t, "public/_redirects", m.File)
+		assert.Equal(t, []string{"api.example.com", "*.example.net"}, m.AllowedProxyHosts)
+	})
+
+	t.Run("unknown subdirective", func(t *testing.T) {
+		var m caddyredirects.Redirects
+		err := m.UnmarshalCaddyfile(caddyfile.NewTestDispenser(`redirects {
+			bogus
+		}`))
+		assert.Error(t, err)
+	})
+}
+
+// provision returns the module provisioned with rules written to a file.
+func provision(t *testing.T, rules string) (*caddyredirects.Redirects, error) {
+	path := filepath.Join(t.TempDir(), "_redirects")
+	assert.NoError(t, os.WriteFile(path, []byte(rules), 0644))
+
+	ctx, cancel := caddy.NewContext(caddy.Context{Context: context.Background()})
+	t.Cleanup(cancel)
+
+	m := &caddyredirects.Redirects{File: path}
+	return m, m.Provision(ctx)
+}
+
+func TestRedirects_Provision(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		_, err := provision(t, "/home  /\n")
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := provision(t, "/home\n")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "parsing")
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		ctx, cancel := caddy.NewContext(caddy.Context{Context: context.Background()})
+		defer cancel()
+
+		m := &caddyredirects.Redirects{File: filepath.Join(t.TempDir(), "_redirects")}
+		assert.Error(t, m.Provision(ctx))
+	})
+}
+
+func TestRedirects_ServeHTTP(t *testing.T) {
+	m, err := provision(t, `
+		/home   /       302
+		/app/*  /index.html  200
+	`)
+	assert.NoError(t, err)
+
+	var served []string
+	next := caddyhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		served = append(served, r.URL.Path)
+		return nil
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		assert.NoError(t, m.ServeHTTP(w, httptest.NewRequest("GET", "/home", nil), next))
+		assert.Equal(t, 302, w.Code)
+		assert.Equal(t, "/", w.Header().Get("Location"))
+		assert.Empty(t, served)
+	})
+
+	t.Run("rewrite", func(t *testing.T) {
+		served = nil
+		w := httptest.NewRecorder()
+		assert.NoError(t, m.ServeHTTP(w, httptest.NewRequest("GET", "/app/settings", nil), next))
+		assert.Equal(t, []string{"/index.html"}, served)
+	})
+
+	t.Run("pass through", func(t *testing.T) {
+		served = nil
+		w := httptest.NewRecorder()
+		assert.NoError(t, m.ServeHTTP(w, httptest.NewRequest("GET", "/about", nil), next))
+		assert.Equal(t, []string{"/about"}, served)
+	})
+
+	t.Run("next error", func(t *testing.T) {
+		failing := caddyhttp.HandlerFunc(func(http.ResponseWriter, *http.Request) error {
+			return caddyhttp.Error(http.StatusNotFound, nil)
+		})
+
+		err := m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/about", nil), failing)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,42 @@
+package redirects
+
+import (
+	"fmt"
+	"log"
+)
+
+// A Divergence is a request which the Candidate rules of a Handler would
+// serve differently than the active rules.
+type Divergence struct {
+	// Request is the request.
+	Request Request
+
+	// Active is the response served by the active rules.
+	Active Response
+
+	// Candidate is the response the Candidate rules would have served.
+	Candidate Response
+}
+
+// String returns a description of the divergence for logging.
+func (d Divergence) String() string {
+	return fmt.Sprintf("%s %s: active %s, candidate %s", d.Request.Method, d.Request.Path, describeResponse(d.Active), describeResponse(d.Candidate))
+}
+
+// candidate matches req against the Candidate rules, reporting a divergence
+// from the result of the active rules.
+func (h *Handler) candidate(req Request, res Result, ok bool) {
+	active := newResponse(res, ok)
+	candidate := newResponse(matchContent(h.Candidate, nil, req))
+	if sameResponse(active, candidate) {
+		return
+	}
+
+	d := Divergence{Request: req, Active: active, Candidate: candidate}
+	if h.OnDivergence != nil {
+		h.OnDivergence(d)
+		return
+	}
+
+	log.Printf("redirects: candidate divergence: %s", d)
+}
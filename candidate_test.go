@@ -0,0 +1,55 @@
+package redirects_test
+
+import (
+	"bytes"
+	"log"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestHandler_Candidate(t *testing.T) {
+	active := redirects.Must(redirects.ParseString("/blog/*  /posts/:splat\n/old  /new\n"))
+	candidate := redirects.Must(redirects.ParseString("/blog/*  /articles/:splat  302\n/old  /new\n/app/*  /index.html  200\n"))
+
+	t.Run("divergences", func(t *testing.T) {
+		var found []redirects.Divergence
+		h := &redirects.Handler{
+			Rules:        active,
+			Next:         files,
+			Candidate:    redirects.Compile(candidate),
+			OnDivergence: func(d redirects.Divergence) { found = append(found, d) },
+		}
+
+		for _, path := range []string{"/blog/a", "/old", "/app/settings", "/about"} {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/blog/b", nil))
+		assert.Equal(t, 301, w.Code)
+		assert.Equal(t, "/posts/b", w.Header().Get("Location"))
+
+		assert.Len(t, found, 3)
+		assert.Equal(t, "GET /blog/a: active redirect 301 /posts/a, candidate redirect 302 /articles/a", found[0].String())
+		assert.Equal(t, "GET /app/settings: active none, candidate rewrite 200 /index.html", found[1].String())
+		assert.Equal(t, redirects.ActionRewrite, found[1].Candidate.Action)
+	})
+
+	t.Run("log", func(t *testing.T) {
+		var b bytes.Buffer
+		log.SetOutput(&b)
+		log.SetFlags(0)
+		defer func() {
+			log.SetOutput(os.Stderr)
+			log.SetFlags(log.LstdFlags)
+		}()
+
+		h := &redirects.Handler{Rules: active, Next: files, Candidate: redirects.Compile(candidate)}
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/blog/a", nil))
+		assert.Equal(t, "redirects: candidate divergence: GET /blog/a: active redirect 301 /posts/a, candidate redirect 302 /articles/a\n", b.String())
+	})
+}
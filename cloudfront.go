@@ -0,0 +1,122 @@
+package redirects
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// cloudFrontRule is a rule of a generated CloudFront Function.
+type cloudFrontRule struct {
+	Pattern string   `json:"pattern"`
+	Names   []string `json:"names"`
+	To      string   `json:"to"`
+	Status  int      `json:"status"`
+}
+
+// cloudFrontHandler is the CloudFront Function matching the rules table.
+const cloudFrontHandler = `
+rules.forEach(function (rule) {
+  rule.regexp = new RegExp(rule.pattern);
+});
+
+function query(qs) {
+  var parts = [];
+  Object.keys(qs).forEach(function (key) {
+    var values = qs[key].multiValue || [qs[key]];
+    values.forEach(function (v) {
+      parts.push(encodeURIComponent(key) + "=" + encodeURIComponent(v.value));
+    });
+  });
+  return parts.join("&");
+}
+
+function handler(event) {
+  var request = event.request;
+
+  for (var i = 0; i < rules.length; i++) {
+    var rule = rules[i];
+    var m = rule.regexp.exec(request.uri);
+    if (!m) {
+      continue;
+    }
+
+    var to = rule.to.replace(/:([A-Za-z_][A-Za-z0-9_]*)/g, function (s, name) {
+      var j = rule.names.indexOf(name);
+      return j < 0 ? s : (m[j + 1] || "");
+    });
+
+    if (rule.status === 200) {
+      request.uri = to;
+      return request;
+    }
+
+    var qs = query(request.querystring);
+    if (qs && to.indexOf("?") < 0) {
+      to += "?" + qs;
+    }
+
+    return {
+      statusCode: rule.status,
+      headers: { location: { value: to } }
+    };
+  }
+
+  return request;
+}
+`
+
+// WriteCloudFrontFunction writes a CloudFront Function for viewer requests
+// implementing rules. Redirects and rewrites to local paths are supported,
+// including placeholders and wildcards, while proxies, content responses,
+// query params and conditions are skipped and reported.
+func WriteCloudFrontFunction(w io.Writer, rules []Rule) ([]Unsupported, error) {
+	var table []cloudFrontRule
+	var skipped []Unsupported
+
+	for i, r := range rules {
+		if reason := cloudFrontUnsupported(r); reason != "" {
+			skipped = append(skipped, Unsupported{Index: i, Rule: r, Reason: reason})
+			continue
+		}
+
+		pattern, names := patternRegexp(r.From)
+		table = append(table, cloudFrontRule{
+			Pattern: pattern,
+			Names:   names,
+			To:      splatTo(r.From, r.To),
+			Status:  r.Status,
+		})
+	}
+
+	if table == nil {
+		table = []cloudFrontRule{}
+	}
+
+	b, err := json.MarshalIndent(table, "", "  ")
+	if err != nil {
+		return skipped, err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "// Generated from _redirects rules.")
+	fmt.Fprintf(bw, "var rules = %s;\n", b)
+	fmt.Fprint(bw, cloudFrontHandler)
+	return skipped, bw.Flush()
+}
+
+// cloudFrontUnsupported returns the reason r cannot be implemented by a
+// CloudFront Function, or an empty string.
+func cloudFrontUnsupported(r Rule) string {
+	switch {
+	case isExclusion(r.From):
+		return "exclusion rules are not supported"
+	case r.IsRewrite() && r.IsProxy():
+		return "proxies are not supported"
+	case r.IsContent() && !r.IsRewrite():
+		return "content responses are not supported"
+	default:
+		return unsupportedCondition(r)
+	}
+}
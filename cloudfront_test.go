@@ -0,0 +1,51 @@
+package redirects_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestWriteCloudFrontFunction(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/blog/:year/:slug  /posts/:year/:slug
+		/news/*            /blog/:splat  302
+		/app/*             /index.html  200
+		/api/*             https://api.example.com/:splat  200
+		/gone              /410.html  410
+	`))
+
+	var b strings.Builder
+	skipped, err := redirects.WriteCloudFrontFunction(&b, rules)
+	assert.NoError(t, err)
+
+	assert.Len(t, skipped, 2)
+	assert.Equal(t, "rule 3 (/api/*): proxies are not supported", skipped[0].String())
+	assert.Equal(t, "rule 4 (/gone): content responses are not supported", skipped[1].String())
+
+	js := b.String()
+	assert.Contains(t, js, `"pattern": "^/blog/([^/]+)/([^/]+)/?$"`)
+	assert.Contains(t, js, `"pattern": "^/news(?:/(.*))?/?$"`)
+	assert.Contains(t, js, `"names": [
+      "splat"
+    ]`)
+	assert.Contains(t, js, `"to": "/index.html"`)
+	assert.NotContains(t, js, "api.example.com")
+	assert.Contains(t, js, "function handler(event) {")
+
+	t.Run("extended wildcards", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/docs/*/images/*  /assets/:splat1/:splat
+		`, redirects.WithExtendedWildcards()))
+
+		var b strings.Builder
+		_, err := redirects.WriteCloudFrontFunction(&b, rules)
+		assert.NoError(t, err)
+
+		js := b.String()
+		assert.Contains(t, js, `"pattern": "^/docs/([^/]+)/images(?:/(.*))?/?$"`)
+		assert.Contains(t, js, `"to": "/assets/:splat1/:splat2"`)
+	})
+}
@@ -0,0 +1,25 @@
+// Command redirects-lsp is a Language Server Protocol server for _redirects
+// and netlify.toml files, speaking JSON-RPC over stdin and stdout.
+//
+//	redirects-lsp
+//
+// It publishes the diagnostics of open files, explains the rule under the
+// cursor on hover, and goes to the destination of a rule, being the file it
+// serves relative to the _redirects file or the publish directory of
+// netlify.toml, or otherwise the rule whose source is the destination.
+//
+// Positions are in bytes rather than UTF-16 code units, which only differ
+// on lines with non-ASCII characters.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if err := serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "redirects-lsp: %s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/fission-suite/go-redirects"
+)
+
+// position is a zero based line and column.
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// lspRange is a span of a document, from Start up to but excluding End.
+type lspRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+// location is a span of a document.
+type location struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+// diagnostic is a problem in a document.
+type diagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code,omitempty"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+// textDocumentParams are the params of the supported requests and
+// notifications.
+type textDocumentParams struct {
+	TextDocument struct {
+		URI     string `json:"uri"`
+		Text    string `json:"text"`
+		Version int    `json:"version"`
+	} `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+	Position position `json:"position"`
+}
+
+// hover is the result of a hover request.
+type hover struct {
+	Contents struct {
+		Kind  string `json:"kind"`
+		Value string `json:"value"`
+	} `json:"contents"`
+	Range lspRange `json:"range"`
+}
+
+// span is a rule of a document.
+type span struct {
+	// Rule is the rule.
+	Rule redirects.Rule
+
+	// Start and End are the first and last lines of the rule.
+	Start, End int
+}
+
+// server is a language server for _redirects and netlify.toml files.
+type server struct {
+	w        io.Writer
+	docs     map[string]string
+	shutdown bool
+	err      error
+}
+
+// serve answers the requests read from r until the exit notification.
+func serve(r io.Reader, w io.Writer) error {
+	s := &server{w: w, docs: make(map[string]string)}
+	br := bufio.NewReader(r)
+
+	for {
+		b, err := readMessage(br)
+		if err == io.EOF && s.shutdown {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(b, &req); err != nil {
+			s.reply(json.RawMessage("null"), nil, &responseError{Code: codeParseError, Message: err.Error()})
+			continue
+		}
+
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit before shutdown")
+			}
+			return nil
+		}
+
+		result, rerr := s.handle(req)
+		if req.ID != nil {
+			s.reply(req.ID, result, rerr)
+		}
+
+		if s.err != nil {
+			return s.err
+		}
+	}
+}
+
+// handle returns the result of a request, or nil for notifications.
+func (s *server) handle(req request) (interface{}, *responseError) {
+	var p textDocumentParams
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &responseError{Code: codeInvalidParams, Message: err.Error()}
+		}
+	}
+	uri := p.TextDocument.URI
+
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":   1,
+				"hoverProvider":      true,
+				"definitionProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "redirects-lsp"},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		s.docs[uri] = p.TextDocument.Text
+		s.publish(uri)
+		return nil, nil
+	case "textDocument/didChange":
+		if n := len(p.ContentChanges); n > 0 {
+			s.docs[uri] = p.ContentChanges[n-1].Text
+		}
+		s.publish(uri)
+		return nil, nil
+	case "textDocument/didClose":
+		delete(s.docs, uri)
+		s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+			"uri":         uri,
+			"diagnostics": []diagnostic{},
+		})
+		return nil, nil
+	case "textDocument/hover":
+		return s.hover(uri, p.Position), nil
+	case "textDocument/definition":
+		return s.definition(uri, p.Position), nil
+	}
+
+	if req.ID == nil {
+		return nil, nil
+	}
+
+	return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+// reply sends the response to a request.
+func (s *server) reply(id json.RawMessage, result interface{}, rerr *responseError) {
+	res := response{JSONRPC: "2.0", ID: id, Error: rerr}
+
+	if rerr == nil {
+		b, err := json.Marshal(result)
+		if err != nil {
+			res.Error = &responseError{Code: codeInvalidParams, Message: err.Error()}
+		} else {
+			res.Result = b
+		}
+	}
+
+	s.write(res)
+}
+
+// notify sends a notification.
+func (s *server) notify(method string, params interface{}) {
+	s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// write sends a message, recording the first failure.
+func (s *server) write(v interface{}) {
+	if s.err == nil {
+		s.err = writeMessage(s.w, v)
+	}
+}
+
+// publish sends the diagnostics of a document.
+func (s *server) publish(uri string) {
+	s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+		"uri":         uri,
+		"diagnostics": diagnostics(uri, s.docs[uri]),
+	})
+}
+
+// hover returns the explanation of the rule at pos, or nil.
+func (s *server) hover(uri string, pos position) *hover {
+	text := s.docs[uri]
+	sp, ok := spanAt(spans(uri, text), pos.Line)
+	if !ok {
+		return nil
+	}
+
+	var h hover
+	h.Contents.Kind = "markdown"
+	h.Contents.Value = "```\n" + sp.Rule.String() + "\n```\n\n" + redirects.Explain(sp.Rule)
+	h.Range = linesRange(strings.Split(text, "\n"), sp.Start, sp.End)
+	return &h
+}
+
+// definition returns the destination of the rule at pos, or nil.
+func (s *server) definition(uri string, pos position) *location {
+	text := s.docs[uri]
+	all := spans(uri, text)
+	sp, ok := spanAt(all, pos.Line)
+	if !ok {
+		return nil
+	}
+
+	return destination(uri, text, all, sp.Rule.To)
+}
+
+// isTOML returns true if the document is a netlify.toml file.
+func isTOML(uri string) bool {
+	return path.Ext(uri) == ".toml"
+}
+
+// spans returns the rules of a document which parse.
+func spans(uri, text string) (spans []span) {
+	if isTOML(uri) {
+		for _, r := range parseTOML(text).Redirects {
+			if rules, err := redirects.ParseString(r.Rule); err == nil && len(rules) == 1 {
+				spans = append(spans, span{Rule: rules[0], Start: r.Start, End: r.End})
+			}
+		}
+		return
+	}
+
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if rules, err := redirects.ParseString(line); err == nil && len(rules) == 1 {
+			spans = append(spans, span{Rule: rules[0], Start: n, End: n})
+		}
+	}
+
+	return
+}
+
+// spanAt returns the rule at line n.
+func spanAt(spans []span, n int) (span, bool) {
+	for _, sp := range spans {
+		if sp.Start <= n && n <= sp.End {
+			return sp, true
+		}
+	}
+
+	return span{}, false
+}
+
+// diagnostics returns the problems of a document.
+func diagnostics(uri, text string) []diagnostic {
+	lines := strings.Split(text, "\n")
+	diags := []diagnostic{}
+
+	if !isTOML(uri) {
+		for _, d := range redirects.Diagnostics(strings.NewReader(text)) {
+			diags = append(diags, newDiagnostic(d, lspRange{
+				Start: position{Line: d.Range.Start.Line - 1, Character: d.Range.Start.Column - 1},
+				End:   position{Line: d.Range.End.Line - 1, Character: d.Range.End.Column - 1},
+			}))
+		}
+		return diags
+	}
+
+	t := parseTOML(text)
+	for _, e := range t.Errors {
+		diags = append(diags, diagnostic{
+			Range:    linesRange(lines, e.Line, e.Line),
+			Severity: int(redirects.SeverityError),
+			Source:   "redirects",
+			Message:  e.Err.Error(),
+		})
+	}
+
+	// Each table becomes a _redirects line at the line of its header, so
+	// diagnostics referring to other rules by line remain correct.
+	converted := make([]string, len(lines))
+	tables := make(map[int]tomlRedirect)
+	for _, r := range t.Redirects {
+		if r.Rule != "" {
+			converted[r.Start] = r.Rule
+			tables[r.Start] = r
+		}
+	}
+
+	for _, d := range redirects.Diagnostics(strings.NewReader(strings.Join(converted, "\n"))) {
+		r := tables[d.Range.Start.Line-1]
+		rule := converted[r.Start]
+		tok := rule[d.Range.Start.Column-1 : d.Range.End.Column-1]
+		diags = append(diags, newDiagnostic(d, tokenRange(lines, r, tok)))
+	}
+
+	return diags
+}
+
+// newDiagnostic returns d at rng.
+func newDiagnostic(d redirects.Diagnostic, rng lspRange) diagnostic {
+	return diagnostic{
+		Range:    rng,
+		Severity: int(d.Severity), // the severities match those of the protocol
+		Code:     string(d.Code),
+		Source:   "redirects",
+		Message:  d.Message,
+	}
+}
+
+// tokenRange returns the range of tok within the lines of table r, or of
+// its header when tok is not found, such as when tok is the whole rule.
+func tokenRange(lines []string, r tomlRedirect, tok string) lspRange {
+	for n := r.Start + 1; n <= r.End; n++ {
+		if i := strings.Index(lines[n], `"`+tok+`"`); i >= 0 {
+			return lspRange{
+				Start: position{Line: n, Character: i + 1},
+				End:   position{Line: n, Character: i + 1 + len(tok)},
+			}
+		}
+	}
+
+	return linesRange(lines, r.Start, r.Start)
+}
+
+// linesRange returns the range of the lines from start to end.
+func linesRange(lines []string, start, end int) lspRange {
+	return lspRange{
+		Start: position{Line: start},
+		End:   position{Line: end, Character: len(strings.TrimRight(lines[end], "\r"))},
+	}
+}
+
+// destination returns the location of the destination of a rule in the
+// document at uri: the file served, relative to the _redirects file or the
+// publish directory of netlify.toml, or otherwise the rule whose source is
+// the destination. It returns nil for proxies and destinations with
+// placeholders.
+func destination(uri, text string, spans []span, to string) *location {
+	if !strings.HasPrefix(to, "/") || strings.ContainsAny(to, ":$*") {
+		return nil
+	}
+
+	if i := strings.IndexAny(to, "?#"); i >= 0 {
+		to = to[:i]
+	}
+
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		root := filepath.Dir(filepath.FromSlash(u.Path))
+		if isTOML(uri) {
+			root = filepath.Join(root, filepath.FromSlash(parseTOML(text).Publish))
+		}
+
+		candidates := []string{to, to + "/index.html", to + ".html"}
+		if strings.HasSuffix(to, "/") {
+			candidates = []string{to + "index.html"}
+		}
+
+		for _, c := range candidates {
+			name := filepath.Join(root, filepath.FromSlash(c))
+			if fi, err := os.Stat(name); err == nil && fi.Mode().IsRegular() {
+				return &location{URI: (&url.URL{Scheme: "file", Path: filepath.ToSlash(name)}).String()}
+			}
+		}
+	}
+
+	for _, sp := range spans {
+		if sp.Rule.From == to {
+			return &location{URI: uri, Range: linesRange(strings.Split(text, "\n"), sp.Start, sp.Start)}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+// session returns the messages sent by the server in reply to msgs,
+// followed by shutdown and exit.
+func session(t *testing.T, msgs ...interface{}) (out []map[string]interface{}) {
+	var in, w bytes.Buffer
+	msgs = append(msgs,
+		map[string]interface{}{"jsonrpc": "2.0", "id": 99, "method": "shutdown"},
+		map[string]interface{}{"jsonrpc": "2.0", "method": "exit"},
+	)
+	for _, m := range msgs {
+		assert.NoError(t, writeMessage(&in, m))
+	}
+
+	assert.NoError(t, serve(&in, &w))
+
+	r := bufio.NewReader(&w)
+	for r.Buffered() > 0 || w.Len() > 0 {
+		b, err := readMessage(r)
+		assert.NoError(t, err)
+
+		var m map[string]interface{}
+		assert.NoError(t, json.Unmarshal(b, &m))
+		out = append(out, m)
+	}
+
+	return out[:len(out)-1]
+}
+
+// open returns a didOpen notification.
+func open(uri, text string) map[string]interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "textDocument/didOpen",
+		"params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "text": text},
+		},
+	}
+}
+
+// at returns a request for a position.
+func at(method, uri string, line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri},
+			"position":     map[string]interface{}{"line": line, "character": character},
+		},
+	}
+}
+
+// rng returns a range as decoded from JSON.
+func rng(startLine, startChar, endLine, endChar int) map[string]interface{} {
+	return map[string]interface{}{
+		"start": map[string]interface{}{"line": float64(startLine), "character": float64(startChar)},
+		"end":   map[string]interface{}{"line": float64(endLine), "character": float64(endChar)},
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	for _, length := range []string{"-1", "x", "1073741824"} {
+		t.Run(length, func(t *testing.T) {
+			_, err := readMessage(bufio.NewReader(strings.NewReader("Content-Length: " + length + "\r\n\r\n{}")))
+			assert.Error(t, err)
+		})
+	}
+
+	b, err := readMessage(bufio.NewReader(strings.NewReader("Content-Length: 2\r\n\r\n{}")))
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(b))
+}
+
+func TestServe(t *testing.T) {
+	t.Run("initialize", func(t *testing.T) {
+		out := session(t, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": map[string]interface{}{}})
+		assert.Len(t, out, 1)
+		caps := out[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+		assert.Equal(t, true, caps["hoverProvider"])
+		assert.Equal(t, true, caps["definitionProvider"])
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		out := session(t, open("file:///site/_redirects", "/home  /\n/a  /b  3!01\n"))
+		assert.Equal(t, "textDocument/publishDiagnostics", out[0]["method"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{
+				"range":    rng(1, 8, 1, 12),
+				"severity": float64(1),
+				"code":     "RED002",
+				"source":   "redirects",
+				"message":  `invalid status code "3!01"`,
+			},
+		}, out[0]["params"].(map[string]interface{})["diagnostics"])
+	})
+
+	t.Run("toml diagnostics", func(t *testing.T) {
+		text := "[[redirects]]\nfrom = \"/*\"\nto = \"/index.html\"\n\n[[redirects]]\nfrom = \"/\"\nto = \"/anz\"\nconditions = {Country = [\"aus\"]}\n\n[[redirects]]\nfrom = \"/about\"\nto = \"/index.html\"\nstatus = 200\n"
+		out := session(t, open("file:///site/netlify.toml", text))
+		diags := out[0]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+		assert.Len(t, diags, 2)
+
+		country := diags[0].(map[string]interface{})
+		assert.Equal(t, "RED012", country["code"])
+		assert.Equal(t, rng(7, 26, 7, 29), country["range"])
+
+		shadowed := diags[1].(map[string]interface{})
+		assert.Equal(t, "RED024", shadowed["code"])
+		assert.Equal(t, "rule never applies as the rule on line 1 matches every request it would", shadowed["message"])
+		assert.Equal(t, rng(9, 0, 9, 13), shadowed["range"])
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		out := session(t,
+			open("file:///site/_redirects", "# comment\n/news/*  /blog/:splat  302\n"),
+			at("textDocument/hover", "file:///site/_redirects", 1, 3),
+			at("textDocument/hover", "file:///site/_redirects", 0, 3),
+		)
+		assert.Len(t, out, 3)
+
+		result := out[1]["result"].(map[string]interface{})
+		assert.Equal(t, "```\n/news/* /blog/:splat 302\n```\n\nRequests to /news/* are redirected to /blog preserving the rest of the path with a 302 (Found).", result["contents"].(map[string]interface{})["value"])
+		assert.Equal(t, rng(1, 0, 1, 26), result["range"])
+		assert.Nil(t, out[2]["result"])
+	})
+
+	t.Run("definition", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "dist", "blog"), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "dist", "blog", "index.html"), nil, 0644))
+
+		uri := "file://" + filepath.ToSlash(dir) + "/_redirects"
+		out := session(t,
+			open(uri, "/news  /blog\n/old  /news\n/api/*  https://example.com/:splat  200\n"),
+			at("textDocument/definition", uri, 1, 0),
+			at("textDocument/definition", uri, 2, 0),
+		)
+		assert.Equal(t, map[string]interface{}{"uri": uri, "range": rng(0, 0, 0, 12)}, out[1]["result"])
+		assert.Nil(t, out[2]["result"])
+
+		uri = "file://" + filepath.ToSlash(dir) + "/netlify.toml"
+		out = session(t,
+			open(uri, "[build]\npublish = \"dist\"\n\n[[redirects]]\nfrom = \"/news\"\nto = \"/blog\"\n"),
+			at("textDocument/definition", uri, 5, 0),
+		)
+		assert.Equal(t, "file://"+filepath.ToSlash(filepath.Join(dir, "dist", "blog", "index.html")), out[1]["result"].(map[string]interface{})["uri"])
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		out := session(t, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "textDocument/rename"})
+		assert.Equal(t, float64(codeMethodNotFound), out[0]["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("exit before shutdown", func(t *testing.T) {
+		var in bytes.Buffer
+		assert.NoError(t, writeMessage(&in, map[string]interface{}{"jsonrpc": "2.0", "method": "exit"}))
+		assert.EqualError(t, serve(&in, &bytes.Buffer{}), "exit before shutdown")
+	})
+}
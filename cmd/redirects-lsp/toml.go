@@ -0,0 +1,377 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fission-suite/go-redirects"
+)
+
+// A tomlRedirect is a [[redirects]] table of a netlify.toml file.
+type tomlRedirect struct {
+	// Start and End are the first and last lines of the table, from 0.
+	Start, End int
+
+	// ToLine is the line of the to key, or -1 when there is none.
+	ToLine int
+
+	// Rule is the table in _redirects format, or empty when the table is
+	// missing from or to.
+	Rule string
+}
+
+// A tomlError is a problem with a line of a netlify.toml file.
+type tomlError struct {
+	// Line is the line, from 0.
+	Line int
+
+	// Err is the problem.
+	Err error
+}
+
+// A netlifyTOML is the redirects related content of a netlify.toml file.
+type netlifyTOML struct {
+	// Publish is the publish directory of the build settings.
+	Publish string
+
+	// Redirects are the [[redirects]] tables in order.
+	Redirects []tomlRedirect
+
+	// Errors are the problems found.
+	Errors []tomlError
+}
+
+// parseTOML returns the build publish directory and redirects of a
+// netlify.toml file. Only the TOML used by these settings is supported:
+// single line keys of strings, integers, booleans, arrays and inline tables,
+// along with the [redirects.query] and [redirects.conditions] sub-tables.
+func parseTOML(text string) (t netlifyTOML) {
+	var table string
+	var fields map[string]interface{}
+	var sub map[string]interface{}
+
+	end := func() {
+		if fields == nil {
+			return
+		}
+
+		r := &t.Redirects[len(t.Redirects)-1]
+		rule, err := tomlRule(fields)
+		if err != nil {
+			t.Errors = append(t.Errors, tomlError{Line: r.Start, Err: err})
+		}
+		r.Rule = rule
+		fields, sub = nil, nil
+	}
+
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			header := line
+			if i := strings.IndexByte(header, '#'); i >= 0 {
+				header = strings.TrimSpace(header[:i])
+			}
+
+			switch header {
+			case "[[redirects]]":
+				end()
+				table = "redirects"
+				fields = make(map[string]interface{})
+				sub = nil
+				t.Redirects = append(t.Redirects, tomlRedirect{Start: n, End: n, ToLine: -1})
+			case "[redirects.query]", "[redirects.conditions]", "[redirects.headers]":
+				if fields == nil {
+					t.Errors = append(t.Errors, tomlError{Line: n, Err: fmt.Errorf("%s outside of [[redirects]]", header)})
+					continue
+				}
+				sub = make(map[string]interface{})
+				fields[strings.TrimSuffix(strings.TrimPrefix(header, "[redirects."), "]")] = sub
+				t.Redirects[len(t.Redirects)-1].End = n
+			default:
+				end()
+				table = strings.Trim(header, "[]")
+			}
+			continue
+		}
+
+		key, v, err := parseKeyValue(line)
+		if err != nil {
+			t.Errors = append(t.Errors, tomlError{Line: n, Err: err})
+			continue
+		}
+
+		switch {
+		case sub != nil:
+			sub[key] = v
+		case fields != nil:
+			fields[key] = v
+			if key == "to" {
+				t.Redirects[len(t.Redirects)-1].ToLine = n
+			}
+		case table == "build" && key == "publish":
+			t.Publish, _ = v.(string)
+		}
+
+		if fields != nil {
+			t.Redirects[len(t.Redirects)-1].End = n
+		}
+	}
+
+	end()
+	return
+}
+
+// tomlRule returns the [[redirects]] table fields in _redirects format.
+func tomlRule(fields map[string]interface{}) (string, error) {
+	r := redirects.Rule{Status: 301}
+
+	for _, k := range sortedKeys(fields) {
+		v := fields[k]
+
+		var ok bool
+		switch k {
+		case "from":
+			r.From, ok = v.(string)
+		case "to":
+			r.To, ok = v.(string)
+		case "status":
+			var n int64
+			n, ok = v.(int64)
+			r.Status = int(n)
+		case "force":
+			r.Force, ok = v.(bool)
+		case "query":
+			var m map[string]interface{}
+			if m, ok = v.(map[string]interface{}); ok && len(m) > 0 {
+				r.Params = make(redirects.Params, len(m))
+				for name, value := range m {
+					r.Params[name] = fmt.Sprint(value)
+				}
+			}
+		case "conditions":
+			var m map[string]interface{}
+			if m, ok = v.(map[string]interface{}); ok {
+				for name, value := range m {
+					values := tomlStrings(value)
+					switch name {
+					case "Country":
+						r.Country = values
+					case "Language":
+						r.Language = values
+					default:
+						if r.Conditions == nil {
+							r.Conditions = make(redirects.Conditions)
+						}
+						r.Conditions[name] = strings.Join(values, ",")
+					}
+				}
+			}
+		case "signed":
+			var s string
+			if s, ok = v.(string); ok {
+				if r.Conditions == nil {
+					r.Conditions = make(redirects.Conditions)
+				}
+				r.Conditions["Signed"] = s
+			}
+		case "headers":
+			return "", errors.New("custom proxy headers are not supported")
+		default:
+			return "", fmt.Errorf("unknown key %q", k)
+		}
+
+		if !ok {
+			return "", fmt.Errorf("invalid %s %v", k, v)
+		}
+	}
+
+	switch {
+	case r.From == "":
+		return "", errors.New("missing from")
+	case r.To == "":
+		return "", errors.New("missing to")
+	}
+
+	return r.String(), nil
+}
+
+// tomlStrings returns a string or array of strings as a slice.
+func tomlStrings(v interface{}) (values []string) {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, e := range v {
+			values = append(values, fmt.Sprint(e))
+		}
+	default:
+		values = append(values, fmt.Sprint(v))
+	}
+
+	return
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseKeyValue returns the key and value of a "key = value" line.
+func parseKeyValue(line string) (string, interface{}, error) {
+	key, rest, err := parseKey(line)
+	if err != nil {
+		return "", nil, err
+	}
+
+	v, rest, err := parseValue(rest)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if rest = strings.TrimSpace(rest); rest != "" && rest[0] != '#' {
+		return "", nil, fmt.Errorf("unexpected %q after value", rest)
+	}
+
+	return key, v, nil
+}
+
+// parseKey returns the key before the "=" at the start of s and the rest
+// of s after it.
+func parseKey(s string) (string, string, error) {
+	i := strings.IndexByte(s, '=')
+	if i < 0 {
+		return "", "", fmt.Errorf("expected key = value, got %q", s)
+	}
+
+	key := strings.TrimSpace(s[:i])
+	if strings.HasPrefix(key, `"`) {
+		k, err := strconv.Unquote(key)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid key %s", key)
+		}
+		key = k
+	}
+
+	if key == "" {
+		return "", "", fmt.Errorf("missing key in %q", s)
+	}
+
+	return key, s[i+1:], nil
+}
+
+// parseValue returns the value at the start of s and the rest of s.
+func parseValue(s string) (interface{}, string, error) {
+	s = strings.TrimLeft(s, " \t")
+	if s == "" {
+		return nil, "", errors.New("missing value")
+	}
+
+	switch s[0] {
+	case '"':
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				v, err := strconv.Unquote(s[:i+1])
+				if err != nil {
+					return nil, "", fmt.Errorf("invalid string %s", s[:i+1])
+				}
+				return v, s[i+1:], nil
+			}
+		}
+		return nil, "", fmt.Errorf("unterminated string %s", s)
+
+	case '\'':
+		i := strings.IndexByte(s[1:], '\'')
+		if i < 0 {
+			return nil, "", fmt.Errorf("unterminated string %s", s)
+		}
+		return s[1 : i+1], s[i+2:], nil
+
+	case '[':
+		var values []interface{}
+		s = s[1:]
+		for {
+			s = strings.TrimLeft(s, " \t")
+			if strings.HasPrefix(s, "]") {
+				return values, s[1:], nil
+			}
+
+			v, rest, err := parseValue(s)
+			if err != nil {
+				return nil, "", err
+			}
+			values = append(values, v)
+
+			s = strings.TrimLeft(rest, " \t")
+			switch {
+			case strings.HasPrefix(s, ","):
+				s = s[1:]
+			case strings.HasPrefix(s, "]"):
+				return values, s[1:], nil
+			default:
+				return nil, "", errors.New("unterminated array")
+			}
+		}
+
+	case '{':
+		m := make(map[string]interface{})
+		s = s[1:]
+		for {
+			s = strings.TrimLeft(s, " \t")
+			if strings.HasPrefix(s, "}") {
+				return m, s[1:], nil
+			}
+
+			key, rest, err := parseKey(s)
+			if err != nil {
+				return nil, "", err
+			}
+
+			v, rest, err := parseValue(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			m[key] = v
+
+			s = strings.TrimLeft(rest, " \t")
+			switch {
+			case strings.HasPrefix(s, ","):
+				s = s[1:]
+			case strings.HasPrefix(s, "}"):
+				return m, s[1:], nil
+			default:
+				return nil, "", errors.New("unterminated inline table")
+			}
+		}
+	}
+
+	i := strings.IndexAny(s, ",]} \t#")
+	if i < 0 {
+		i = len(s)
+	}
+
+	switch tok := s[:i]; tok {
+	case "true":
+		return true, s[i:], nil
+	case "false":
+		return false, s[i:], nil
+	default:
+		n, err := strconv.ParseInt(strings.ReplaceAll(tok, "_", ""), 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("unsupported value %q", tok)
+		}
+		return n, s[i:], nil
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestParseTOML(t *testing.T) {
+	t.Run("redirects", func(t *testing.T) {
+		toml := parseTOML(`
+[build]
+  command = "make"  # builds the site
+  publish = "public"
+
+[[redirects]]
+  from = "/store"
+  to = "/blog/:id"
+  status = 302
+  force = true
+  query = {id = ":id"}
+
+[[redirects]]
+  from = "/"
+  to = '/anz'
+  [redirects.conditions]
+    Country = ["au", "nz"]
+    Role = ["admin"]
+
+[context.production]
+  environment = { ACCESS_TOKEN = "secret" }
+`)
+		assert.Empty(t, toml.Errors)
+		assert.Equal(t, "public", toml.Publish)
+		assert.Equal(t, []tomlRedirect{
+			{Start: 5, End: 10, ToLine: 7, Rule: "/store id=:id /blog/:id 302!"},
+			{Start: 12, End: 17, ToLine: 14, Rule: "/ /anz 301 Country=au,nz Role=admin"},
+		}, toml.Redirects)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		toml := parseTOML("[[redirects]]\nfrom = \"/a\"\nstatus = \"301\"\n[[redirects]]\nfrom = \"/b\"\n[[redirects]]\nfrom = /c\nto = \"/d\" x\n")
+		assert.Equal(t, []tomlError{
+			{Line: 0, Err: toml.Errors[0].Err},
+			{Line: 3, Err: toml.Errors[1].Err},
+			{Line: 6, Err: toml.Errors[2].Err},
+			{Line: 7, Err: toml.Errors[3].Err},
+			{Line: 5, Err: toml.Errors[4].Err},
+		}, toml.Errors)
+		assert.EqualError(t, toml.Errors[0].Err, "invalid status 301")
+		assert.EqualError(t, toml.Errors[1].Err, "missing to")
+		assert.EqualError(t, toml.Errors[2].Err, `unsupported value "/c"`)
+		assert.EqualError(t, toml.Errors[3].Err, `unexpected "x" after value`)
+		assert.EqualError(t, toml.Errors[4].Err, "missing from")
+	})
+}
@@ -0,0 +1,346 @@
+// Command redirects inspects _redirects files.
+//
+//	redirects stats [file]
+//	redirects sections [file]
+//	redirects [-hits file] stale [file]
+//	redirects [-format text|json|sarif] check [file]
+//	redirects generate [file]
+//	redirects -current file -samples file simulate [file]
+//	redirects [-base url] destinations [file]
+//	redirects [-root dir] files [file]
+//	redirects -sitemap file sitemap [file]
+//
+// The file defaults to stdin.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fission-suite/go-redirects"
+)
+
+// usage is the command usage.
+const usage = `Usage: redirects [flags] <command> [file]
+
+Commands:
+  stats         print rule counts by status, kind and condition
+  sections      print rule counts by section
+  stale         print expired rules, and those without hits given -hits
+  check         print every error and warning, failing on errors
+  generate      print redirects for a CSV of old and new URLs
+  simulate      print the sampled requests served differently than by -current
+  destinations  print rules whose destination is unreachable or an error
+  files         print content rules whose destination is not a file in -root
+  sitemap       print rules conflicting with the pages of -sitemap
+
+Flags:
+  -hits     JSON hit counts served by a DebugHandler with ?format=json
+  -format   output format of check, text, json or sarif, defaulting to text
+  -current  _redirects file currently deployed, compared by simulate
+  -samples  access log or paths with optional counts, replayed by simulate
+  -base     site URL internal destinations are checked against
+  -root     site directory checked by files, defaulting to the current one
+  -sitemap  sitemap.xml of the published pages checked by sitemap
+`
+
+// options are the command line flags.
+type options struct {
+	hits    string
+	format  string
+	current string
+	samples string
+	base    string
+	root    string
+	sitemap string
+}
+
+// commands are the subcommands by name.
+var commands = map[string]func(d *redirects.Document, o options, stdout io.Writer) error{
+	"stats":        stats,
+	"sections":     sections,
+	"stale":        stale,
+	"simulate":     simulate,
+	"destinations": destinations,
+	"files":        files,
+	"sitemap":      sitemap,
+}
+
+// readers are the subcommands reading something other than a _redirects
+// file, by name.
+var readers = map[string]func(r io.Reader, name string, o options, stdout io.Writer) error{
+	"check":    check,
+	"generate": generate,
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "redirects: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command of args.
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	var o options
+
+	flags := flag.NewFlagSet("redirects", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&o.hits, "hits", "", "")
+	flags.StringVar(&o.format, "format", "text", "")
+	flags.StringVar(&o.current, "current", "", "")
+	flags.StringVar(&o.samples, "samples", "", "")
+	flags.StringVar(&o.base, "base", "", "")
+	flags.StringVar(&o.root, "root", ".", "")
+	flags.StringVar(&o.sitemap, "sitemap", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%s\n\n%s", err, usage)
+	}
+
+	args = flags.Args()
+	if len(args) == 0 || len(args) > 2 {
+		return fmt.Errorf("invalid arguments\n\n%s", usage)
+	}
+
+	cmd, ok := commands[args[0]]
+	reader, isReader := readers[args[0]]
+	if !ok && !isReader {
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], usage)
+	}
+
+	r, name := stdin, "stdin"
+	if len(args) == 2 {
+		f, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r, name = f, args[1]
+	}
+
+	if isReader {
+		return reader(r, name, o, stdout)
+	}
+
+	d, err := redirects.ParseDocument(r)
+	if err != nil {
+		return err
+	}
+
+	return cmd(d, o, stdout)
+}
+
+// stats prints the statistics of rules.
+func stats(d *redirects.Document, _ options, stdout io.Writer) error {
+	_, err := redirects.Stats(d.Rules).WriteTo(stdout)
+	return err
+}
+
+// sections prints the rule counts of each section, with rules before the
+// first section listed as "-".
+func sections(d *redirects.Document, _ options, stdout io.Writer) error {
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "section\trules\tredirects\trewrites\tproxies")
+
+	stats := d.Stats()
+	row := func(name, key string) {
+		s := stats[key]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", name, s.Rules, s.Redirects, s.Rewrites, s.Proxies)
+	}
+
+	if _, ok := stats[""]; ok {
+		row("-", "")
+	}
+
+	for _, s := range d.Sections {
+		row(s.Name, s.Name)
+	}
+
+	return tw.Flush()
+}
+
+// stale prints the rules which have expired, and with -hits those which no
+// request has matched, for cleaning up.
+func stale(d *redirects.Document, o options, stdout io.Writer) error {
+	var hits map[string]uint64
+
+	if o.hits != "" {
+		f, err := os.Open(o.hits)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if hits, err = redirects.ReadHits(f); err != nil {
+			return fmt.Errorf("reading hits: %w", err)
+		}
+	}
+
+	for _, s := range redirects.AuditStale(d.Rules, time.Now(), hits) {
+		line := s.String()
+		if owner := s.Rule.Meta["owner"]; owner != "" {
+			line += " (owner " + owner + ")"
+		}
+		fmt.Fprintln(stdout, line)
+	}
+
+	return nil
+}
+
+// check prints the diagnostics of the file in the format of -format, and
+// fails when any is an error, for editors and CI.
+func check(r io.Reader, name string, o options, stdout io.Writer) error {
+	diags := redirects.Diagnostics(r)
+
+	switch o.format {
+	case "text":
+		for _, d := range diags {
+			fmt.Fprintf(stdout, "%s:%d:%d: %s %s: %s\n", name, d.Range.Start.Line, d.Range.Start.Column, d.Severity, d.Code, d.Message)
+		}
+	case "json":
+		if diags == nil {
+			diags = []redirects.Diagnostic{}
+		}
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(diags); err != nil {
+			return err
+		}
+	case "sarif":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(newSARIF(name, diags)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown format %q\n\n%s", o.format, usage)
+	}
+
+	for _, d := range diags {
+		if d.Severity == redirects.SeverityError {
+			return errors.New("errors found")
+		}
+	}
+
+	return nil
+}
+
+// generate prints the redirects of a CSV of old and new URLs.
+func generate(r io.Reader, _ string, _ options, stdout io.Writer) error {
+	mappings, err := redirects.ReadMappings(r)
+	if err != nil {
+		return err
+	}
+
+	rules, err := redirects.Generate(mappings)
+	if err != nil {
+		return err
+	}
+
+	_, err = stdout.Write(redirects.Marshal(rules))
+	return err
+}
+
+// simulate prints the sampled requests the rules serve differently than the
+// rules of -current, to estimate the effect of a change before shipping it.
+func simulate(d *redirects.Document, o options, stdout io.Writer) error {
+	if o.current == "" || o.samples == "" {
+		return fmt.Errorf("simulate requires -current and -samples\n\n%s", usage)
+	}
+
+	f, err := os.Open(o.current)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	current, err := redirects.Parse(f)
+	if err != nil {
+		return fmt.Errorf("parsing current rules: %w", err)
+	}
+
+	sf, err := os.Open(o.samples)
+	if err != nil {
+		return err
+	}
+	defer sf.Close()
+
+	samples, err := redirects.ReadSamples(sf)
+	if err != nil {
+		return fmt.Errorf("reading samples: %w", err)
+	}
+
+	_, err = redirects.Simulate(current, d.Rules, samples).WriteTo(stdout)
+	return err
+}
+
+// destinations prints the rules whose destination is unreachable or
+// responds with an error status, failing when there are any.
+func destinations(d *redirects.Document, o options, stdout io.Writer) error {
+	found, err := redirects.CheckDestinations(context.Background(), d.Rules, redirects.CheckOptions{BaseURL: o.base})
+	if err != nil {
+		return err
+	}
+
+	for _, f := range found {
+		fmt.Fprintln(stdout, f)
+	}
+
+	if len(found) > 0 {
+		return errors.New("dead destinations found")
+	}
+
+	return nil
+}
+
+// files prints the content rules whose destination is not a file of the
+// site in -root, failing when there are any.
+func files(d *redirects.Document, o options, stdout io.Writer) error {
+	found := redirects.AuditMissingFiles(d.Rules, os.DirFS(o.root))
+	for _, f := range found {
+		fmt.Fprintln(stdout, f)
+	}
+
+	if len(found) > 0 {
+		return errors.New("missing files found")
+	}
+
+	return nil
+}
+
+// sitemap prints the rules conflicting with the pages of -sitemap, failing
+// when there are any.
+func sitemap(d *redirects.Document, o options, stdout io.Writer) error {
+	if o.sitemap == "" {
+		return fmt.Errorf("sitemap requires -sitemap\n\n%s", usage)
+	}
+
+	f, err := os.Open(o.sitemap)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	found, err := redirects.AuditSitemap(d.Rules, f)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range found {
+		fmt.Fprintln(stdout, c)
+	}
+
+	if len(found) > 0 {
+		return errors.New("sitemap conflicts found")
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("stats", func(t *testing.T) {
+		var b strings.Builder
+		err := run([]string{"stats"}, strings.NewReader("/home  /\n/news  /blog  302\n"), &b)
+		assert.NoError(t, err)
+		assert.Contains(t, b.String(), "rules         2\n")
+		assert.Contains(t, b.String(), "  302                 1\n")
+	})
+
+	t.Run("sections", func(t *testing.T) {
+		var b strings.Builder
+		err := run([]string{"sections"}, strings.NewReader("/home  /\n## [section: blog]\n/news  /blog  302\n/feed  /rss.xml  200\n"), &b)
+		assert.NoError(t, err)
+		assert.Equal(t, "section  rules  redirects  rewrites  proxies\n-        1      1          0         0\nblog     2      1          1         0\n", b.String())
+	})
+
+	t.Run("stale", func(t *testing.T) {
+		hits := filepath.Join(t.TempDir(), "hits.json")
+		err := os.WriteFile(hits, []byte(`{"rules": [{"index": 1, "rule": "/news /blog 301", "hits": 0}]}`), 0644)
+		assert.NoError(t, err)
+
+		var b strings.Builder
+		err = run([]string{"-hits", hits, "stale"}, strings.NewReader("#@ owner=seo expires=2001-01-01\n/old  /new\n/news  /blog\n#@ expires=2999-01-01\n/sale  /winter  302\n"), &b)
+		assert.NoError(t, err)
+		assert.Equal(t, "rule 0 (/old): expired 2001-01-01 (owner seo)\nrule 1 (/news): no hits\n", b.String())
+	})
+
+	t.Run("check", func(t *testing.T) {
+		var b strings.Builder
+		err := run([]string{"check"}, strings.NewReader("/home  /\n/a  /b  3!01\n/home  /other\n"), &b)
+		assert.EqualError(t, err, "errors found")
+		assert.Equal(t, "stdin:2:9: error RED002: invalid status code \"3!01\"\nstdin:3:1: warning RED024: rule never applies as the rule on line 1 matches every request it would\n", b.String())
+	})
+
+	t.Run("check json", func(t *testing.T) {
+		var b strings.Builder
+		err := run([]string{"-format", "json", "check"}, strings.NewReader("/home  /\n"), &b)
+		assert.NoError(t, err)
+		assert.Equal(t, "[]\n", b.String())
+	})
+
+	t.Run("check sarif", func(t *testing.T) {
+		var b strings.Builder
+		err := run([]string{"-format", "sarif", "check"}, strings.NewReader("/home\n"), &b)
+		assert.EqualError(t, err, "errors found")
+
+		var log sarifLog
+		assert.NoError(t, json.Unmarshal([]byte(b.String()), &log))
+		assert.Equal(t, "2.1.0", log.Version)
+		assert.Equal(t, "redirects", log.Runs[0].Tool.Driver.Name)
+		assert.Equal(t, []sarifResult{{
+			RuleID:  "RED001",
+			Level:   "error",
+			Message: sarifMessage{Text: "missing destination path"},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "stdin"},
+					Region:           sarifRegion{StartLine: 1, StartColumn: 1, EndLine: 1, EndColumn: 6},
+				},
+			}},
+		}}, log.Runs[0].Results)
+	})
+
+	t.Run("check unknown format", func(t *testing.T) {
+		err := run([]string{"-format", "xml", "check"}, strings.NewReader(""), &strings.Builder{})
+		assert.Contains(t, err.Error(), `unknown format "xml"`)
+	})
+
+	t.Run("generate", func(t *testing.T) {
+		var b strings.Builder
+		err := run([]string{"generate"}, strings.NewReader("old,new\n/blog/a,/posts/a\n/blog/b,/posts/b\n/about-us,/about\n"), &b)
+		assert.NoError(t, err)
+		assert.Equal(t, "/about-us /about 301\n/blog/* /posts/:splat 301\n", b.String())
+	})
+
+	t.Run("simulate", func(t *testing.T) {
+		dir := t.TempDir()
+		current := filepath.Join(dir, "_redirects")
+		samples := filepath.Join(dir, "samples.txt")
+		assert.NoError(t, os.WriteFile(current, []byte("/blog/*  /posts/:splat\n"), 0644))
+		assert.NoError(t, os.WriteFile(samples, []byte("/blog/a 3\n/about 1\n"), 0644))
+
+		var b strings.Builder
+		err := run([]string{"-current", current, "-samples", samples, "simulate"}, strings.NewReader("/blog/*  /articles/:splat\n"), &b)
+		assert.NoError(t, err)
+		assert.Equal(t, "requests  4\nchanged   3 (75.0%)\n\ncount  path     before                 after\n3      /blog/a  redirect 301 /posts/a  redirect 301 /articles/a\n", b.String())
+
+		err = run([]string{"simulate"}, strings.NewReader(""), &b)
+		assert.Contains(t, err.Error(), "simulate requires -current and -samples")
+	})
+
+	t.Run("destinations", func(t *testing.T) {
+		site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/live" {
+				http.NotFound(w, r)
+			}
+		}))
+		defer site.Close()
+
+		var b strings.Builder
+		err := run([]string{"-base", site.URL, "destinations"}, strings.NewReader("/a  /live\n/b  /missing\n"), &b)
+		assert.EqualError(t, err, "dead destinations found")
+		assert.Equal(t, "rule 1 (/b): "+site.URL+"/missing responded 404 Not Found\n", b.String())
+	})
+
+	t.Run("files", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), nil, 0644))
+
+		var b strings.Builder
+		err := run([]string{"-root", dir, "files"}, strings.NewReader("/*  /index.html  200\n/app/*  /index.htm  200\n"), &b)
+		assert.EqualError(t, err, "missing files found")
+		assert.Equal(t, "rule 1 (/app/*): destination /index.htm does not exist, did you mean /index.html?\n", b.String())
+	})
+
+	t.Run("sitemap", func(t *testing.T) {
+		sitemap := filepath.Join(t.TempDir(), "sitemap.xml")
+		err := os.WriteFile(sitemap, []byte(`<urlset><url><loc>https://example.com/pricing</loc></url></urlset>`), 0644)
+		assert.NoError(t, err)
+
+		var b strings.Builder
+		err = run([]string{"-sitemap", sitemap, "sitemap"}, strings.NewReader("/pricing  /plans  302!\n"), &b)
+		assert.EqualError(t, err, "sitemap conflicts found")
+		assert.Equal(t, "rule 0 (/pricing): forced rule hides the live page /pricing\n", b.String())
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		err := run([]string{"nope"}, strings.NewReader(""), &strings.Builder{})
+		assert.Contains(t, err.Error(), `unknown command "nope"`)
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		err := run([]string{"stats"}, strings.NewReader("/home\n"), &strings.Builder{})
+		assert.Contains(t, err.Error(), "line 1: missing destination")
+	})
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"github.com/fission-suite/go-redirects"
+)
+
+// sarifSchema is the schema of SARIF 2.1.0 logs.
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// sarifLog is a SARIF log, as read by code scanning tools such as GitHub's.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifRun is a single run of the tool.
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+// sarifTool describes the tool.
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+// sarifDriver describes the tool and the rules it checks.
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+// sarifRule describes a code.
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+// sarifMessage is a plain text message.
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifResult is a diagnostic.
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+// sarifLocation is where a diagnostic is.
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+// sarifPhysicalLocation is a range of a file.
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+// sarifArtifactLocation is a file.
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// sarifRegion is a range of lines and columns, starting at 1 with the end
+// column exclusive as with redirects.Range.
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+// newSARIF returns the SARIF log of the diagnostics of the named file.
+func newSARIF(name string, diags []redirects.Diagnostic) sarifLog {
+	driver := sarifDriver{
+		Name:           "redirects",
+		InformationURI: "https://github.com/fission-suite/go-redirects",
+	}
+
+	for _, c := range redirects.Catalog() {
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:               string(c.Code),
+			ShortDescription: sarifMessage{Text: c.Summary},
+		})
+	}
+
+	results := []sarifResult{}
+	for _, d := range diags {
+		results = append(results, sarifResult{
+			RuleID:  string(d.Code),
+			Level:   d.Severity.String(),
+			Message: sarifMessage{Text: d.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: name},
+					Region: sarifRegion{
+						StartLine:   d.Range.Start.Line,
+						StartColumn: d.Range.Start.Column,
+						EndLine:     d.Range.End.Line,
+						EndColumn:   d.Range.End.Column,
+					},
+				},
+			}},
+		})
+	}
+
+	return sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: driver},
+			Results: results,
+		}},
+	}
+}
@@ -0,0 +1,142 @@
+// Command redirectsd serves rule decisions to proxies supporting external
+// authorization, such as Envoy's HTTP ext_authz filter or Traefik's
+// ForwardAuth middleware.
+//
+//	redirectsd [-addr :8080] [-prefix /check] [-country-header name] file
+//
+// The request is read from the X-Forwarded-Method, X-Forwarded-Host and
+// X-Forwarded-Uri headers when present, as sent by Traefik, or otherwise
+// from the check request itself with the prefix removed, as sent by Envoy.
+//
+// Requests matching a redirect are answered with the redirect, which the
+// proxy returns to the client. Other requests are allowed with a 200, and
+// those matching a rewrite, proxy or content rule carry the decision in
+// the X-Redirects-To and X-Redirects-Status headers for the proxy to apply.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fission-suite/go-redirects"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "redirectsd: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the server configured by args.
+func run(args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("redirectsd", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	addr := flags.String("addr", ":8080", "listen address")
+	prefix := flags.String("prefix", "", "path prefix of check requests to remove")
+	countryHeader := flags.String("country-header", "", "request header holding the visitor's country code")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a _redirects file")
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rules, err := redirects.Parse(f)
+	if err != nil {
+		return err
+	}
+
+	s := &server{
+		rules:         rules,
+		prefix:        *prefix,
+		countryHeader: *countryHeader,
+	}
+
+	return http.ListenAndServe(*addr, s)
+}
+
+// server answers check requests with the decision of rules.
+type server struct {
+	rules         []redirects.Rule
+	prefix        string
+	countryHeader string
+}
+
+// ServeHTTP implementation.
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := s.request(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, ok := redirects.Match(s.rules, req)
+	if !ok {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if res.Rule.IsContent() || (res.Rule.IsRewrite() && res.Rule.IsProxy()) {
+		w.Header().Set("X-Redirects-To", res.To)
+		w.Header().Set("X-Redirects-Status", strconv.Itoa(res.Rule.Status))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	w.Header().Set("Location", res.To)
+	w.WriteHeader(res.Rule.Status)
+}
+
+// request returns the request being checked.
+func (s *server) request(r *http.Request) (redirects.Request, error) {
+	method, host := r.Method, r.Host
+	uri := strings.TrimPrefix(r.URL.RequestURI(), s.prefix)
+
+	if v := r.Header.Get("X-Forwarded-Uri"); v != "" {
+		uri = v
+		if v := r.Header.Get("X-Forwarded-Method"); v != "" {
+			method = v
+		}
+		if v := r.Header.Get("X-Forwarded-Host"); v != "" {
+			host = v
+		}
+	}
+
+	if !strings.HasPrefix(uri, "/") {
+		uri = "/" + uri
+	}
+
+	u, err := url.ParseRequestURI(uri)
+	if err != nil {
+		return redirects.Request{}, fmt.Errorf("invalid request URI %q", uri)
+	}
+
+	check := r.Clone(r.Context())
+	check.Method = method
+	check.Host = host
+	check.URL = u
+
+	req := redirects.NewRequest(check)
+	req.Time = time.Now()
+	if s.countryHeader != "" {
+		req.Country = r.Header.Get(s.countryHeader)
+	}
+
+	return req, nil
+}
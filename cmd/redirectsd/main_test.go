@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestServer(t *testing.T) {
+	s := &server{
+		rules: redirects.Must(redirects.ParseString(`
+			/home    /
+			/app/*   /index.html  200
+			/api/*   https://api.example.com/:splat  200
+			/google  https://www.google.com  301
+			/        /anz  302  Country=au
+		`)),
+		prefix:        "/check",
+		countryHeader: "CF-IPCountry",
+	}
+
+	t.Run("redirect", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("GET", "/check/home?a=b", nil))
+		assert.Equal(t, 301, w.Code)
+		assert.Equal(t, "/?a=b", w.Header().Get("Location"))
+	})
+
+	t.Run("external redirect", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("GET", "/check/google", nil))
+		assert.Equal(t, 301, w.Code)
+		assert.Equal(t, "https://www.google.com", w.Header().Get("Location"))
+		assert.Empty(t, w.Header().Get("X-Redirects-To"))
+	})
+
+	t.Run("rewrite", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("GET", "/check/app/settings", nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "/index.html", w.Header().Get("X-Redirects-To"))
+		assert.Equal(t, "200", w.Header().Get("X-Redirects-Status"))
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("POST", "/check/api/users", nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "https://api.example.com/users", w.Header().Get("X-Redirects-To"))
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("GET", "/check/about", nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Empty(t, w.Header().Get("X-Redirects-To"))
+		assert.Empty(t, w.Header().Get("Location"))
+	})
+
+	t.Run("forwarded headers", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/auth", nil)
+		r.Header.Set("X-Forwarded-Method", "GET")
+		r.Header.Set("X-Forwarded-Host", "example.com")
+		r.Header.Set("X-Forwarded-Uri", "/")
+		r.Header.Set("CF-IPCountry", "au")
+
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		assert.Equal(t, 302, w.Code)
+		assert.Equal(t, "/anz", w.Header().Get("Location"))
+	})
+}
+
+func TestRun(t *testing.T) {
+	t.Run("missing file", func(t *testing.T) {
+		err := run(nil, &strings.Builder{})
+		assert.EqualError(t, err, "expected a _redirects file")
+	})
+}
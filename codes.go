@@ -0,0 +1,152 @@
+package redirects
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// A Code is the stable identifier of a kind of parse or validation failure,
+// such as "RED001", for CI annotations, editor plugins and documentation to
+// reference, and for tooling to suppress specific diagnostics. Codes are
+// never reused, while messages may change.
+type Code string
+
+// Codes of parse and validation failures.
+const (
+	CodeMissingDestination   Code = "RED001"
+	CodeInvalidStatus        Code = "RED002"
+	CodeInvalidParam         Code = "RED003"
+	CodeDetachedForce        Code = "RED004"
+	CodeUnknownCondition     Code = "RED005"
+	CodeUnexpectedToken      Code = "RED006"
+	CodeInvalidTime          Code = "RED007"
+	CodeUnsupportedStatus    Code = "RED008"
+	CodeInvalidSource        Code = "RED009"
+	CodeInvalidDestination   Code = "RED010"
+	CodeUnboundPlaceholder   Code = "RED011"
+	CodeInvalidCountry       Code = "RED012"
+	CodeInvalidLanguage      Code = "RED013"
+	CodeInvalidSchedule      Code = "RED014"
+	CodeInvalidHost          Code = "RED015"
+	CodeInvalidMethod        Code = "RED016"
+	CodeInvalidConstraint    Code = "RED017"
+	CodeExtensionRequired    Code = "RED018"
+	CodeProxyNotAllowed      Code = "RED019"
+	CodeIPFSUnsupported      Code = "RED020"
+	CodeDuplicateSection     Code = "RED021"
+	CodeInvalidAnnotation    Code = "RED022"
+	CodeExclusionDestination Code = "RED023"
+	CodeShadowed             Code = "RED024"
+	CodeTooManyRules         Code = "RED025"
+	CodeLineTooLong          Code = "RED026"
+	CodeUndefinedVariable    Code = "RED027"
+	CodeReservedPlaceholder  Code = "RED028"
+	CodeDuplicatePlaceholder Code = "RED029"
+	CodeUnusedPlaceholder    Code = "RED030"
+	CodeFileTooLarge         Code = "RED031"
+)
+
+// A CodeInfo describes a Code.
+type CodeInfo struct {
+	// Code is the code.
+	Code Code
+
+	// Summary describes the failure.
+	Summary string
+}
+
+// catalog is the description of every code, ordered by code.
+var catalog = []CodeInfo{
+	{CodeMissingDestination, "rule without a destination"},
+	{CodeInvalidStatus, "malformed status code"},
+	{CodeInvalidParam, "malformed or empty query param, or one binding a wildcard placeholder"},
+	{CodeDetachedForce, "force flag not attached to a status code"},
+	{CodeUnknownCondition, "unsupported condition"},
+	{CodeUnexpectedToken, "token after the status code which is not a condition"},
+	{CodeInvalidTime, "From or Until time not in RFC 3339"},
+	{CodeUnsupportedStatus, "status code which is not supported"},
+	{CodeInvalidSource, "invalid source path or regular expression"},
+	{CodeInvalidDestination, "invalid destination path or URL"},
+	{CodeUnboundPlaceholder, "destination placeholder not bound by the source or params"},
+	{CodeInvalidCountry, "country which is not an ISO 3166-1 alpha-2 code"},
+	{CodeInvalidLanguage, "language which is not an ISO 639-1 code"},
+	{CodeInvalidSchedule, "Until time not after the From time"},
+	{CodeInvalidHost, "malformed Host condition"},
+	{CodeInvalidMethod, "malformed Method condition"},
+	{CodeInvalidConstraint, "malformed or unknown placeholder constraint"},
+	{CodeExtensionRequired, "extension used without the option enabling it"},
+	{CodeProxyNotAllowed, "proxy to a host which is not allowed"},
+	{CodeIPFSUnsupported, "rule not supported by IPFS gateways"},
+	{CodeDuplicateSection, "section name used more than once"},
+	{CodeInvalidAnnotation, "annotation field which is not a key=value pair"},
+	{CodeExclusionDestination, "exclusion rule with a destination"},
+	{CodeShadowed, "rule shadowed by an earlier rule, reported as a warning"},
+	{CodeTooManyRules, "more rules than allowed by WithMaxRules"},
+	{CodeLineTooLong, "line longer than allowed by WithMaxLineLength"},
+	{CodeUndefinedVariable, "environment variable reference which is not defined"},
+	{CodeReservedPlaceholder, "source placeholder named after a wildcard, reported as a warning"},
+	{CodeDuplicatePlaceholder, "source placeholder used more than once, reported as a warning"},
+	{CodeUnusedPlaceholder, "source placeholder unused by the destination, reported as a warning"},
+	{CodeFileTooLarge, "decompressed file larger than allowed by WithDecompression"},
+}
+
+// Catalog returns every code along with a summary of the failure, ordered
+// by code.
+func Catalog() []CodeInfo {
+	return append([]CodeInfo(nil), catalog...)
+}
+
+// sentinelCodes are the codes of the sentinel errors.
+var sentinelCodes = map[error]Code{
+	ErrMissingDestination: CodeMissingDestination,
+	ErrInvalidParam:       CodeInvalidParam,
+	ErrInvalidStatus:      CodeInvalidStatus,
+	ErrDetachedForce:      CodeDetachedForce,
+	ErrUnknownCondition:   CodeUnknownCondition,
+	ErrUnexpectedToken:    CodeUnexpectedToken,
+	ErrDuplicateSection:   CodeDuplicateSection,
+	ErrInvalidAnnotation:  CodeInvalidAnnotation,
+	ErrTooManyRules:       CodeTooManyRules,
+	ErrLineTooLong:        CodeLineTooLong,
+	ErrUndefinedVariable:  CodeUndefinedVariable,
+	ErrFileTooLarge:       CodeFileTooLarge,
+}
+
+// ErrorCode returns the code of a parse or validation failure, such as a
+// *ParseError or *ValidationError, or an empty code for other errors.
+func ErrorCode(err error) Code {
+	var ce *codeError
+	if errors.As(err, &ce) {
+		return ce.code
+	}
+
+	for sentinel, code := range sentinelCodes {
+		if errors.Is(err, sentinel) {
+			return code
+		}
+	}
+
+	return ""
+}
+
+// codeError is a failure with a code.
+type codeError struct {
+	code Code
+	err  error
+}
+
+// Error implementation.
+func (e *codeError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *codeError) Unwrap() error {
+	return e.err
+}
+
+// errorf returns a failure with a code, formatted as with fmt.Errorf.
+func errorf(code Code, format string, args ...interface{}) error {
+	return &codeError{code: code, err: fmt.Errorf(format, args...)}
+}
@@ -0,0 +1,91 @@
+package redirects_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/pkg/errors"
+	"github.com/tj/assert"
+)
+
+func TestErrorCode(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		cases := []struct {
+			input string
+			code  redirects.Code
+			opts  []redirects.Option
+		}{
+			{"/from", redirects.CodeMissingDestination, nil},
+			{"/a /b 3!01", redirects.CodeInvalidStatus, nil},
+			{"/a =b /b", redirects.CodeInvalidParam, nil},
+			{"/a /b 301 !", redirects.CodeDetachedForce, nil},
+			{"/a /b 301 Role=admin", redirects.CodeUnknownCondition, nil},
+			{"/a /b 301 302", redirects.CodeUnexpectedToken, nil},
+			{"/a /b 302 From=tomorrow", redirects.CodeInvalidTime, nil},
+			{"/a /b 999", redirects.CodeUnsupportedStatus, nil},
+			{"/a /:id", redirects.CodeUnboundPlaceholder, nil},
+			{"/a /b 302 Country=aus", redirects.CodeInvalidCountry, nil},
+			{"/a /b 302 Language=english", redirects.CodeInvalidLanguage, nil},
+			{"/a /b 302 From=2025-01-02 Until=2025-01-01", redirects.CodeInvalidSchedule, nil},
+			{"/a /b 302 Host=exa_mple.com", redirects.CodeInvalidHost, nil},
+			{"/a /b 302 Method=G-T", redirects.CodeInvalidMethod, nil},
+			{"/a/:id(nope) /b", redirects.CodeInvalidConstraint, nil},
+			{"~^/a$ /b", redirects.CodeExtensionRequired, nil},
+			{"/a https://evil.example/ 200", redirects.CodeProxyNotAllowed, []redirects.Option{redirects.WithAllowedProxyHosts("api.example.com")}},
+			{"/a /b 302!", redirects.CodeIPFSUnsupported, []redirects.Option{redirects.WithIPFSGateway()}},
+			{"#@ owner\n/a /b", redirects.CodeInvalidAnnotation, nil},
+		}
+
+		for _, c := range cases {
+			t.Run(c.input, func(t *testing.T) {
+				_, err := redirects.ParseString(c.input, c.opts...)
+				assert.Equal(t, c.code, redirects.ErrorCode(err), "%v", err)
+
+				var perr *redirects.ParseError
+				assert.True(t, errors.As(err, &perr))
+				assert.Equal(t, c.code, perr.Code())
+			})
+		}
+	})
+
+	t.Run("document", func(t *testing.T) {
+		_, err := redirects.ParseDocument(strings.NewReader("## [section: a]\n## [section: a]\n"))
+		assert.Equal(t, redirects.CodeDuplicateSection, redirects.ErrorCode(err))
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		errs := redirects.Validate([]redirects.Rule{
+			{From: "relative", To: "/b", Status: 301},
+			{From: "/a", To: "relative", Status: 301},
+			{From: "!/a", To: "/b"},
+		})
+		assert.Len(t, errs, 3)
+
+		var codes []redirects.Code
+		for _, err := range errs {
+			codes = append(codes, err.(*redirects.ValidationError).Code())
+		}
+
+		assert.Equal(t, []redirects.Code{
+			redirects.CodeInvalidSource,
+			redirects.CodeInvalidDestination,
+			redirects.CodeExclusionDestination,
+		}, codes)
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		assert.Equal(t, redirects.Code(""), redirects.ErrorCode(nil))
+		assert.Equal(t, redirects.Code(""), redirects.ErrorCode(errors.New("boom")))
+	})
+}
+
+func TestCatalog(t *testing.T) {
+	catalog := redirects.Catalog()
+
+	for i, c := range catalog {
+		assert.Equal(t, redirects.Code(fmt.Sprintf("RED%03d", i+1)), c.Code)
+		assert.NotEmpty(t, c.Summary, c.Code)
+	}
+}
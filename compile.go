@@ -0,0 +1,409 @@
+package redirects
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// CompiledRules is a rule set prepared for matching many requests, with an
+// optional cache of match results for frequently requested paths.
+//
+// CompiledRules is safe for concurrent use. Results may be shared between
+// callers and must not be modified.
+type CompiledRules struct {
+	mu        sync.RWMutex
+	rules     []Rule
+	matchers  []matcher
+	cacheSize int
+	cache     *lru
+	keys      cacheKeys
+	hits      []uint64
+	loaded    time.Time
+	hash      string
+	size      int64
+	changed   chan struct{}
+}
+
+// A Snapshot describes the compiled rules at a point in time.
+type Snapshot struct {
+	// Rules are the compiled rules.
+	Rules []Rule
+
+	// Hits is the number of requests matched by each rule since loading.
+	Hits []uint64
+
+	// Loaded is when the rules were compiled or last reloaded.
+	Loaded time.Time
+
+	// Hash is the hex SHA-256 of the rules in _redirects format, one per
+	// line preceded by their annotations, identifying the loaded source.
+	Hash string
+}
+
+// A CompileOption configures compiled rules.
+type CompileOption func(*CompiledRules)
+
+// WithCacheSize caches the results of the n most recently matched requests,
+// keyed by the path and the parts of the request which the rules consult.
+// Rule sets with scheduled rules are never cached as their results change
+// over time.
+func WithCacheSize(n int) CompileOption {
+	return func(c *CompiledRules) {
+		c.cacheSize = n
+	}
+}
+
+// Compile returns the rules prepared for matching.
+func Compile(rules []Rule, opts ...CompileOption) *CompiledRules {
+	c := &CompiledRules{}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	c.Reload(rules)
+	return c
+}
+
+// CompileAll parses and compiles many files at once, such as the _redirects
+// files of every site of a deployment, returning the compiled rules of each
+// file which parsed and the error of each which did not, by key. Up to
+// concurrency files are compiled at a time, defaulting to 8. Files not
+// compiled when ctx ends have the error of ctx.
+func CompileAll(ctx context.Context, files map[string]io.Reader, concurrency int, opts ...Option) (map[string]*CompiledRules, map[string]error) {
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+
+	keys := make([]string, 0, len(files))
+	for k := range files {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	compiled := make(map[string]*CompiledRules)
+	errs := make(map[string]error)
+
+	var mu sync.Mutex
+	queue := make(chan string)
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range queue {
+				rules, err := Parse(files[k], opts...)
+
+				var c *CompiledRules
+				if err == nil {
+					c = Compile(rules)
+				}
+
+				mu.Lock()
+				if err != nil {
+					errs[k] = err
+				} else {
+					compiled[k] = c
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i, k := range keys {
+		select {
+		case queue <- k:
+			continue
+		case <-ctx.Done():
+		}
+
+		mu.Lock()
+		for _, k := range keys[i:] {
+			errs[k] = ctx.Err()
+		}
+		mu.Unlock()
+		break
+	}
+
+	close(queue)
+	wg.Wait()
+
+	return compiled, errs
+}
+
+// Reload replaces the rules, invalidating any cached results.
+func (c *CompiledRules) Reload(rules []Rule) {
+	keys, cacheable := newCacheKeys(rules)
+
+	size := int64(len(rules)) * hitsSize
+	for i := range rules {
+		size += ruleSize(&rules[i])
+	}
+
+	variants := localeVariants(rules)
+	matchers := make([]matcher, len(rules))
+	for i := range rules {
+		matchers[i] = newMatcher(&rules[i])
+		matchers[i].to = newDestination(&rules[i])
+		matchers[i].variants = variants[i]
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rules = rules
+	c.matchers = matchers
+	c.keys = keys
+	c.cache = nil
+	c.hits = make([]uint64, len(rules))
+	c.loaded = time.Now()
+	c.hash = rulesHash(rules)
+	c.size = size
+
+	if c.changed != nil {
+		close(c.changed)
+	}
+	c.changed = make(chan struct{})
+
+	if c.cacheSize > 0 && cacheable {
+		c.cache = newLRU(c.cacheSize)
+	}
+}
+
+// Rules returns the compiled rules.
+func (c *CompiledRules) Rules() []Rule {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rules
+}
+
+// Size returns an estimate of the memory in bytes used by the compiled
+// rules, including their matchers, strings, params and hit counts and the
+// cached results, for operators of many rule sets to budget memory and
+// evict those rarely used.
+func (c *CompiledRules) Size() int64 {
+	c.mu.RLock()
+	size, cache := c.size, c.cache
+	c.mu.RUnlock()
+
+	if cache != nil {
+		entries, keyBytes := cache.stats()
+		size += int64(entries)*cacheEntrySize + int64(keyBytes)
+	}
+
+	return size
+}
+
+// Changed returns a channel which is closed when the rules are next
+// reloaded, for streaming rule updates.
+func (c *CompiledRules) Changed() <-chan struct{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.changed
+}
+
+// Snapshot returns the rules along with their hit counts.
+func (c *CompiledRules) Snapshot() Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	hits := make([]uint64, len(c.hits))
+	for i := range c.hits {
+		hits[i] = atomic.LoadUint64(&c.hits[i])
+	}
+
+	return Snapshot{
+		Rules:  c.rules,
+		Hits:   hits,
+		Loaded: c.loaded,
+		Hash:   c.hash,
+	}
+}
+
+// Match returns the result of the first rule matching req, and false
+// when none match. Without a cache, requests which no rule matches are
+// matched without allocating.
+func (c *CompiledRules) Match(req Request) (Result, bool) {
+	c.mu.RLock()
+	matchers, cache, keys, hits := c.matchers, c.cache, c.keys, c.hits
+	c.mu.RUnlock()
+
+	var res Result
+	var ok bool
+
+	if cache == nil {
+		res, ok = matchAll(matchers, req)
+	} else {
+		key := keys.key(req)
+		if v, found := cache.get(key); found {
+			hit := v.(cached)
+			res, ok = hit.result, hit.ok
+		} else {
+			res, ok = matchAll(matchers, req)
+			cache.add(key, cached{res, ok})
+		}
+	}
+
+	if ok {
+		atomic.AddUint64(&hits[res.Index], 1)
+	}
+
+	return res, ok
+}
+
+// rulesHash returns the hex SHA-256 of rules in _redirects format, along
+// with their annotations so edits to Rule.Meta change it.
+func rulesHash(rules []Rule) string {
+	h := sha256.New()
+	for _, r := range rules {
+		r.StatusImplied = false
+		h.Write(appendRule(nil, r))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cached is a cached match result.
+type cached struct {
+	result Result
+	ok     bool
+}
+
+// cacheKeys records which parts of a request the rules consult.
+type cacheKeys struct {
+	host     bool
+	method   bool
+	country  bool
+	language bool
+	headers  []string
+}
+
+// newCacheKeys returns the cache keys for rules, and false when results
+// can not be cached.
+func newCacheKeys(rules []Rule) (k cacheKeys, cacheable bool) {
+	for _, r := range rules {
+		if !r.ActiveFrom.IsZero() || !r.ActiveUntil.IsZero() {
+			return k, false
+		}
+
+		k.host = k.host || len(r.Host) > 0 || !strings.HasPrefix(r.From, "/")
+		k.method = k.method || len(r.Method) > 0
+		k.country = k.country || len(r.Country) > 0
+		k.language = k.language || len(r.Language) > 0
+
+		for c := range r.Conditions {
+			if name := headerCondition(c); name != "" && !contains(k.headers, name) {
+				k.headers = append(k.headers, name)
+			}
+		}
+	}
+
+	return k, true
+}
+
+// key returns the cache key of req.
+func (k cacheKeys) key(req Request) string {
+	var b strings.Builder
+
+	b.WriteString(req.Path)
+	b.WriteByte(0)
+	b.WriteString(req.Query.Encode())
+
+	if k.host {
+		b.WriteByte(0)
+		b.WriteString(strings.ToLower(req.Host))
+	}
+
+	if k.method {
+		b.WriteByte(0)
+		b.WriteString(req.Method)
+	}
+
+	if k.country {
+		b.WriteByte(0)
+		b.WriteString(strings.ToLower(req.Country))
+	}
+
+	if k.language {
+		b.WriteByte(0)
+		b.WriteString(strings.ToLower(strings.Join(req.Language, ",")))
+	}
+
+	for _, h := range k.headers {
+		b.WriteByte(0)
+		b.WriteString(req.Header.Get(h))
+	}
+
+	return b.String()
+}
+
+// contains returns true if list contains s.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Estimated sizes in bytes of the parts of compiled rules, for ruleSize
+// and Size.
+const (
+	mapEntrySize   = 48
+	regexpByteSize = 40
+	stringSize     = int64(unsafe.Sizeof(""))
+	ruleBaseSize   = int64(unsafe.Sizeof(Rule{}) + unsafe.Sizeof(matcher{}))
+	partSize       = int64(unsafe.Sizeof(destinationPart{}))
+	hitsSize       = int64(unsafe.Sizeof(uint64(0)))
+	cacheEntrySize = mapEntrySize + int64(unsafe.Sizeof(list.Element{})+unsafe.Sizeof(entry{})+unsafe.Sizeof(cached{}))
+)
+
+// ruleSize returns an estimate of the memory in bytes of r once compiled,
+// including its matcher, source segments and prepared destination.
+func ruleSize(r *Rule) int64 {
+	n := ruleBaseSize + int64(len(r.From)+len(r.To))
+
+	from := sourcePattern(r.From)
+	if isRegexp(from) {
+		n += int64(len(from)) * regexpByteSize
+	} else {
+		n += int64(strings.Count(from, "/")) * stringSize
+	}
+
+	// the destination is split into parts referencing the literal text
+	n += int64(strings.Count(r.To, ":")+1) * partSize
+
+	for k, v := range r.Params {
+		n += mapEntrySize + int64(len(k))
+		if s, ok := v.(string); ok {
+			n += int64(len(s))
+		}
+	}
+
+	for k, v := range r.Conditions {
+		n += mapEntrySize + int64(len(k)+len(v))
+	}
+
+	for k, v := range r.Meta {
+		n += mapEntrySize + int64(len(k)+len(v))
+	}
+
+	for _, list := range [][]string{r.Country, r.Language, r.Method, r.Host} {
+		for _, s := range list {
+			n += stringSize + int64(len(s))
+		}
+	}
+
+	return n
+}
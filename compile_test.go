@@ -0,0 +1,240 @@
+package redirects_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestCompile(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/home       /
+		/blog/*     /posts/:splat
+		/           /anz     302  Country=au
+		/           /canary  200  Header:X-Canary=true
+	`))
+
+	for _, size := range []int{0, 2} {
+		t.Run(fmt.Sprintf("cache size %d", size), func(t *testing.T) {
+			c := redirects.Compile(rules, redirects.WithCacheSize(size))
+
+			for i := 0; i < 3; i++ {
+				res, ok := c.Match(redirects.Request{Path: "/blog/hello"})
+				assert.True(t, ok)
+				assert.Equal(t, "/posts/hello", res.To)
+
+				res, ok = c.Match(redirects.Request{Path: "/", Country: "AU"})
+				assert.True(t, ok)
+				assert.Equal(t, "/anz", res.To)
+
+				res, ok = c.Match(redirects.Request{Path: "/", Header: http.Header{"X-Canary": {"true"}}})
+				assert.True(t, ok)
+				assert.Equal(t, "/canary", res.To)
+
+				_, ok = c.Match(redirects.Request{Path: "/"})
+				assert.False(t, ok)
+
+				res, ok = c.Match(redirects.Request{Path: "/home", Query: url.Values{"a": {"b"}}})
+				assert.True(t, ok)
+				assert.Equal(t, "/?a=b", res.To)
+			}
+		})
+	}
+}
+
+func TestCompileAll(t *testing.T) {
+	files := func() map[string]io.Reader {
+		files := map[string]io.Reader{
+			"invalid.example.com": strings.NewReader("/home\n"),
+		}
+		for i := 0; i < 20; i++ {
+			files[fmt.Sprintf("site%d.example.com", i)] = strings.NewReader(fmt.Sprintf("/home  /%d\n", i))
+		}
+		return files
+	}
+
+	t.Run("compiles", func(t *testing.T) {
+		compiled, errs := redirects.CompileAll(context.Background(), files(), 4)
+		assert.Len(t, compiled, 20)
+		assert.Len(t, errs, 1)
+		assert.Equal(t, redirects.CodeMissingDestination, redirects.ErrorCode(errs["invalid.example.com"]))
+
+		res, ok := compiled["site7.example.com"].Match(redirects.Request{Path: "/home"})
+		assert.True(t, ok)
+		assert.Equal(t, "/7", res.To)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		compiled, errs := redirects.CompileAll(ctx, files(), 0)
+		assert.Len(t, errs, 21-len(compiled))
+		for _, err := range errs {
+			assert.Equal(t, context.Canceled, err)
+		}
+	})
+}
+
+func TestCompiledRules_Reload(t *testing.T) {
+	c := redirects.Compile(redirects.Must(redirects.ParseString(`/home /`)), redirects.WithCacheSize(10))
+
+	res, _ := c.Match(redirects.Request{Path: "/home"})
+	assert.Equal(t, "/", res.To)
+
+	c.Reload(redirects.Must(redirects.ParseString(`/home /welcome`)))
+
+	res, _ = c.Match(redirects.Request{Path: "/home"})
+	assert.Equal(t, "/welcome", res.To)
+	assert.Equal(t, "/home", c.Rules()[0].From)
+}
+
+func TestCompiledRules_Size(t *testing.T) {
+	small := redirects.Compile(redirects.Must(redirects.ParseString(`/home  /`)), redirects.WithCacheSize(10))
+	large := redirects.Compile(redirects.Must(redirects.ParseString(`
+		/home       /
+		/blog/*     /posts/:splat
+		/search     q=:q  /find?q=:q
+		/           /anz  302  Country=au,nz
+	`)))
+
+	assert.Greater(t, small.Size(), int64(0))
+	assert.Greater(t, large.Size(), small.Size())
+
+	before := small.Size()
+	small.Match(redirects.Request{Path: "/home"})
+	assert.Greater(t, small.Size(), before)
+
+	small.Reload(nil)
+	assert.Equal(t, int64(0), small.Size())
+}
+
+func TestCompiledRules_Changed(t *testing.T) {
+	c := redirects.Compile(redirects.Must(redirects.ParseString(`/home /`)))
+	changed := c.Changed()
+
+	select {
+	case <-changed:
+		t.Fatal("changed before reloading")
+	default:
+	}
+
+	c.Reload(nil)
+
+	select {
+	case <-changed:
+	default:
+		t.Fatal("not changed after reloading")
+	}
+
+	assert.NotEqual(t, changed, c.Changed())
+}
+
+func TestCompiledRules_schedule(t *testing.T) {
+	c := redirects.Compile(redirects.Must(redirects.ParseString(`
+		/sale  /winter  302  From=2024-12-01 Until=2025-01-01
+		/sale  /sales
+	`)), redirects.WithCacheSize(10))
+
+	res, _ := c.Match(redirects.Request{Path: "/sale"})
+	assert.Equal(t, "/sales", res.To)
+}
+
+func TestCompiledRules_allocs(t *testing.T) {
+	c := redirects.Compile(redirects.Must(redirects.ParseString(`
+		/legacy/page-1     /pages/1
+		/store id=:id      /items/:id
+		/user/:id(int)     /users/:id
+		/blog/:year/:slug  /posts/:year/:slug
+		/docs/*            /documentation/:splat
+		/a  /b  302  Country=us Language=en Method=GET Header:X-A=b
+		https://example.com/x  /y
+		~^/archive/(\d+)$  /posts/$1
+	`, redirects.WithRegexRules())))
+
+	allocs := func(path string) float64 {
+		req := redirects.Request{Host: "example.org", Method: "GET", Path: path, Country: "nz", Language: []string{"en"}}
+		return testing.AllocsPerRun(100, func() {
+			c.Match(req)
+		})
+	}
+
+	t.Run("not matching", func(t *testing.T) {
+		for _, path := range []string{"/", "/about/team", "/user/abc", "/a", "/x", "/archive/latest", "/deeply/nested/path/to/a/page/not/covered"} {
+			assert.Equal(t, 0.0, allocs(path), path)
+		}
+	})
+
+	t.Run("matching", func(t *testing.T) {
+		assert.Equal(t, 0.0, allocs("/legacy/page-1"))
+
+		// only the destination
+		assert.LessOrEqual(t, allocs("/blog/2020/hello"), 1.0)
+		assert.LessOrEqual(t, allocs("/docs/guide/intro"), 1.0)
+	})
+}
+
+// benchmarkRules returns n static rules followed by a few wildcards.
+func benchmarkRules(n int) (rules []redirects.Rule) {
+	for i := 0; i < n; i++ {
+		rules = append(rules, redirects.Rule{
+			From:   fmt.Sprintf("/legacy/page-%d", i),
+			To:     fmt.Sprintf("/pages/%d", i),
+			Status: 301,
+		})
+	}
+
+	return append(rules, redirects.Must(redirects.ParseString(`
+		/blog/:year/:slug  /posts/:year/:slug
+		/docs/*            /documentation/:splat
+		/*                 /index.html  200
+	`))...)
+}
+
+// skewedRequests returns n requests over the rules following a Zipf
+// distribution, so a few paths receive most of the traffic.
+func skewedRequests(rules []redirects.Rule, n int) []redirects.Request {
+	r := rand.New(rand.NewSource(1))
+	z := rand.NewZipf(r, 1.2, 1, uint64(len(rules)-1))
+
+	reqs := make([]redirects.Request, n)
+	for i := range reqs {
+		reqs[i] = redirects.Request{Path: fmt.Sprintf("/legacy/page-%d", z.Uint64()*7%uint64(len(rules)))}
+	}
+
+	return reqs
+}
+
+func BenchmarkMatch(b *testing.B) {
+	rules := benchmarkRules(1000)
+	reqs := skewedRequests(rules, 1024)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		redirects.Match(rules, reqs[i%len(reqs)])
+	}
+}
+
+func BenchmarkCompiledRules_Match(b *testing.B) {
+	rules := benchmarkRules(1000)
+	reqs := skewedRequests(rules, 1024)
+
+	for _, size := range []int{0, 64, 1024} {
+		b.Run(fmt.Sprintf("cache size %d", size), func(b *testing.B) {
+			c := redirects.Compile(rules, redirects.WithCacheSize(size))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				c.Match(reqs[i%len(reqs)])
+			}
+		})
+	}
+}
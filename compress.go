@@ -0,0 +1,88 @@
+package redirects
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// A Decompressor decompresses files starting with its magic bytes, see
+// WithDecompression.
+type Decompressor struct {
+	// Magic is the prefix identifying compressed files.
+	Magic []byte
+
+	// NewReader returns a reader of the decompressed file.
+	NewReader func(io.Reader) (io.Reader, error)
+}
+
+// Gzip decompresses gzip files.
+var Gzip = Decompressor{
+	Magic: []byte{0x1f, 0x8b},
+	NewReader: func(r io.Reader) (io.Reader, error) {
+		return gzip.NewReader(r)
+	},
+}
+
+// ZstdMagic is the prefix of zstd files, for a Decompressor using a zstd
+// implementation such as github.com/klauspost/compress/zstd.
+var ZstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// WithDecompression transparently decompresses files compressed by one of
+// the decompressors, as gateways often store site artifacts, sniffing their
+// magic bytes. Gzip is used when none are given, and files without a known
+// prefix are parsed as is. Decompressed files larger than maxSize bytes
+// fail parsing with ErrFileTooLarge, guarding against decompression bombs,
+// unless maxSize is zero.
+func WithDecompression(maxSize int64, decompressors ...Decompressor) Option {
+	if len(decompressors) == 0 {
+		decompressors = []Decompressor{Gzip}
+	}
+
+	return func(c *config) {
+		c.maxDecompressedSize = maxSize
+		c.decompressors = decompressors
+	}
+}
+
+// decompress returns a reader of r decompressed by the decompressor
+// matching its magic bytes, or of r as is when none match.
+func (c *config) decompress(r io.Reader) (io.Reader, error) {
+	if len(c.decompressors) == 0 {
+		return r, nil
+	}
+
+	br := bufio.NewReader(r)
+
+	for _, d := range c.decompressors {
+		magic, _ := br.Peek(len(d.Magic))
+		if len(d.Magic) == 0 || !bytes.Equal(magic, d.Magic) {
+			continue
+		}
+
+		dr, err := d.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("decompressing: %w", err)
+		}
+
+		if c.maxDecompressedSize > 0 {
+			dr = io.LimitReader(dr, c.maxDecompressedSize+1)
+		}
+
+		// read in full so that errors are not mistaken for a truncated line
+		b, err := io.ReadAll(dr)
+		if err != nil {
+			return nil, fmt.Errorf("decompressing: %w", err)
+		}
+
+		if c.maxDecompressedSize > 0 && int64(len(b)) > c.maxDecompressedSize {
+			return nil, fmt.Errorf("%w, larger than %d bytes decompressed", ErrFileTooLarge, c.maxDecompressedSize)
+		}
+
+		return bytes.NewReader(b), nil
+	}
+
+	return br, nil
+}
@@ -0,0 +1,78 @@
+package redirects_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+
+	"github.com/fission-suite/go-redirects"
+)
+
+// gzipped returns s compressed with gzip.
+func gzipped(s string) []byte {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	w.Write([]byte(s))
+	w.Close()
+	return b.Bytes()
+}
+
+func TestWithDecompression(t *testing.T) {
+	const file = "/home  /\n/news  /blog  302\n"
+
+	t.Run("gzip", func(t *testing.T) {
+		rules, err := redirects.Parse(bytes.NewReader(gzipped(file)), redirects.WithDecompression(1<<20))
+		assert.NoError(t, err)
+		assert.Len(t, rules, 2)
+		assert.Equal(t, "/blog", rules[1].To)
+	})
+
+	t.Run("uncompressed", func(t *testing.T) {
+		rules, err := redirects.Parse(strings.NewReader(file), redirects.WithDecompression(1<<20))
+		assert.NoError(t, err)
+		assert.Len(t, rules, 2)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		bomb := gzipped(strings.Repeat("/a  /b\n", 100000))
+		assert.Less(t, len(bomb), 4096)
+
+		_, err := redirects.Parse(bytes.NewReader(bomb), redirects.WithDecompression(64<<10))
+		assert.True(t, errors.Is(err, redirects.ErrFileTooLarge))
+		assert.Equal(t, redirects.CodeFileTooLarge, redirects.ErrorCode(err))
+	})
+
+	t.Run("exact size", func(t *testing.T) {
+		rules, err := redirects.Parse(bytes.NewReader(gzipped(file)), redirects.WithDecompression(int64(len(file))))
+		assert.NoError(t, err)
+		assert.Len(t, rules, 2)
+	})
+
+	t.Run("corrupt", func(t *testing.T) {
+		_, err := redirects.Parse(bytes.NewReader([]byte{0x1f, 0x8b, 0}), redirects.WithDecompression(1<<20))
+		assert.Error(t, err)
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		upper := redirects.Decompressor{
+			Magic: redirects.ZstdMagic,
+			NewReader: func(r io.Reader) (io.Reader, error) {
+				b, err := io.ReadAll(r)
+				return bytes.NewReader(bytes.TrimPrefix(b, redirects.ZstdMagic)), err
+			},
+		}
+
+		input := append(append([]byte{}, redirects.ZstdMagic...), file...)
+		rules, err := redirects.Parse(bytes.NewReader(input), redirects.WithDecompression(1<<20, upper))
+		assert.NoError(t, err)
+		assert.Len(t, rules, 2)
+
+		_, err = redirects.Parse(bytes.NewReader(gzipped(file)), redirects.WithDecompression(1<<20, upper))
+		assert.Error(t, err)
+	})
+}
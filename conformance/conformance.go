@@ -0,0 +1,137 @@
+// Package conformance provides a corpus of _redirects files with the rules
+// they parse into, so other implementations can verify they parse
+// identically to this package.
+//
+// Each case is a NAME.redirects file along with either NAME.json, holding
+// the expected rules as JSON, or NAME.err, describing why parsing must fail.
+//
+// The examples of Netlify's redirects documentation are provided with how
+// Netlify serves requests, verifying matching as well, along with a
+// compatibility matrix of the features this package diverges on.
+package conformance
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+)
+
+//go:embed testdata
+var corpus embed.FS
+
+// A Case is a _redirects file along with the expected outcome of parsing it.
+type Case struct {
+	// Name of the case.
+	Name string
+
+	// Input is the _redirects file.
+	Input []byte
+
+	// Expected is the JSON encoding of the rules, or nil when parsing fails.
+	Expected []byte
+
+	// Error describes why parsing must fail, or is empty when it succeeds.
+	Error string
+}
+
+// ParseFunc parses a _redirects file.
+type ParseFunc func([]byte) ([]redirects.Rule, error)
+
+// FS returns the corpus files.
+func FS() fs.FS {
+	sub, _ := fs.Sub(corpus, "testdata")
+	return sub
+}
+
+// Cases returns the corpus ordered by name.
+func Cases() ([]Case, error) {
+	return LoadCases(FS())
+}
+
+// LoadCases returns the cases in the root of fsys ordered by name, so
+// corpora outside this package can be loaded the same way.
+func LoadCases(fsys fs.FS) (cases []Case, err error) {
+	names, err := fs.Glob(fsys, "*.redirects")
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		c := Case{Name: strings.TrimSuffix(name, path.Ext(name))}
+
+		if c.Input, err = fs.ReadFile(fsys, name); err != nil {
+			return nil, err
+		}
+
+		if b, err := fs.ReadFile(fsys, c.Name+".err"); err == nil {
+			c.Error = strings.TrimSpace(string(b))
+		} else if c.Expected, err = fs.ReadFile(fsys, c.Name+".json"); err != nil {
+			return nil, err
+		}
+
+		cases = append(cases, c)
+	}
+
+	return
+}
+
+// Run verifies parse against every case in the corpus, as a subtest per case.
+func Run(t *testing.T, parse ParseFunc) {
+	cases, err := Cases()
+	if err != nil {
+		t.Fatalf("loading cases: %s", err)
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			if err := c.Check(parse); err != "" {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// Check returns a description of how parse disagrees with the case, or
+// an empty string when it agrees.
+func (c Case) Check(parse ParseFunc) string {
+	rules, err := parse(c.Input)
+
+	if c.Error != "" {
+		if err == nil {
+			return "expected an error: " + c.Error
+		}
+		return ""
+	}
+
+	if err != nil {
+		return "unexpected error: " + err.Error()
+	}
+
+	actual, err := json.Marshal(rules)
+	if err != nil {
+		return "encoding rules: " + err.Error()
+	}
+
+	var want, got interface{}
+	if err := json.Unmarshal(c.Expected, &want); err != nil {
+		return "decoding expected rules: " + err.Error()
+	}
+
+	json.Unmarshal(actual, &got)
+
+	if !reflect.DeepEqual(want, got) {
+		return "rules differ\nexpected: " + string(c.Expected) + "\nactual:   " + string(actual)
+	}
+
+	return ""
+}
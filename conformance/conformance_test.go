@@ -0,0 +1,108 @@
+package conformance_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/fission-suite/go-redirects/conformance"
+	"github.com/tj/assert"
+)
+
+var update = flag.Bool("update", false, "update the expected JSON files")
+
+// parse is the ParseFunc of this package.
+func parse(b []byte) ([]redirects.Rule, error) {
+	return redirects.Parse(bytes.NewReader(b))
+}
+
+func TestRun(t *testing.T) {
+	if *update {
+		cases, err := conformance.Cases()
+		assert.NoError(t, err)
+
+		for _, c := range cases {
+			if c.Error != "" {
+				continue
+			}
+
+			rules, err := parse(c.Input)
+			assert.NoError(t, err)
+
+			b, err := json.MarshalIndent(rules, "", "  ")
+			assert.NoError(t, err)
+
+			err = os.WriteFile(filepath.Join("testdata", c.Name+".json"), append(b, '\n'), 0644)
+			assert.NoError(t, err)
+		}
+	}
+
+	conformance.Run(t, parse)
+}
+
+func TestCases(t *testing.T) {
+	cases, err := conformance.Cases()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, cases)
+
+	for _, c := range cases {
+		assert.NotEmpty(t, c.Input, c.Name)
+		assert.True(t, c.Error != "" || len(c.Expected) > 0, c.Name)
+	}
+}
+
+func TestCase_Check(t *testing.T) {
+	c := conformance.Case{
+		Name:     "example",
+		Input:    []byte("/a /b"),
+		Expected: []byte(`[{"From":"/a","To":"/c"}]`),
+	}
+
+	assert.Contains(t, c.Check(parse), "rules differ")
+
+	c.Expected = nil
+	c.Error = "must fail"
+	assert.Equal(t, "expected an error: must fail", c.Check(parse))
+}
+
+// apply is the ApplyFunc of this package.
+func apply(b []byte, req redirects.Request) (redirects.Response, error) {
+	rules, err := parse(b)
+	if err != nil {
+		return redirects.Response{}, err
+	}
+
+	return redirects.Apply(rules, req), nil
+}
+
+func TestRunExamples(t *testing.T) {
+	conformance.RunExamples(t, apply)
+}
+
+func TestExamples(t *testing.T) {
+	features := make(map[string]conformance.Feature)
+	for _, f := range conformance.Compatibility() {
+		features[f.Name] = f
+	}
+
+	for _, e := range conformance.Examples() {
+		assert.NotEmpty(t, e.Doc, e.Name)
+
+		if e.Divergence == "" {
+			assert.NotEmpty(t, e.Requests, e.Name)
+			continue
+		}
+
+		f, ok := features[e.Divergence]
+		assert.True(t, ok, e.Name)
+		assert.NotEqual(t, conformance.Supported, f.Support, e.Name)
+		assert.NotEmpty(t, f.Note, e.Name)
+
+		_, err := parse([]byte(e.Input))
+		assert.Error(t, err, e.Name)
+	}
+}
@@ -0,0 +1,320 @@
+package conformance
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+)
+
+// docs is the base URL of Netlify's redirects documentation.
+const docs = "https://docs.netlify.com/routing/redirects/"
+
+// An Example is a rule set from Netlify's redirects documentation along with
+// how Netlify serves requests with it.
+type Example struct {
+	// Name of the example.
+	Name string
+
+	// Doc is the documentation page of the example.
+	Doc string
+
+	// Input is the _redirects file.
+	Input string
+
+	// Requests are the requests served by the rules.
+	Requests []ExampleRequest
+
+	// Divergence is the name of the Feature this package does not support
+	// as Netlify does, or empty when it serves the example identically.
+	Divergence string
+}
+
+// An ExampleRequest is a request along with how Netlify serves it.
+type ExampleRequest struct {
+	// Request is the request, with a path and optionally a query string,
+	// host, country, languages or existing content.
+	Request redirects.Request
+
+	// Action is what is done with the request.
+	Action redirects.Action
+
+	// To is the destination, or empty for redirects.ActionNone.
+	To string
+
+	// Status is the status code, or zero for redirects.ActionNone and
+	// redirects.ActionProxy.
+	Status int
+}
+
+// Support is how completely a Feature is supported.
+type Support string
+
+// Support levels.
+const (
+	Supported   Support = "supported"
+	Partial     Support = "partial"
+	Unsupported Support = "unsupported"
+)
+
+// A Feature is a documented Netlify redirects feature along with how this
+// package supports it.
+type Feature struct {
+	// Name of the feature.
+	Name string
+
+	// Support is how completely this package supports the feature.
+	Support Support
+
+	// Note describes any difference from Netlify.
+	Note string
+}
+
+// ApplyFunc returns the response to req of the rules parsed from input.
+type ApplyFunc func(input []byte, req redirects.Request) (redirects.Response, error)
+
+// compatibility is the compatibility matrix.
+var compatibility = []Feature{
+	{Name: "redirects", Support: Supported},
+	{Name: "status-codes", Support: Supported, Note: "any other 2xx, 4xx or 5xx status is also served as content"},
+	{Name: "splats", Support: Supported},
+	{Name: "placeholders", Support: Supported},
+	{Name: "query-params", Support: Supported},
+	{Name: "query-passthrough", Support: Supported},
+	{Name: "trailing-slash", Support: Supported},
+	{Name: "shadowing", Support: Supported, Note: "Apply skips unforced rules when Request.FileExists is set, and Handler when its Content reports existing content; a Handler without Content applies every rule as though forced"},
+	{Name: "custom-404", Support: Supported},
+	{Name: "single-page-apps", Support: Supported},
+	{Name: "proxies", Support: Supported},
+	{Name: "domain-level", Support: Supported, Note: "absolute sources match Request.Host regardless of scheme"},
+	{Name: "country-language", Support: Supported},
+	{Name: "role-conditions", Support: Unsupported, Note: "Role conditions fail parsing unless skipped with WithUnknownOptionPolicy"},
+	{Name: "signed-proxies", Support: Unsupported, Note: "Signed conditions fail parsing unless skipped with WithUnknownOptionPolicy"},
+}
+
+// examples are Netlify's documented examples.
+var examples = []Example{
+	{
+		Name: "redirects",
+		Doc:  docs,
+		Input: `
+# Redirects from what the browser requests to what we serve
+/home              /
+/blog/my-post.php  /blog/my-post
+/news              /blog
+/cuties            https://www.petsofnetlify.com
+`,
+		Requests: []ExampleRequest{
+			{Request: get("/home"), Action: redirects.ActionRedirect, To: "/", Status: 301},
+			{Request: get("/blog/my-post.php"), Action: redirects.ActionRedirect, To: "/blog/my-post", Status: 301},
+			{Request: get("/news"), Action: redirects.ActionRedirect, To: "/blog", Status: 301},
+			{Request: get("/cuties"), Action: redirects.ActionRedirect, To: "https://www.petsofnetlify.com", Status: 301},
+			{Request: get("/about"), Action: redirects.ActionNone},
+		},
+	},
+	{
+		Name: "status-codes",
+		Doc:  docs + "redirect-options/#http-status-codes",
+		Input: `
+/home         /              301
+/my-redirect  /              302
+/pass-through /index.html    200
+/ecommerce    /store-closed  404
+/admin/*      /login         401!
+`,
+		Requests: []ExampleRequest{
+			{Request: get("/home"), Action: redirects.ActionRedirect, To: "/", Status: 301},
+			{Request: get("/my-redirect"), Action: redirects.ActionRedirect, To: "/", Status: 302},
+			{Request: get("/pass-through"), Action: redirects.ActionRewrite, To: "/index.html", Status: 200},
+			{Request: get("/ecommerce"), Action: redirects.ActionRewrite, To: "/store-closed", Status: 404},
+			{Request: get("/admin/users"), Action: redirects.ActionRewrite, To: "/login", Status: 401},
+		},
+	},
+	{
+		Name:  "splats",
+		Doc:   docs + "redirect-options/#splats",
+		Input: "/news/*  /blog/:splat\n",
+		Requests: []ExampleRequest{
+			{Request: get("/news/2004/01/10/my-story"), Action: redirects.ActionRedirect, To: "/blog/2004/01/10/my-story", Status: 301},
+		},
+	},
+	{
+		Name:  "placeholders",
+		Doc:   docs + "redirect-options/#placeholders",
+		Input: "/news/:month/:date/:year/:slug  /blog/:year/:month/:date/:slug\n",
+		Requests: []ExampleRequest{
+			{Request: get("/news/02/12/2004/my-story"), Action: redirects.ActionRedirect, To: "/blog/2004/02/12/my-story", Status: 301},
+			{Request: get("/news/02/12/2004"), Action: redirects.ActionNone},
+		},
+	},
+	{
+		Name: "query-params",
+		Doc:  docs + "redirect-options/#query-parameters",
+		Input: `
+/store id=:id  /blog/:id  301
+/articles id=:id tag=:tag /posts/:tag/:id 301
+`,
+		Requests: []ExampleRequest{
+			{Request: get("/store?id=my-post"), Action: redirects.ActionRedirect, To: "/blog/my-post", Status: 301},
+			{Request: get("/store"), Action: redirects.ActionNone},
+			{Request: get("/articles?id=5&tag=netlify"), Action: redirects.ActionRedirect, To: "/posts/netlify/5", Status: 301},
+			{Request: get("/articles?id=5"), Action: redirects.ActionNone},
+		},
+	},
+	{
+		Name:  "query-passthrough",
+		Doc:   docs + "redirect-options/#query-parameters",
+		Input: "/blog/*  /news/:splat  301\n",
+		Requests: []ExampleRequest{
+			{Request: get("/blog/my-post?utm_source=newsletter"), Action: redirects.ActionRedirect, To: "/news/my-post?utm_source=newsletter", Status: 301},
+		},
+	},
+	{
+		Name:  "trailing-slash",
+		Doc:   docs + "redirect-options/#trailing-slash",
+		Input: "/blog/title-with-trailing-slash/  /news  301\n",
+		Requests: []ExampleRequest{
+			{Request: get("/blog/title-with-trailing-slash"), Action: redirects.ActionRedirect, To: "/news", Status: 301},
+			{Request: get("/blog/title-with-trailing-slash/"), Action: redirects.ActionRedirect, To: "/news", Status: 301},
+		},
+	},
+	{
+		Name: "shadowing",
+		Doc:  docs + "rewrites-proxies/#shadowing",
+		Input: `
+/app/*     /app/index.html  200
+/static/*  /app/index.html  200!
+`,
+		Requests: []ExampleRequest{
+			{Request: get("/app/settings"), Action: redirects.ActionRewrite, To: "/app/index.html", Status: 200},
+			{Request: existing("/app/logo.png"), Action: redirects.ActionNone},
+			{Request: existing("/static/logo.png"), Action: redirects.ActionRewrite, To: "/app/index.html", Status: 200},
+		},
+	},
+	{
+		Name: "custom-404",
+		Doc:  docs + "redirect-options/#custom-404-page-handling",
+		Input: `
+/en/*  /en/404.html  404
+/de/*  /de/404.html  404
+`,
+		Requests: []ExampleRequest{
+			{Request: get("/de/missing"), Action: redirects.ActionRewrite, To: "/de/404.html", Status: 404},
+			{Request: existing("/de/index.html"), Action: redirects.ActionNone},
+		},
+	},
+	{
+		Name:  "single-page-apps",
+		Doc:   docs + "rewrites-proxies/#history-pushstate-and-single-page-apps",
+		Input: "/*    /index.html   200\n",
+		Requests: []ExampleRequest{
+			{Request: get("/dashboard/settings"), Action: redirects.ActionRewrite, To: "/index.html", Status: 200},
+			{Request: existing("/main.js"), Action: redirects.ActionNone},
+		},
+	},
+	{
+		Name:  "proxies",
+		Doc:   docs + "rewrites-proxies/#proxy-to-another-service",
+		Input: "/api/*  https://api.example.com/:splat  200\n",
+		Requests: []ExampleRequest{
+			{Request: get("/api/users"), Action: redirects.ActionProxy, To: "https://api.example.com/users"},
+		},
+	},
+	{
+		Name: "domain-level",
+		Doc:  docs + "redirect-options/#domain-level-redirects",
+		Input: `
+http://blog.yoursite.com/*  https://www.yoursite.com/blog/:splat  301!
+https://blog.yoursite.com/* https://www.yoursite.com/blog/:splat  301!
+`,
+		Requests: []ExampleRequest{
+			{Request: host("blog.yoursite.com", "/my-post"), Action: redirects.ActionRedirect, To: "https://www.yoursite.com/blog/my-post", Status: 301},
+			{Request: host("www.yoursite.com", "/my-post"), Action: redirects.ActionNone},
+		},
+	},
+	{
+		Name: "country-language",
+		Doc:  docs + "redirect-by-country-or-language/",
+		Input: `
+# Redirect users in Australia or New Zealand to /anz.
+/  /anz     302  Country=au,nz
+# Redirect users in Israel to /israel
+/  /israel  302  Country=il
+# Redirect users with Chinese language preference from /china to /china/zh-cn
+/china/*  /china/zh-cn/:splat  302  Language=zh
+`,
+		Requests: []ExampleRequest{
+			{Request: redirects.Request{Method: "GET", Path: "/", Country: "NZ"}, Action: redirects.ActionRedirect, To: "/anz", Status: 302},
+			{Request: redirects.Request{Method: "GET", Path: "/", Country: "IL"}, Action: redirects.ActionRedirect, To: "/israel", Status: 302},
+			{Request: redirects.Request{Method: "GET", Path: "/", Country: "US"}, Action: redirects.ActionNone},
+			{Request: redirects.Request{Method: "GET", Path: "/china/about", Language: []string{"zh"}}, Action: redirects.ActionRedirect, To: "/china/zh-cn/about", Status: 302},
+		},
+	},
+	{
+		Name:       "role-conditions",
+		Doc:        "https://docs.netlify.com/visitor-access/role-based-access-control/",
+		Input:      "/admin/*  /admin/:splat  200!  Role=admin\n",
+		Divergence: "role-conditions",
+	},
+	{
+		Name:       "signed-proxies",
+		Doc:        docs + "rewrites-proxies/#signed-proxy-redirects",
+		Input:      "/api/*  https://api.example.com/:splat  200  Signed=API_SIGNATURE_TOKEN\n",
+		Divergence: "signed-proxies",
+	},
+}
+
+// Examples returns Netlify's documented examples.
+func Examples() []Example {
+	return append([]Example(nil), examples...)
+}
+
+// Compatibility returns the documented Netlify features along with how this
+// package supports them. Examples with a Divergence name one of them.
+func Compatibility() []Feature {
+	return append([]Feature(nil), compatibility...)
+}
+
+// RunExamples verifies apply against every example, as a subtest per
+// example. Examples of features this package diverges on are skipped.
+func RunExamples(t *testing.T, apply ApplyFunc) {
+	for _, e := range examples {
+		e := e
+		t.Run(e.Name, func(t *testing.T) {
+			if e.Divergence != "" {
+				t.Skipf("diverges from Netlify, see the %q feature", e.Divergence)
+			}
+
+			for _, r := range e.Requests {
+				res, err := apply([]byte(e.Input), r.Request)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				if res.Action != r.Action || res.To != r.To || res.Status != r.Status {
+					t.Errorf("%s: expected %s %q %d, got %s %q %d", r.Request.Path, r.Action, r.To, r.Status, res.Action, res.To, res.Status)
+				}
+			}
+		})
+	}
+}
+
+// get returns a GET request for a path with an optional query string.
+func get(path string) redirects.Request {
+	u, _ := url.ParseRequestURI(path)
+	return redirects.Request{Method: "GET", Path: u.Path, Query: u.Query()}
+}
+
+// existing returns a GET request for path with content existing there.
+func existing(path string) redirects.Request {
+	req := get(path)
+	req.FileExists = true
+	return req
+}
+
+// host returns a GET request for path on host.
+func host(host, path string) redirects.Request {
+	req := get(path)
+	req.Host = host
+	return req
+}
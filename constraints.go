@@ -0,0 +1,108 @@
+package redirects
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// constraints are the expressions of the named placeholder constraints.
+var constraints = map[string]string{
+	"int":   `[0-9]+`,
+	"alpha": `[A-Za-z]+`,
+	"alnum": `[A-Za-z0-9]+`,
+	"uuid":  `[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}`,
+}
+
+// constraintRegexps are the compiled constraint expressions.
+var constraintRegexps sync.Map
+
+// placeholderName returns the name and constraint of a placeholder segment
+// such as ":id(int)", the constraint being empty when there is none.
+func placeholderName(s string) (name, constraint string) {
+	name = strings.TrimPrefix(s, ":")
+
+	if i := strings.IndexByte(name, '('); i >= 0 && strings.HasSuffix(name, ")") {
+		return name[:i], name[i+1 : len(name)-1]
+	}
+
+	return name, ""
+}
+
+// constraintExpr returns the regular expression of a constraint, such as
+// "int" or "ext=png,jpg", or an error if it is not known.
+func constraintExpr(constraint string) (string, error) {
+	if expr, ok := constraints[constraint]; ok {
+		return expr, nil
+	}
+
+	exts := strings.TrimPrefix(constraint, "ext=")
+	if exts == constraint || exts == "" {
+		return "", errorf(CodeInvalidConstraint, "unknown placeholder constraint %q", constraint)
+	}
+
+	var alts []string
+	for _, ext := range strings.Split(exts, ",") {
+		if ext == "" {
+			return "", errorf(CodeInvalidConstraint, "empty extension in placeholder constraint %q", constraint)
+		}
+		alts = append(alts, regexp.QuoteMeta(ext))
+	}
+
+	return `[^/]*\.(?:` + strings.Join(alts, "|") + `)`, nil
+}
+
+// matchConstraint returns true if the path segment satisfies the constraint.
+func matchConstraint(constraint, segment string) bool {
+	if constraint == "" {
+		return true
+	}
+
+	if re, ok := constraintRegexps.Load(constraint); ok {
+		return re.(*regexp.Regexp).MatchString(segment)
+	}
+
+	expr, err := constraintExpr(constraint)
+	if err != nil {
+		return false
+	}
+
+	re := regexp.MustCompile(`^(?:` + expr + `)$`)
+	constraintRegexps.Store(constraint, re)
+	return re.MatchString(segment)
+}
+
+// validateConstraints returns the problems with the placeholder constraints
+// of the source path.
+func validateConstraints(from string) (errs []error) {
+	for _, s := range segments(from) {
+		if segmentKind(s) != placeholderSegment {
+			continue
+		}
+
+		name, c := placeholderName(s)
+		if strings.ContainsAny(name, "()") {
+			errs = append(errs, errorf(CodeInvalidConstraint, "malformed placeholder %q", s))
+			continue
+		}
+
+		if c != "" {
+			if _, err := constraintExpr(c); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return
+}
+
+// constrained returns true if the source path has a placeholder constraint.
+func constrained(from string) bool {
+	for _, s := range segments(from) {
+		if _, c := placeholderName(s); segmentKind(s) == placeholderSegment && c != "" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,86 @@
+package redirects_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestMatch_constraints(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/user/:id(int)              /users/:id
+		/user/:name                 /people/:name
+		/file/:name(ext=png,jpg)    /images/:name
+		/order/:id(uuid)            /orders/:id
+		/tag/:tag(alpha)            /tags/:tag
+	`))
+
+	cases := []struct {
+		path string
+		to   string
+		ok   bool
+	}{
+		{"/user/42", "/users/42", true},
+		{"/user/tj", "/people/tj", true},
+		{"/file/logo.png", "/images/logo.png", true},
+		{"/file/logo.jpg", "/images/logo.jpg", true},
+		{"/file/logo.gif", "", false},
+		{"/file/png", "", false},
+		{"/order/123e4567-e89b-12d3-a456-426614174000", "/orders/123e4567-e89b-12d3-a456-426614174000", true},
+		{"/order/123", "", false},
+		{"/tag/go", "/tags/go", true},
+		{"/tag/go1", "", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			res, ok := redirects.Match(rules, redirects.Request{Path: c.path})
+			assert.Equal(t, c.ok, ok)
+			assert.Equal(t, c.to, res.To)
+		})
+	}
+
+	t.Run("captures", func(t *testing.T) {
+		res, ok := redirects.Match(rules, redirects.Request{Path: "/user/42"})
+		assert.True(t, ok)
+		assert.Equal(t, map[string]string{"id": "42"}, res.Captures.Map())
+	})
+}
+
+func TestParse_constraints(t *testing.T) {
+	t.Run("unknown", func(t *testing.T) {
+		_, err := redirects.ParseString(`/user/:id(float)  /users/:id`)
+		assert.EqualError(t, err, `line 1: unknown placeholder constraint "float": "/user/:id(float)  /users/:id"`)
+	})
+
+	t.Run("empty extension", func(t *testing.T) {
+		_, err := redirects.ParseString(`/file/:name(ext=png,)  /images/:name`)
+		assert.Error(t, err)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := redirects.ParseString(`/user/:id(int  /users/:id`)
+		assert.Error(t, err)
+	})
+}
+
+func TestShadows_constraints(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/user/:id(int)  /users/:id
+		/user/tj        /people/tj
+		/user/42        /users/the-answer
+	`))
+
+	assert.Equal(t, []redirects.Shadow{{Rule: 0, Shadowed: 2}}, redirects.Shadows(rules))
+}
+
+func TestWriteCloudFrontFunction_constraints(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`/user/:id(int)  /users/:id`))
+
+	var b strings.Builder
+	_, err := redirects.WriteCloudFrontFunction(&b, rules)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), `"pattern": "^/user/([0-9]+)/?$"`)
+}
@@ -0,0 +1,213 @@
+package redirects
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConsulStore is a RuleStore keeping rules as a JSON array in a Consul KV
+// key, watching it with blocking queries so every node of a fleet reloads
+// the rules as soon as they change.
+type ConsulStore struct {
+	// Address is the Consul HTTP API address. Defaults to
+	// "http://127.0.0.1:8500".
+	Address string
+
+	// Key is the key holding the rules, such as "redirects/example.com".
+	Key string
+
+	// Token is the optional ACL token.
+	Token string
+
+	// Client is the HTTP client. Defaults to http.DefaultClient.
+	Client *http.Client
+
+	// Wait is the longest a blocking query waits for a change. Defaults to
+	// 5 minutes.
+	Wait time.Duration
+
+	// Retry is the delay before retrying a failed query. Defaults to 5
+	// seconds.
+	Retry time.Duration
+}
+
+// Load implementation. A missing key has no rules.
+func (s *ConsulStore) Load(ctx context.Context) ([]Rule, error) {
+	rules, _, err := s.get(ctx, 0)
+	return rules, err
+}
+
+// Save implementation.
+func (s *ConsulStore) Save(ctx context.Context, rules []Rule) error {
+	if rules == nil {
+		rules = []Rule{}
+	}
+
+	b, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.do(ctx, http.MethodPut, nil, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return consulError(res)
+	}
+
+	return nil
+}
+
+// Watch implementation. Values which fail to load are skipped.
+func (s *ConsulStore) Watch(ctx context.Context) (<-chan []Rule, error) {
+	_, index, err := s.get(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := s.Retry
+	if retry == 0 {
+		retry = 5 * time.Second
+	}
+
+	ch := make(chan []Rule)
+
+	go func() {
+		defer close(ch)
+
+		for {
+			rules, next, err := s.get(ctx, index)
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err != nil {
+				if next != 0 {
+					index = next
+					continue
+				}
+
+				select {
+				case <-time.After(retry):
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+
+			// the index going backwards means it was reset, see
+			// https://developer.hashicorp.com/consul/api-docs/features/blocking
+			if next < index {
+				index = 0
+				continue
+			}
+
+			if next == index {
+				continue
+			}
+			index = next
+
+			select {
+			case ch <- rules:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// get returns the rules and the Consul index of the key, blocking until
+// the index is past index when it is not zero.
+func (s *ConsulStore) get(ctx context.Context, index uint64) ([]Rule, uint64, error) {
+	query := url.Values{"raw": {""}}
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", s.wait())
+	}
+
+	res, err := s.do(ctx, http.MethodGet, query, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+
+	next, _ := strconv.ParseUint(res.Header.Get("X-Consul-Index"), 10, 64)
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, next, nil
+	default:
+		return nil, 0, consulError(res)
+	}
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rules, err := decodeRules(b)
+	if err != nil {
+		return nil, next, fmt.Errorf("decoding %s: %w", s.Key, err)
+	}
+
+	return rules, next, nil
+}
+
+// do sends a request for the key.
+func (s *ConsulStore) do(ctx context.Context, method string, query url.Values, body io.Reader) (*http.Response, error) {
+	addr := s.Address
+	if addr == "" {
+		addr = "http://127.0.0.1:8500"
+	}
+
+	u := strings.TrimSuffix(addr, "/") + "/v1/kv/" + strings.TrimPrefix(s.Key, "/")
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Token != "" {
+		req.Header.Set("X-Consul-Token", s.Token)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return client.Do(req)
+}
+
+// wait returns the blocking query wait time.
+func (s *ConsulStore) wait() string {
+	if s.Wait == 0 {
+		return "5m"
+	}
+
+	return strconv.FormatInt(s.Wait.Milliseconds(), 10) + "ms"
+}
+
+// consulError returns the error of a failed Consul response.
+func consulError(res *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+	return fmt.Errorf("consul: %s: %s", res.Status, strings.TrimSpace(string(b)))
+}
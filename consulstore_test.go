@@ -0,0 +1,131 @@
+package redirects_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+// consul is a fake Consul KV API supporting blocking queries.
+type consul struct {
+	mu      sync.Mutex
+	changed *sync.Cond
+	values  map[string]string
+	index   uint64
+	tokens  []string
+}
+
+func newConsul() *consul {
+	c := &consul{values: map[string]string{}, index: 1}
+	c.changed = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *consul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tokens = append(c.tokens, r.Header.Get("X-Consul-Token"))
+	key := r.URL.Path[len("/v1/kv/"):]
+
+	if r.Method == http.MethodPut {
+		b, _ := io.ReadAll(r.Body)
+		c.values[key] = string(b)
+		c.index++
+		c.changed.Broadcast()
+		w.Write([]byte("true"))
+		return
+	}
+
+	if s := r.URL.Query().Get("index"); s != "" {
+		index, _ := strconv.ParseUint(s, 10, 64)
+		for c.index <= index {
+			c.changed.Wait()
+		}
+	}
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(c.index, 10))
+
+	v, ok := c.values[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Write([]byte(v))
+}
+
+func TestConsulStore(t *testing.T) {
+	c := newConsul()
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	store := &redirects.ConsulStore{
+		Address: server.URL,
+		Key:     "redirects/example.com",
+		Token:   "secret",
+		Retry:   time.Millisecond,
+	}
+
+	ctx := context.Background()
+
+	t.Run("missing", func(t *testing.T) {
+		rules, err := store.Load(ctx)
+		assert.NoError(t, err)
+		assert.Empty(t, rules)
+	})
+
+	t.Run("save and load", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`/home  /  301`))
+		assert.NoError(t, store.Save(ctx, rules))
+
+		loaded, err := store.Load(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, rules, loaded)
+		assert.Equal(t, "secret", c.tokens[0])
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		c.mu.Lock()
+		c.values["redirects/invalid"] = `[{"From":"home","To":"/"}]`
+		c.mu.Unlock()
+
+		_, err := (&redirects.ConsulStore{Address: server.URL, Key: "redirects/invalid"}).Load(ctx)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "rule 0")
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+
+		compiled := redirects.Compile(nil)
+		done := make(chan error)
+		go func() { done <- redirects.Sync(ctx, store, compiled) }()
+
+		assert.Eventually(t, func() bool { return len(compiled.Rules()) == 1 }, time.Second, time.Millisecond)
+
+		assert.NoError(t, store.Save(ctx, redirects.Must(redirects.ParseString(`
+			/docs  /guide
+			/news  /blog
+		`))))
+		assert.Eventually(t, func() bool { return len(compiled.Rules()) == 2 }, time.Second, time.Millisecond)
+
+		cancel()
+
+		// wake the pending blocking query
+		c.mu.Lock()
+		c.index++
+		c.changed.Broadcast()
+		c.mu.Unlock()
+
+		assert.Equal(t, context.Canceled, <-done)
+	})
+}
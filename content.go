@@ -0,0 +1,39 @@
+package redirects
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// A ShadowChecker reports whether a site has content at a path. As on
+// Netlify, content shadows the rules matching its path unless they are
+// forced, so a Handler with a ShadowChecker only applies forced rules to
+// such requests. Implementations may check a file system, an object store
+// or an IPFS directory.
+type ShadowChecker interface {
+	// Exists returns true if there is content at the request path.
+	Exists(path string) bool
+}
+
+// ShadowCheckerFunc adapts a function to a ShadowChecker.
+type ShadowCheckerFunc func(path string) bool
+
+// Exists implementation.
+func (f ShadowCheckerFunc) Exists(path string) bool {
+	return f(path)
+}
+
+// FSShadowChecker returns a ShadowChecker for the site file tree fsys, such
+// as os.DirFS of the publish directory. Content is found as served: a
+// directory by its index.html, and "/about" by "about.html".
+func FSShadowChecker(fsys fs.FS) ShadowChecker {
+	return ShadowCheckerFunc(func(p string) bool {
+		name := strings.TrimPrefix(path.Clean("/"+p), "/")
+		if name == "" {
+			name = "."
+		}
+
+		return fileExists(fsys, name)
+	})
+}
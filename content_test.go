@@ -0,0 +1,35 @@
+package redirects_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/tj/assert"
+
+	"github.com/fission-suite/go-redirects"
+)
+
+func TestFSShadowChecker(t *testing.T) {
+	c := redirects.FSShadowChecker(fstest.MapFS{
+		"index.html":      {},
+		"about.html":      {},
+		"blog/index.html": {},
+		"css/site.css":    {},
+		"empty/.keep":     {},
+	})
+
+	for path, exists := range map[string]bool{
+		"/":              true,
+		"/about":         true,
+		"/about.html":    true,
+		"/blog":          true,
+		"/blog/":         true,
+		"/css/site.css":  true,
+		"/css/other.css": false,
+		"/empty":         false,
+		"/../about.html": true,
+		"/missing/page":  false,
+	} {
+		assert.Equal(t, exists, c.Exists(path), path)
+	}
+}
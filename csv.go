@@ -0,0 +1,181 @@
+package redirects
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// csvHeader is the header row of CSV rule sets.
+var csvHeader = []string{"from", "to", "status", "force", "country", "language", "params", "method", "host", "conditions", "active_from", "active_until", "meta"}
+
+// ExportCSV writes the rules as CSV with a header row. Country and language
+// cells are comma separated lists, and params and meta are space separated
+// key=value pairs as written in a _redirects file.
+func ExportCSV(w io.Writer, rules []Rule) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, r := range rules {
+		err := cw.Write([]string{
+			r.From,
+			r.To,
+			strconv.Itoa(r.Status),
+			strconv.FormatBool(r.Force),
+			strings.Join(r.Country, ","),
+			strings.Join(r.Language, ","),
+			formatParams(r.Params),
+			strings.Join(r.Method, ","),
+			strings.Join(r.Host, ","),
+			formatConditions(r.Conditions),
+			formatTime(r.ActiveFrom),
+			formatTime(r.ActiveUntil),
+			formatMeta(r.Meta),
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// ImportCSV reads rules written by ExportCSV. The header row is required,
+// though columns may appear in any order and only from and to are mandatory.
+// Empty status and force cells default to 301 and false. Errors reference
+// the row number, counting the header as row 1.
+func ImportCSV(r io.Reader) (rules []Rule, err error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "reading header")
+	}
+
+	cols := make(map[string]int)
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{"from", "to"} {
+		if _, ok := cols[name]; !ok {
+			return nil, errors.Errorf("missing %q column", name)
+		}
+	}
+
+	for row := 2; ; row++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "row %d", row)
+		}
+
+		cell := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		rule := Rule{
+			From:   cell("from"),
+			To:     cell("to"),
+			Status: 301,
+		}
+
+		if s := cell("status"); s != "" {
+			code, force, err := parseStatus(s)
+			if err != nil {
+				return nil, errors.Wrapf(err, "row %d: invalid status %q", row, s)
+			}
+			rule.Status = code
+			rule.Force = force
+		}
+
+		if s := cell("force"); s != "" {
+			force, err := strconv.ParseBool(s)
+			if err != nil {
+				return nil, errors.Wrapf(err, "row %d: invalid force %q", row, s)
+			}
+			rule.Force = rule.Force || force
+		}
+
+		if s := cell("country"); s != "" {
+			rule.Country = parseList(s)
+		}
+
+		if s := cell("language"); s != "" {
+			rule.Language = parseList(s)
+		}
+
+		if s := cell("method"); s != "" {
+			rule.Method = parseList(s)
+		}
+
+		if s := cell("host"); s != "" {
+			rule.Host = parseList(s)
+		}
+
+		if s := cell("conditions"); s != "" {
+			rule.Conditions = parseConditions(strings.Fields(s))
+		}
+
+		if s := cell("active_from"); s != "" {
+			if rule.ActiveFrom, err = parseTime(s); err != nil {
+				return nil, errors.Wrapf(err, "row %d", row)
+			}
+		}
+
+		if s := cell("active_until"); s != "" {
+			if rule.ActiveUntil, err = parseTime(s); err != nil {
+				return nil, errors.Wrapf(err, "row %d", row)
+			}
+		}
+
+		if s := cell("params"); s != "" {
+			if rule.Params, err = parseParams(strings.Fields(s), make(Params)); err != nil {
+				return nil, errors.Wrapf(err, "row %d", row)
+			}
+		}
+
+		if s := cell("meta"); s != "" {
+			if rule.Meta, err = parseAnnotation(s, nil); err != nil {
+				return nil, errors.Wrapf(err, "row %d", row)
+			}
+		}
+
+		if errs := validateRule(rule, nil); len(errs) > 0 {
+			return nil, errors.Wrapf(errs[0], "row %d", row)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return
+}
+
+// formatTime returns t in RFC 3339, or an empty string when zero.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,79 @@
+package redirects_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestExportCSV(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		#@ owner=seo-team
+		/home   /  301
+		/store  id=:id tag=:tag  /item/:tag/:id  302!
+		/       /anz  302  Country=au,nz Language=en
+		/form   https://api.example.com/form  200  Method=POST,PUT
+		/       /canary  200  Header:X-Canary=true
+		/sale   /winter  302  From=2024-12-01T00:00Z Until=2025-01-01
+		/       /shop    200  Host=shop.example.com,*.shop.example.com
+	`))
+
+	var buf bytes.Buffer
+	assert.NoError(t, redirects.ExportCSV(&buf, rules))
+
+	assert.Equal(t, `from,to,status,force,country,language,params,method,host,conditions,active_from,active_until,meta
+/home,/,301,false,,,,,,,,,owner=seo-team
+/store,/item/:tag/:id,302,true,,,id=:id tag=:tag,,,,,,
+/,/anz,302,false,"au,nz",en,,,,,,,
+/form,https://api.example.com/form,200,false,,,,"POST,PUT",,,,,
+/,/canary,200,false,,,,,,Header:X-Canary=true,,,
+/sale,/winter,302,false,,,,,,,2024-12-01T00:00:00Z,2025-01-01T00:00:00Z,
+/,/shop,200,false,,,,,"shop.example.com,*.shop.example.com",,,,
+`, buf.String())
+
+	imported, err := redirects.ImportCSV(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, rules, imported)
+}
+
+func TestImportCSV(t *testing.T) {
+	t.Run("minimal columns", func(t *testing.T) {
+		rules, err := redirects.ImportCSV(strings.NewReader("To,From\n/new,/old\n/b,/a\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, []redirects.Rule{
+			{From: "/old", To: "/new", Status: 301},
+			{From: "/a", To: "/b", Status: 301},
+		}, rules)
+	})
+
+	t.Run("forced status", func(t *testing.T) {
+		rules, err := redirects.ImportCSV(strings.NewReader("from,to,status\n/a,/b,200!\n"))
+		assert.NoError(t, err)
+		assert.True(t, rules[0].Force)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		rules, err := redirects.ImportCSV(strings.NewReader(""))
+		assert.NoError(t, err)
+		assert.Empty(t, rules)
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		_, err := redirects.ImportCSV(strings.NewReader("from,status\n/a,301\n"))
+		assert.EqualError(t, err, `missing "to" column`)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		_, err := redirects.ImportCSV(strings.NewReader("from,to,status\n/a,/b,301\n/c,/d,abc\n"))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "row 3: invalid status")
+	})
+
+	t.Run("invalid rule", func(t *testing.T) {
+		_, err := redirects.ImportCSV(strings.NewReader("from,to,status\n/a,/b,301\n/c,/d,999\n"))
+		assert.EqualError(t, err, "row 3: unsupported status code 999")
+	})
+}
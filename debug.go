@@ -0,0 +1,98 @@
+package redirects
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A DebugHandler renders the compiled rules with their hit counts, when
+// they were loaded, the hash of their source and their estimated memory
+// size, as an HTML page or as
+// JSON when requested with "?format=json" or an Accept header of
+// application/json. Mount it at a path such as "/_redirects/debug".
+type DebugHandler struct {
+	// Rules are the rendered rules.
+	Rules *CompiledRules
+}
+
+// debugRule is a rule in the debug output.
+type debugRule struct {
+	Index int    `json:"index"`
+	Rule  string `json:"rule"`
+	Hits  uint64 `json:"hits"`
+}
+
+// debugInfo is the debug output.
+type debugInfo struct {
+	Loaded time.Time   `json:"loaded"`
+	Hash   string      `json:"hash"`
+	Size   int64       `json:"size"`
+	Rules  []debugRule `json:"rules"`
+}
+
+// debugSource is the debug HTML page template.
+const debugSource = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Redirects</title>
+<style>
+body { font-family: sans-serif; }
+td, th { padding: 2px 8px; text-align: left; }
+td.n { text-align: right; }
+code { white-space: pre; }
+</style>
+</head>
+<body>
+<h1>Redirects</h1>
+<p>{{len .Rules}} rules loaded {{.Loaded.Format "2006-01-02T15:04:05Z07:00"}}, sha256 <code>{{.Hash}}</code>, using an estimated {{.Size}} bytes</p>
+<table>
+<tr><th>#</th><th>Rule</th><th>Hits</th></tr>
+{{range .Rules}}<tr><td class="n">{{.Index}}</td><td><code>{{.Rule}}</code></td><td class="n">{{.Hits}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`
+
+// debugPage is the parsed debug page, parsed on first use rather than when
+// the package is initialized.
+var debugPage struct {
+	once sync.Once
+	tmpl *template.Template
+}
+
+// ServeHTTP implementation.
+func (h *DebugHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s := h.Rules.Snapshot()
+
+	info := debugInfo{
+		Loaded: s.Loaded,
+		Hash:   s.Hash,
+		Size:   h.Rules.Size(),
+		Rules:  make([]debugRule, len(s.Rules)),
+	}
+
+	for i, rule := range s.Rules {
+		info.Rules[i] = debugRule{Index: i, Rule: rule.String(), Hits: s.Hits[i]}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(info)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	debugPage.once.Do(func() {
+		debugPage.tmpl = template.Must(template.New("debug").Parse(debugSource))
+	})
+	debugPage.tmpl.Execute(w, info)
+}
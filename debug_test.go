@@ -0,0 +1,64 @@
+package redirects_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestDebugHandler(t *testing.T) {
+	compiled := redirects.Compile(redirects.Must(redirects.ParseString(`
+		/home      /
+		/news/*    /blog/:splat  302
+	`)), redirects.WithCacheSize(10))
+
+	for _, path := range []string{"/home", "/news/a", "/news/a", "/about"} {
+		compiled.Match(redirects.Request{Path: path})
+	}
+
+	h := &redirects.DebugHandler{Rules: compiled}
+
+	t.Run("json", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/_redirects/debug?format=json", nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var info struct {
+			Hash  string
+			Size  int64
+			Rules []struct {
+				Index int
+				Rule  string
+				Hits  uint64
+			}
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+		assert.Len(t, info.Hash, 64)
+		assert.Equal(t, compiled.Size(), info.Size)
+		assert.Len(t, info.Rules, 2)
+		assert.Equal(t, "/home / 301", info.Rules[0].Rule)
+		assert.Equal(t, uint64(1), info.Rules[0].Hits)
+		assert.Equal(t, uint64(2), info.Rules[1].Hits)
+	})
+
+	t.Run("html", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/_redirects/debug", nil))
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "<code>/news/* /blog/:splat 302</code>")
+		assert.Contains(t, w.Body.String(), compiled.Snapshot().Hash)
+	})
+
+	t.Run("reload resets hits", func(t *testing.T) {
+		before := compiled.Snapshot().Hash
+		compiled.Reload(redirects.Must(redirects.ParseString(`/home  /start`)))
+
+		s := compiled.Snapshot()
+		assert.Equal(t, []uint64{0}, s.Hits)
+		assert.NotEqual(t, before, s.Hash)
+	})
+}
@@ -0,0 +1,207 @@
+package redirects
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CheckOptions configures CheckDestinations.
+type CheckOptions struct {
+	// BaseURL, when set, is the site internal destinations such as "/blog"
+	// are checked against, such as "https://example.com". Only absolute
+	// destinations are checked otherwise.
+	BaseURL string
+
+	// Client sends the requests, without following redirects. Defaults to
+	// http.DefaultClient.
+	Client *http.Client
+
+	// Concurrency is the number of destinations checked at once. Defaults
+	// to 8.
+	Concurrency int
+
+	// Timeout is the time allowed for each request. Defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+// A DeadDestination reports a rule whose destination could not be reached
+// or responded with an error status.
+type DeadDestination struct {
+	// Index is the position of the rule in the checked slice.
+	Index int
+
+	// Rule is the flagged rule.
+	Rule Rule
+
+	// URL is the checked URL.
+	URL string
+
+	// Status is the status code of the response, or zero when the
+	// destination could not be reached.
+	Status int
+
+	// Reason describes the problem.
+	Reason string
+}
+
+// String returns a description of the finding.
+func (d DeadDestination) String() string {
+	return fmt.Sprintf("rule %d (%s): %s", d.Index, d.Rule.From, d.Reason)
+}
+
+// CheckDestinations sends a HEAD request to the destination of each rule,
+// falling back to GET for servers not supporting HEAD, and returns the
+// rules whose destination could not be reached or responded with a status
+// other than 2xx or 3xx, ordered by index. Destinations with placeholders
+// cannot be checked and are skipped, as are internal destinations unless
+// opts.BaseURL is set. Each URL is requested once however many rules lead
+// to it. The error is that of ctx when it ends before every check is done.
+func CheckDestinations(ctx context.Context, rules []Rule, opts CheckOptions) ([]DeadDestination, error) {
+	client := http.DefaultClient
+	if opts.Client != nil {
+		client = opts.Client
+	}
+
+	c := *client
+	c.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 8
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	byURL := make(map[string][]int)
+	var urls []string
+
+	for i, r := range rules {
+		u, ok := destinationURL(r, opts.BaseURL)
+		if !ok {
+			continue
+		}
+
+		if _, seen := byURL[u]; !seen {
+			urls = append(urls, u)
+		}
+		byURL[u] = append(byURL[u], i)
+	}
+
+	var mu sync.Mutex
+	var found []DeadDestination
+
+	queue := make(chan string)
+	var wg sync.WaitGroup
+
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range queue {
+				status, reason := checkURL(ctx, &c, u, opts.Timeout)
+				if reason == "" || ctx.Err() != nil {
+					continue
+				}
+
+				mu.Lock()
+				for _, i := range byURL[u] {
+					found = append(found, DeadDestination{
+						Index:  i,
+						Rule:   rules[i],
+						URL:    u,
+						Status: status,
+						Reason: reason,
+					})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, u := range urls {
+		select {
+		case queue <- u:
+		case <-ctx.Done():
+		}
+	}
+
+	close(queue)
+	wg.Wait()
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Index < found[j].Index
+	})
+
+	return found, ctx.Err()
+}
+
+// destinationURL returns the URL to check for the destination of r.
+func destinationURL(r Rule, base string) (string, bool) {
+	if isExclusion(r.From) || placeholder.MatchString(r.To) || strings.Contains(r.To, "$") {
+		return "", false
+	}
+
+	u, err := url.Parse(r.To)
+	if err != nil {
+		return "", false
+	}
+
+	switch {
+	case u.Scheme == "http" || u.Scheme == "https":
+		return u.String(), true
+	case u.Scheme == "" && u.Host == "" && strings.HasPrefix(u.Path, "/") && base != "":
+		b, err := url.Parse(base)
+		if err != nil {
+			return "", false
+		}
+		return b.ResolveReference(u).String(), true
+	default:
+		return "", false
+	}
+}
+
+// checkURL requests u, returning the status and the reason it is dead, or
+// an empty reason when it is live.
+func checkURL(ctx context.Context, c *http.Client, u string, timeout time.Duration) (int, string) {
+	status, err := request(ctx, c, http.MethodHead, u, timeout)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = request(ctx, c, http.MethodGet, u, timeout)
+	}
+
+	switch {
+	case err != nil:
+		return 0, fmt.Sprintf("%s unreachable: %s", u, err)
+	case status >= 400:
+		return status, fmt.Sprintf("%s responded %d %s", u, status, http.StatusText(status))
+	default:
+		return status, ""
+	}
+}
+
+// request sends a request without a body, returning the response status.
+func request(ctx context.Context, c *http.Client, method, u string, timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+
+	return res.StatusCode, nil
+}
@@ -0,0 +1,78 @@
+package redirects_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestCheckDestinations(t *testing.T) {
+	var hits int32
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		switch r.URL.Path {
+		case "/live":
+		case "/moved":
+			http.Redirect(w, r, "/gone", http.StatusMovedPermanently)
+		case "/get-only":
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer site.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	rules := redirects.Must(redirects.ParseString(fmt.Sprintf(`
+		/a  %[1]s/live
+		/b  %[1]s/moved
+		/c  %[1]s/get-only
+		/d  %[1]s/missing
+		/e  %[1]s/missing
+		/f  %[1]s/broken  302
+		/g  %[2]s/api  200
+		/h/*  %[1]s/:splat
+		/i  /missing
+	`, site.URL, closed.URL)))
+
+	t.Run("absolute", func(t *testing.T) {
+		atomic.StoreInt32(&hits, 0)
+		found, err := redirects.CheckDestinations(context.Background(), rules, redirects.CheckOptions{})
+		assert.NoError(t, err)
+
+		var got []string
+		for _, d := range found {
+			got = append(got, fmt.Sprintf("%d %d", d.Index, d.Status))
+		}
+		assert.Equal(t, []string{"3 404", "4 404", "5 500", "6 0"}, got)
+		assert.Equal(t, fmt.Sprintf("rule 3 (/d): %s/missing responded 404 Not Found", site.URL), found[0].String())
+		assert.Contains(t, found[3].Reason, "unreachable")
+		assert.Equal(t, int32(6), atomic.LoadInt32(&hits))
+	})
+
+	t.Run("internal", func(t *testing.T) {
+		found, err := redirects.CheckDestinations(context.Background(), rules[8:], redirects.CheckOptions{BaseURL: site.URL})
+		assert.NoError(t, err)
+		assert.Len(t, found, 1)
+		assert.Equal(t, site.URL+"/missing", found[0].URL)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := redirects.CheckDestinations(ctx, rules, redirects.CheckOptions{})
+		assert.Equal(t, context.Canceled, err)
+	})
+}
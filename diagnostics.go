@@ -0,0 +1,202 @@
+package redirects
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Severity is how serious a Diagnostic is.
+type Severity int
+
+// Severities.
+const (
+	// SeverityError is a problem which fails parsing.
+	SeverityError Severity = iota + 1
+
+	// SeverityWarning is a likely mistake which does not fail parsing.
+	SeverityWarning
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+
+	return "error"
+}
+
+// MarshalText implementation.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// A Position is a location in a file. Lines and columns start at 1, and
+// columns count bytes.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// A Range is a span of a file, from Start up to but excluding End.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// A Diagnostic is a problem in a _redirects file, for editors and code
+// scanning tools.
+type Diagnostic struct {
+	// Severity is how serious the problem is.
+	Severity Severity `json:"severity"`
+
+	// Range is the offending token, or the rule when no single token is.
+	Range Range `json:"range"`
+
+	// Code identifies the kind of problem.
+	Code Code `json:"code"`
+
+	// Message describes the problem.
+	Message string `json:"message"`
+}
+
+// quoted matches the quoted tokens of error messages.
+var quoted = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+// Diagnostics returns every problem in the given reader in order, rather
+// than the first as Parse does, along with warnings for rules which never
+// apply because an earlier rule shadows them, for likely mistakes in source
+// placeholders and for conditions skipped under
+// WithUnknownOptionPolicy(UnknownOptionWarn).
+func Diagnostics(r io.Reader, opts ...Option) (diags []Diagnostic) {
+	c := newConfig(opts)
+	s := bufio.NewScanner(r)
+
+	var rules []Rule
+	var lines []int
+	var raws []string
+	sections := make(map[string]bool)
+
+	for n := 1; s.Scan(); n++ {
+		raw := s.Text()
+		line := strings.TrimSpace(raw)
+
+		report := func(severity Severity, err error) {
+			diags = append(diags, Diagnostic{
+				Severity: severity,
+				Range:    errorRange(n, raw, err),
+				Code:     ErrorCode(err),
+				Message:  err.Error(),
+			})
+		}
+
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#@"):
+			if _, err := parseAnnotation(line[2:], nil); err != nil {
+				report(SeverityError, err)
+			}
+			continue
+		case strings.HasPrefix(line, "#"):
+			if name, ok := parseSection(line); ok {
+				if sections[name] {
+					report(SeverityError, fmt.Errorf("%w %q", ErrDuplicateSection, name))
+				}
+				sections[name] = true
+			}
+			continue
+		}
+
+		rule, skipped, err := parseLine(line, c, nil)
+		if c.unknownOptions == UnknownOptionWarn {
+			for _, w := range skipped {
+				report(SeverityWarning, w)
+			}
+		}
+
+		if err != nil {
+			report(SeverityError, err)
+			continue
+		}
+
+		if rule, err = c.normalizePlaceholders(rule); err != nil {
+			report(SeverityError, err)
+			continue
+		}
+
+		errs := c.checkRule(rule)
+		for _, err := range errs {
+			report(SeverityError, err)
+		}
+
+		if len(errs) == 0 {
+			for _, w := range placeholderWarnings(rule) {
+				report(SeverityWarning, w)
+			}
+
+			rules = append(rules, rule)
+			lines = append(lines, n)
+			raws = append(raws, raw)
+		}
+	}
+
+	for _, s := range Shadows(rules) {
+		diags = insertDiagnostic(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Range:    lineRange(lines[s.Shadowed], raws[s.Shadowed], ""),
+			Code:     CodeShadowed,
+			Message:  fmt.Sprintf("rule never applies as the rule on line %d matches every request it would", lines[s.Rule]),
+		})
+	}
+
+	return
+}
+
+// insertDiagnostic inserts d before the first diagnostic on a later line.
+func insertDiagnostic(diags []Diagnostic, d Diagnostic) []Diagnostic {
+	i := len(diags)
+	for i > 0 && diags[i-1].Range.Start.Line > d.Range.Start.Line {
+		i--
+	}
+
+	diags = append(diags, Diagnostic{})
+	copy(diags[i+1:], diags[i:])
+	diags[i] = d
+	return diags
+}
+
+// errorRange returns the range of the last token of line n quoted by err,
+// or of the whole rule when none is.
+func errorRange(n int, raw string, err error) Range {
+	matches := quoted.FindAllString(err.Error(), -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		tok, uerr := strconv.Unquote(matches[i])
+		if uerr == nil && tok != "" && strings.Contains(raw, tok) {
+			return lineRange(n, raw, tok)
+		}
+	}
+
+	return lineRange(n, raw, "")
+}
+
+// lineRange returns the range of tok within line n, or of the line without
+// surrounding whitespace when tok is empty.
+func lineRange(n int, raw, tok string) Range {
+	start := len(raw) - len(strings.TrimLeft(raw, " \t"))
+	end := len(strings.TrimRight(raw, " \t"))
+
+	if tok != "" {
+		start = strings.Index(raw, tok)
+		end = start + len(tok)
+	}
+
+	return Range{
+		Start: Position{Line: n, Column: start + 1},
+		End:   Position{Line: n, Column: end + 1},
+	}
+}
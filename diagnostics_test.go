@@ -0,0 +1,111 @@
+package redirects_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestDiagnostics(t *testing.T) {
+	t.Run("problems", func(t *testing.T) {
+		diags := redirects.Diagnostics(strings.NewReader(strings.Join([]string{
+			"/home  /",
+			"  /a  /b  3!01",
+			"#@ owner",
+			"/blog/*  /posts/:splat",
+			"/blog/news  /news",
+			"/c  /:id  302  Country=aus",
+			"## [section: a]",
+			"## [section: a]",
+		}, "\n")))
+
+		assert.Equal(t, []redirects.Diagnostic{
+			{
+				Severity: redirects.SeverityError,
+				Range:    redirects.Range{Start: redirects.Position{Line: 2, Column: 11}, End: redirects.Position{Line: 2, Column: 15}},
+				Code:     redirects.CodeInvalidStatus,
+				Message:  `invalid status code "3!01"`,
+			},
+			{
+				Severity: redirects.SeverityError,
+				Range:    redirects.Range{Start: redirects.Position{Line: 3, Column: 4}, End: redirects.Position{Line: 3, Column: 9}},
+				Code:     redirects.CodeInvalidAnnotation,
+				Message:  `invalid annotation "owner"`,
+			},
+			{
+				Severity: redirects.SeverityWarning,
+				Range:    redirects.Range{Start: redirects.Position{Line: 5, Column: 1}, End: redirects.Position{Line: 5, Column: 18}},
+				Code:     redirects.CodeShadowed,
+				Message:  "rule never applies as the rule on line 4 matches every request it would",
+			},
+			{
+				Severity: redirects.SeverityError,
+				Range:    redirects.Range{Start: redirects.Position{Line: 6, Column: 1}, End: redirects.Position{Line: 6, Column: 27}},
+				Code:     redirects.CodeUnboundPlaceholder,
+				Message:  "destination placeholder :id is not bound by the source path or params",
+			},
+			{
+				Severity: redirects.SeverityError,
+				Range:    redirects.Range{Start: redirects.Position{Line: 6, Column: 24}, End: redirects.Position{Line: 6, Column: 27}},
+				Code:     redirects.CodeInvalidCountry,
+				Message:  `invalid country code "aus"`,
+			},
+			{
+				Severity: redirects.SeverityError,
+				Range:    redirects.Range{Start: redirects.Position{Line: 8, Column: 14}, End: redirects.Position{Line: 8, Column: 15}},
+				Code:     redirects.CodeDuplicateSection,
+				Message:  `duplicate section "a"`,
+			},
+		}, diags)
+	})
+
+	t.Run("placeholders", func(t *testing.T) {
+		diags := redirects.Diagnostics(strings.NewReader(strings.Join([]string{
+			"/users/:splat  /u/:splat",
+			"/a/:id/b/:id  /x/:id",
+			"/blog/:year/:slug  /posts/:slug",
+			"/docs/:page  /guide/:page",
+		}, "\n")))
+
+		assert.Equal(t, []redirects.Diagnostic{
+			{
+				Severity: redirects.SeverityWarning,
+				Range:    redirects.Range{Start: redirects.Position{Line: 1, Column: 8}, End: redirects.Position{Line: 1, Column: 14}},
+				Code:     redirects.CodeReservedPlaceholder,
+				Message:  `placeholder ":splat" is reserved for wildcards`,
+			},
+			{
+				Severity: redirects.SeverityWarning,
+				Range:    redirects.Range{Start: redirects.Position{Line: 2, Column: 4}, End: redirects.Position{Line: 2, Column: 7}},
+				Code:     redirects.CodeDuplicatePlaceholder,
+				Message:  `placeholder ":id" is used more than once`,
+			},
+			{
+				Severity: redirects.SeverityWarning,
+				Range:    redirects.Range{Start: redirects.Position{Line: 3, Column: 7}, End: redirects.Position{Line: 3, Column: 12}},
+				Code:     redirects.CodeUnusedPlaceholder,
+				Message:  `placeholder ":year" is not used by the destination`,
+			},
+		}, diags)
+	})
+
+	t.Run("unknown conditions", func(t *testing.T) {
+		diags := redirects.Diagnostics(strings.NewReader("/a /b 301 Role=admin"), redirects.WithUnknownOptionPolicy(redirects.UnknownOptionWarn))
+		assert.Len(t, diags, 1)
+		assert.Equal(t, redirects.SeverityWarning, diags[0].Severity)
+		assert.Equal(t, redirects.CodeUnknownCondition, diags[0].Code)
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		assert.Empty(t, redirects.Diagnostics(strings.NewReader("/home  /\n")))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		b, err := json.Marshal(redirects.Diagnostics(strings.NewReader("/a")))
+		assert.NoError(t, err)
+		assert.Equal(t, `[{"severity":"error","range":{"start":{"line":1,"column":1},"end":{"line":1,"column":3}},"code":"RED001","message":"missing destination path"}]`, string(b))
+	})
+}
@@ -0,0 +1,190 @@
+package redirects
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// sectionComment matches a section comment such as "## [section: blog]".
+var sectionComment = regexp.MustCompile(`^#+\s*\[section:\s*([^\]]*?)\s*\]\s*$`)
+
+// A Document is a _redirects file divided into named sections by comments
+// such as "## [section: blog-migration]", so tooling may report on rules by
+// section and insert rules into the right block.
+type Document struct {
+	// Rules are the rules of all sections in order.
+	Rules []Rule
+
+	// Sections are the named sections in order. Rules before the first
+	// section belong to none.
+	Sections []Section
+}
+
+// A Section is a named block of rules in a Document.
+type Section struct {
+	// Name is the name given by the section comment.
+	Name string
+
+	// Line is the line number of the section comment, or zero for sections
+	// added by Insert.
+	Line int
+
+	// Start and End are the indices of the first rule in the section and
+	// the one after its last rule in Document.Rules.
+	Start, End int
+}
+
+// Len returns the number of rules in the section.
+func (s Section) Len() int {
+	return s.End - s.Start
+}
+
+// ParseDocument parses the given reader as Parse does, recording the
+// sections it is divided into. A section continues until the next section
+// comment, and names must be unique.
+func ParseDocument(r io.Reader, opts ...Option) (*Document, error) {
+	d := &Document{}
+
+	c := newConfig(opts)
+	c.section = func(name string, line, index int) error {
+		if _, ok := d.Section(name); ok {
+			return fmt.Errorf("%w %q", ErrDuplicateSection, name)
+		}
+
+		if n := len(d.Sections); n > 0 {
+			d.Sections[n-1].End = index
+		}
+
+		d.Sections = append(d.Sections, Section{Name: name, Line: line, Start: index})
+		return nil
+	}
+
+	rules, err := parse(r, c, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if n := len(d.Sections); n > 0 {
+		d.Sections[n-1].End = len(rules)
+	}
+
+	d.Rules = rules
+	return d, nil
+}
+
+// Section returns the section with the given name.
+func (d *Document) Section(name string) (Section, bool) {
+	for _, s := range d.Sections {
+		if s.Name == name {
+			return s, true
+		}
+	}
+
+	return Section{}, false
+}
+
+// SectionRules returns the rules of the section with the given name.
+func (d *Document) SectionRules(name string) []Rule {
+	s, ok := d.Section(name)
+	if !ok {
+		return nil
+	}
+
+	return d.Rules[s.Start:s.End]
+}
+
+// Insert adds rule at the end of the section with the given name, adding
+// the section at the end of the document when missing.
+func (d *Document) Insert(name string, rule Rule) {
+	i := len(d.Sections)
+	for j, s := range d.Sections {
+		if s.Name == name {
+			i = j
+			break
+		}
+	}
+
+	if i == len(d.Sections) {
+		n := len(d.Rules)
+		d.Sections = append(d.Sections, Section{Name: name, Start: n, End: n})
+	}
+
+	at := d.Sections[i].End
+	d.Rules = append(d.Rules, Rule{})
+	copy(d.Rules[at+1:], d.Rules[at:])
+	d.Rules[at] = rule
+
+	d.Sections[i].End++
+	for j := i + 1; j < len(d.Sections); j++ {
+		d.Sections[j].Start++
+		d.Sections[j].End++
+	}
+}
+
+// Stats returns the statistics of the rules of each section by name, with
+// rules before the first section under the empty name when there are any.
+func (d *Document) Stats() map[string]RuleStats {
+	stats := make(map[string]RuleStats, len(d.Sections)+1)
+
+	start := len(d.Rules)
+	if len(d.Sections) > 0 {
+		start = d.Sections[0].Start
+	}
+
+	if start > 0 {
+		stats[""] = Stats(d.Rules[:start])
+	}
+
+	for _, s := range d.Sections {
+		stats[s.Name] = Stats(d.Rules[s.Start:s.End])
+	}
+
+	return stats
+}
+
+// WriteTo writes the document in the _redirects file format, with a
+// comment starting each section and annotations above rules with metadata.
+// Other comments are not preserved.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	return d.Format(w, FormatOptions{})
+}
+
+// Format writes the document as WriteTo does, with the rules of each
+// section laid out as configured by opts, see MarshalWith.
+func (d *Document) Format(w io.Writer, opts FormatOptions) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+
+	write := func(b []byte) {
+		m, _ := bw.Write(b)
+		n += int64(m)
+	}
+
+	i := 0
+	for _, s := range d.Sections {
+		write(MarshalWith(d.Rules[i:s.Start], opts))
+		i = s.Start
+
+		if n > 0 {
+			write([]byte("\n"))
+		}
+
+		write([]byte("## [section: " + s.Name + "]\n"))
+	}
+
+	write(MarshalWith(d.Rules[i:], opts))
+
+	return n, bw.Flush()
+}
+
+// parseSection returns the name of a section comment.
+func parseSection(line string) (string, bool) {
+	m := sectionComment.FindStringSubmatch(line)
+	if m == nil || m[1] == "" {
+		return "", false
+	}
+
+	return m[1], true
+}
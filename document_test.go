@@ -0,0 +1,95 @@
+package redirects_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+const document = `
+/home  /
+
+## [section: blog-migration]
+/blog/*  /posts/:splat
+/news    /posts  302
+
+# legacy pages
+## [section: legacy]
+#@ owner=seo-team
+/old/*  /new/:splat  301!
+`
+
+func TestParseDocument(t *testing.T) {
+	t.Run("sections", func(t *testing.T) {
+		d, err := redirects.ParseDocument(strings.NewReader(document))
+		assert.NoError(t, err)
+		assert.Len(t, d.Rules, 4)
+		assert.Equal(t, []redirects.Section{
+			{Name: "blog-migration", Line: 4, Start: 1, End: 3},
+			{Name: "legacy", Line: 9, Start: 3, End: 4},
+		}, d.Sections)
+
+		rules := d.SectionRules("blog-migration")
+		assert.Len(t, rules, 2)
+		assert.Equal(t, "/news", rules[1].From)
+		assert.Nil(t, d.SectionRules("missing"))
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		_, err := redirects.ParseDocument(strings.NewReader("## [section: a]\n/a /b\n## [section: a]\n"))
+		assert.True(t, errors.Is(err, redirects.ErrDuplicateSection))
+		assert.EqualError(t, err, `line 3: duplicate section "a": "## [section: a]"`)
+	})
+
+	t.Run("plain comments", func(t *testing.T) {
+		rules, err := redirects.ParseString(document)
+		assert.NoError(t, err)
+		assert.Len(t, rules, 4)
+	})
+}
+
+func TestDocument_Insert(t *testing.T) {
+	d, err := redirects.ParseDocument(strings.NewReader(document))
+	assert.NoError(t, err)
+
+	d.Insert("blog-migration", redirects.Rule{From: "/feed", To: "/rss.xml", Status: 301})
+	d.Insert("campaigns", redirects.Rule{From: "/sale", To: "/winter", Status: 302})
+
+	var b strings.Builder
+	_, err = d.WriteTo(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, `/home /
+
+## [section: blog-migration]
+/blog/* /posts/:splat
+/news /posts 302
+/feed /rss.xml 301
+
+## [section: legacy]
+#@ owner=seo-team
+/old/* /new/:splat 301!
+
+## [section: campaigns]
+/sale /winter 302
+`, b.String())
+
+	again, err := redirects.ParseDocument(strings.NewReader(b.String()))
+	assert.NoError(t, err)
+	assert.Equal(t, d.Rules, again.Rules)
+	assert.Len(t, again.Sections, 3)
+}
+
+func TestDocument_Stats(t *testing.T) {
+	d, err := redirects.ParseDocument(strings.NewReader(document))
+	assert.NoError(t, err)
+
+	stats := d.Stats()
+	assert.Len(t, stats, 3)
+	assert.Equal(t, 1, stats[""].Rules)
+	assert.Equal(t, 2, stats["blog-migration"].Rules)
+	assert.Equal(t, 1, stats["blog-migration"].Wildcards)
+	assert.Equal(t, 1, stats["legacy"].Forced)
+}
@@ -0,0 +1,70 @@
+package redirects
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// dotEscaper escapes DOT quoted strings.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// WriteDOT writes the rules as a Graphviz DOT directed graph, with an edge
+// from the source to the destination of each rule. Edges are labelled
+// with the status and colored by its kind: green for rewrites, blue for
+// permanent redirects, orange for temporary redirects and red for errors.
+// Proxy edges are dashed.
+func WriteDOT(w io.Writer, rules []Rule) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "digraph redirects {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, "  node [shape=box];")
+
+	seen := make(map[string]bool)
+	for _, r := range rules {
+		for _, n := range []string{r.From, r.To} {
+			if !seen[n] {
+				seen[n] = true
+				fmt.Fprintf(bw, "  \"%s\";\n", dotEscaper.Replace(n))
+			}
+		}
+	}
+
+	for _, r := range rules {
+		label := fmt.Sprint(r.Status)
+		if r.Force {
+			label += "!"
+		}
+
+		style := "solid"
+		if r.IsRewrite() && r.IsProxy() {
+			style = "dashed"
+		}
+
+		fmt.Fprintf(bw, "  \"%s\" -> \"%s\" [label=\"%s\", color=%s, style=%s];\n",
+			dotEscaper.Replace(r.From),
+			dotEscaper.Replace(r.To),
+			label,
+			statusColor(r.Status),
+			style)
+	}
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// statusColor returns the edge color for a status code.
+func statusColor(status int) string {
+	switch {
+	case status == 200:
+		return "green"
+	case status == 301 || status == 308:
+		return "blue"
+	case status >= 300 && status < 400:
+		return "orange"
+	default:
+		return "red"
+	}
+}
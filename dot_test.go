@@ -0,0 +1,46 @@
+package redirects_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestWriteDOT(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/home    /
+		/old     /home     302
+		/app/*   /index.html  200!
+		/api/*   https://api.example.com/:splat  200
+		/closed  /404.html    404
+		/google  https://www.google.com  301
+	`))
+
+	var buf bytes.Buffer
+	assert.NoError(t, redirects.WriteDOT(&buf, rules))
+
+	assert.Equal(t, `digraph redirects {
+  rankdir=LR;
+  node [shape=box];
+  "/home";
+  "/";
+  "/old";
+  "/app/*";
+  "/index.html";
+  "/api/*";
+  "https://api.example.com/:splat";
+  "/closed";
+  "/404.html";
+  "/google";
+  "https://www.google.com";
+  "/home" -> "/" [label="301", color=blue, style=solid];
+  "/old" -> "/home" [label="302", color=orange, style=solid];
+  "/app/*" -> "/index.html" [label="200!", color=green, style=solid];
+  "/api/*" -> "https://api.example.com/:splat" [label="200", color=green, style=dashed];
+  "/closed" -> "/404.html" [label="404", color=red, style=solid];
+  "/google" -> "https://www.google.com" [label="301", color=blue, style=solid];
+}
+`, buf.String())
+}
@@ -0,0 +1,103 @@
+package redirects
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// format is the rule format, for error messages.
+const format = "from [a=:save1 b=value] to [code][!] [Country=x,y] [Language=x,y] [Method=x,y] [Host=x,y] [Header:name=value] [From=time] [Until=time]"
+
+// Errors for malformed rules, which may be tested for with errors.Is.
+var (
+	// ErrMissingDestination is returned for a rule without a destination,
+	// such as "/from", "/from a=b" or "/from 301".
+	ErrMissingDestination = errors.New("missing destination path")
+
+	// ErrInvalidParam is returned for a param without a key, such as "=b".
+	ErrInvalidParam = errors.New("invalid param")
+
+	// ErrInvalidStatus is returned for a malformed status code, such as "3!01".
+	ErrInvalidStatus = errors.New("invalid status code")
+
+	// ErrDetachedForce is returned for a force flag which is not attached
+	// to a status code, such as "/a /b 301 !" or "/a /b!".
+	ErrDetachedForce = errors.New("force flag must be attached to a status code, such as 301!, or follow the destination")
+
+	// ErrUnknownCondition is returned for an unsupported condition, such as "Role=admin".
+	ErrUnknownCondition = errors.New("unknown condition")
+
+	// ErrUnexpectedToken is returned for a token after the status code
+	// which is not a key=value condition.
+	ErrUnexpectedToken = errors.New("unexpected token")
+
+	// ErrDuplicateSection is returned by ParseDocument for a section name
+	// used more than once.
+	ErrDuplicateSection = errors.New("duplicate section")
+
+	// ErrInvalidAnnotation is returned for an annotation comment field which
+	// is not a key=value pair, such as "#@ owner".
+	ErrInvalidAnnotation = errors.New("invalid annotation")
+
+	// ErrTooManyRules is returned for a file with more rules than allowed
+	// by WithMaxRules.
+	ErrTooManyRules = errors.New("too many rules")
+
+	// ErrLineTooLong is returned for a line longer than allowed by
+	// WithMaxLineLength.
+	ErrLineTooLong = errors.New("line too long")
+
+	// ErrUndefinedVariable is returned for a reference such as ${env:NAME}
+	// to a variable which WithEnv's lookup does not define.
+	ErrUndefinedVariable = errors.New("undefined variable")
+
+	// ErrFileTooLarge is returned for a compressed file larger than allowed
+	// by WithDecompression once decompressed.
+	ErrFileTooLarge = errors.New("file too large")
+)
+
+// Errors for redirect chains followed by Resolve.
+var (
+	// ErrRedirectLoop is returned when a chain redirects to a path it
+	// has already visited.
+	ErrRedirectLoop = errors.New("redirect loop")
+
+	// ErrTooManyHops is returned when a chain is longer than the hop limit.
+	ErrTooManyHops = errors.New("too many redirects")
+)
+
+// ErrQuotaExceeded is returned by Registry.Load for rules exceeding the
+// quota of their site.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// ErrUnsupported is reported by importers for a construct without an
+// equivalent rule, such as a complex regular expression.
+var ErrUnsupported = errors.New("unsupported")
+
+// A ParseError describes a malformed or invalid line.
+type ParseError struct {
+	// Line is the line number, starting at 1.
+	Line int
+
+	// Text is the line.
+	Text string
+
+	// Err is the underlying problem.
+	Err error
+}
+
+// Error implementation.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s: %q", e.Line, e.Err, e.Text)
+}
+
+// Unwrap returns the underlying problem.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Code returns the code of the underlying problem.
+func (e *ParseError) Code() Code {
+	return ErrorCode(e.Err)
+}
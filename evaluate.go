@@ -0,0 +1,46 @@
+package redirects
+
+import (
+	"runtime"
+	"sync"
+)
+
+// EvaluateAll returns the result of matching each of paths, which may
+// include query strings, against rules. Results are in the order of paths,
+// with a nil Rule for paths matching no rule. Paths are matched
+// concurrently, making it suitable for checking large crawls.
+func EvaluateAll(rules []Rule, paths []string) []Result {
+	results := make([]Result, len(paths))
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+
+	indexes := make(chan int, workers)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				req, err := pathRequest(paths[i])
+				if err != nil {
+					continue
+				}
+
+				results[i], _ = Match(rules, req)
+			}
+		}()
+	}
+
+	for i := range paths {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,59 @@
+package redirects_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestEvaluateAll(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/old/:id    /items/:id
+		/search q=:q  /find?query=:q  302
+		/app/*      /index.html  200
+	`))
+
+	results := redirects.EvaluateAll(rules, []string{
+		"/old/5",
+		"/about",
+		"/search?q=shoes",
+		"/app/settings",
+		"%zz",
+	})
+
+	assert.Len(t, results, 5)
+
+	assert.Equal(t, "/items/5", results[0].To)
+	assert.Equal(t, 301, results[0].Rule.Status)
+	assert.Equal(t, 0, results[0].Index)
+
+	assert.Nil(t, results[1].Rule)
+
+	assert.Equal(t, "/find?query=shoes", results[2].To)
+	assert.Equal(t, 302, results[2].Rule.Status)
+
+	assert.Equal(t, "/index.html", results[3].To)
+	assert.Equal(t, 2, results[3].Index)
+
+	assert.Nil(t, results[4].Rule)
+}
+
+func BenchmarkEvaluateAll(b *testing.B) {
+	rules := redirects.Must(redirects.ParseString(`
+		/old/:id    /items/:id
+		/blog/*     /posts/:splat
+		/app/*      /index.html  200
+	`))
+
+	paths := make([]string, 10000)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("/old/%d", i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		redirects.EvaluateAll(rules, paths)
+	}
+}
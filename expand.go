@@ -0,0 +1,219 @@
+package redirects
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// group matches a reference to a regular expression group, such as $1 or ${name}.
+var group = regexp.MustCompile(`\$(\d+|\{[A-Za-z_][A-Za-z0-9_]*\})`)
+
+// reference matches a placeholder or a regular expression group reference.
+var reference = regexp.MustCompile(group.String() + `|` + placeholder.String())
+
+// hostLabels matches a capture which is safe to substitute into a host.
+var hostLabels = regexp.MustCompile(`^[A-Za-z0-9.-]+$`)
+
+// component is a part of a destination URL, determining how captures
+// substituted into it are escaped.
+type component int
+
+// Destination components.
+const (
+	hostComponent component = iota
+	pathComponent
+	segmentComponent
+	queryComponent
+	fragmentComponent
+)
+
+// A destination is a rule destination split into literal text and
+// references to captures, prepared for expanding.
+type destination []destinationPart
+
+// destinationPart is literal text, or a reference to the capture name
+// substituted into the component c.
+type destinationPart struct {
+	text string
+	name string
+	c    component
+}
+
+// newDestination returns the destination of r. Groups are only referenced
+// by regular expression sources.
+func newDestination(r *Rule) (d destination) {
+	to := r.To
+	regexpRule := isRegexp(sourcePattern(r.From))
+	bounds := componentBounds(to)
+	last := 0
+
+	for _, m := range reference.FindAllStringIndex(to, -1) {
+		ref := to[m[0]:m[1]]
+
+		name := ref[1:]
+		if strings.HasPrefix(ref, "$") {
+			if !regexpRule {
+				continue
+			}
+			name = strings.Trim(name, "{}")
+		}
+
+		if last < m[0] {
+			d = append(d, destinationPart{text: to[last:m[0]]})
+		}
+		c := bounds.at(m[0])
+		if c == pathComponent && boundByParam(r, ref) {
+			c = segmentComponent
+		}
+
+		d = append(d, destinationPart{text: ref, name: name, c: c})
+		last = m[1]
+	}
+
+	if last < len(to) {
+		d = append(d, destinationPart{text: to[last:]})
+	}
+
+	return
+}
+
+// boundByParam returns true if the placeholder ref is bound by a query
+// param of r, as ":id" is by "id=:id".
+func boundByParam(r *Rule, ref string) bool {
+	for _, v := range r.Params {
+		if s, ok := v.(string); ok && s == ref {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expand returns the destination of r with placeholders, and groups of
+// regular expression sources, replaced by captures.
+func expand(r *Rule, captures *Captures) string {
+	return newDestination(r).expand(captures)
+}
+
+// expand returns the destination with references replaced by captures.
+// References without a capture are left as written.
+//
+// Captures are escaped for the component of the destination they are
+// substituted into, so a hostile path cannot inject a query, fragment,
+// host or dot segment: each path segment is path-escaped, query and host
+// values are query-escaped unless they only contain host characters, and
+// "." and ".." segments are percent-encoded. Query params substituted into
+// the path are a single segment, with any slash escaped.
+func (d destination) expand(captures *Captures) string {
+	if len(d) == 1 && d[0].name == "" {
+		return d[0].text
+	}
+
+	n := 0
+	for _, p := range d {
+		v, _ := captures.Get(p.name)
+		n += len(p.text) + len(v)
+	}
+
+	var b strings.Builder
+	b.Grow(n)
+
+	for _, p := range d {
+		v, ok := captures.Get(p.name)
+		if p.name == "" || !ok {
+			b.WriteString(p.text)
+			continue
+		}
+
+		writeCapture(&b, p.c, v)
+	}
+
+	return b.String()
+}
+
+// bounds are the offsets at which the components of a destination start.
+type bounds struct {
+	path, query, fragment int
+}
+
+// componentBounds returns the component offsets of the destination to.
+func componentBounds(to string) (b bounds) {
+	b.fragment = len(to)
+	if i := strings.IndexByte(to, '#'); i >= 0 {
+		b.fragment = i
+	}
+
+	b.query = b.fragment
+	if i := strings.IndexByte(to[:b.fragment], '?'); i >= 0 {
+		b.query = i
+	}
+
+	if i := strings.Index(to[:b.query], "://"); i >= 0 {
+		b.path = b.query
+		if j := strings.IndexByte(to[i+3:b.query], '/'); j >= 0 {
+			b.path = i + 3 + j
+		}
+	}
+
+	return
+}
+
+// at returns the component at offset i.
+func (b bounds) at(i int) component {
+	switch {
+	case i >= b.fragment:
+		return fragmentComponent
+	case i >= b.query:
+		return queryComponent
+	case i >= b.path:
+		return pathComponent
+	default:
+		return hostComponent
+	}
+}
+
+// writeCapture writes the capture v escaped for the component c.
+func writeCapture(b *strings.Builder, c component, v string) {
+	switch c {
+	case hostComponent:
+		if hostLabels.MatchString(v) {
+			b.WriteString(v)
+		} else {
+			b.WriteString(url.QueryEscape(v))
+		}
+		return
+	case queryComponent:
+		b.WriteString(url.QueryEscape(v))
+		return
+	case fragmentComponent:
+		b.WriteString(url.PathEscape(v))
+		return
+	case segmentComponent:
+		if v == "." || v == ".." {
+			b.WriteString(strings.ReplaceAll(v, ".", "%2E"))
+		} else {
+			b.WriteString(url.PathEscape(v))
+		}
+		return
+	}
+
+	for i := 0; ; i++ {
+		s, rest, more := strings.Cut(v, "/")
+
+		if i > 0 {
+			b.WriteByte('/')
+		}
+
+		if s == "." || s == ".." {
+			b.WriteString(strings.ReplaceAll(s, ".", "%2E"))
+		} else {
+			b.WriteString(url.PathEscape(s))
+		}
+
+		if !more {
+			return
+		}
+		v = rest
+	}
+}
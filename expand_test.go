@@ -0,0 +1,87 @@
+package redirects_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestMatch_escaping(t *testing.T) {
+	rules := append([]redirects.Rule{
+		{From: "/tenant/:t/*", To: "https://:t.example.com/:splat", Status: 200},
+	}, redirects.Must(redirects.ParseString(`
+		/api/*             https://api.example.com/v1/:splat  200
+		/search/:q         /find?q=:q
+		/docs/:page        /manual#:page
+		/news/*            /blog/:splat
+	`))...)
+
+	cases := []struct {
+		name string
+		path string
+		to   string
+	}{
+		{"query injection", "/api/users?admin=true", "https://api.example.com/v1/users%3Fadmin=true"},
+		{"fragment injection", "/api/users#x", "https://api.example.com/v1/users%23x"},
+		{"dot segments", "/api/../admin", "https://api.example.com/v1/%2E%2E/admin"},
+		{"encoded slash", "/news/a b/c", "/blog/a%20b/c"},
+		{"host", "/tenant/acme/x", "https://acme.example.com/x"},
+		{"host injection", "/tenant/evil.com@x/y", "https://evil.com%40x.example.com/y"},
+		{"query value", "/search/a&b=c", "/find?q=a%26b%3Dc"},
+		{"fragment", "/docs/a b", "/manual#a%20b"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res, ok := redirects.Match(rules, redirects.Request{Path: c.path})
+			assert.True(t, ok)
+			assert.Equal(t, c.to, res.To)
+		})
+	}
+
+	t.Run("captures are not escaped", func(t *testing.T) {
+		res, ok := redirects.Match(rules, redirects.Request{Path: "/news/a b"})
+		assert.True(t, ok)
+		v, _ := res.Captures.Get("splat")
+		assert.Equal(t, "a b", v)
+	})
+}
+
+func TestMatch_escapingParams(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`/store  id=:id  /item/:id`))
+
+	cases := []struct {
+		name string
+		id   string
+		to   string
+	}{
+		{"value", "5", "/item/5"},
+		{"slash", "a b/c", "/item/a%20b%2Fc"},
+		{"dot segment", "..", "/item/%2E%2E"},
+		{"query injection", "5?admin=1", "/item/5%3Fadmin=1"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := redirects.Request{Path: "/store", Query: url.Values{"id": {c.id}}}
+
+			res, ok := redirects.Match(rules, req)
+			assert.True(t, ok)
+			assert.Equal(t, c.to, res.To)
+
+			res, ok = redirects.Compile(rules).Match(req)
+			assert.True(t, ok)
+			assert.Equal(t, c.to, res.To)
+		})
+	}
+}
+
+func TestMatch_escapingRegexp(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`~^/p/(.+)$  /products?id=$1`, redirects.WithRegexRules()))
+
+	res, ok := redirects.Match(rules, redirects.Request{Path: "/p/1&admin=1"})
+	assert.True(t, ok)
+	assert.Equal(t, "/products?id=1%26admin%3D1", res.To)
+}
@@ -0,0 +1,126 @@
+package redirects
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Explain returns a description of what a rule does in plain English, for
+// admin interfaces and change summaries, such as "Requests to /api/* are
+// proxied to https://api.example.com preserving the rest of the path;
+// applies only to visitors from AU or NZ."
+func Explain(r Rule) string {
+	var b strings.Builder
+
+	if from := sourcePattern(r.From); isRegexp(from) {
+		fmt.Fprintf(&b, "Requests matching %s ", from[1:])
+	} else {
+		fmt.Fprintf(&b, "Requests to %s ", from)
+	}
+
+	to, rest := unicodeURL(r.To), ""
+	if strings.HasSuffix(r.From, "/*") && strings.HasSuffix(to, "/:splat") {
+		to, rest = strings.TrimSuffix(to, "/:splat"), " preserving the rest of the path"
+	}
+
+	switch {
+	case isExclusion(r.From):
+		b.WriteString("are not handled by later rules")
+	case r.IsRewrite() && r.IsProxy():
+		fmt.Fprintf(&b, "are proxied to %s%s", to, rest)
+	case r.IsRewrite():
+		fmt.Fprintf(&b, "are served the content of %s%s", to, rest)
+	case r.IsContent():
+		fmt.Fprintf(&b, "are answered with the content of %s%s and %s", to, rest, explainStatus(r.Status))
+	default:
+		fmt.Fprintf(&b, "are redirected to %s%s with %s", to, rest, explainStatus(r.Status))
+	}
+
+	if conds := explainConditions(r); len(conds) > 0 {
+		b.WriteString("; applies only to " + strings.Join(conds, " and "))
+	}
+
+	switch {
+	case !r.ActiveFrom.IsZero() && !r.ActiveUntil.IsZero():
+		fmt.Fprintf(&b, "; active from %s until %s", r.ActiveFrom.Format(time.RFC3339), r.ActiveUntil.Format(time.RFC3339))
+	case !r.ActiveFrom.IsZero():
+		fmt.Fprintf(&b, "; active from %s", r.ActiveFrom.Format(time.RFC3339))
+	case !r.ActiveUntil.IsZero():
+		fmt.Fprintf(&b, "; active until %s", r.ActiveUntil.Format(time.RFC3339))
+	}
+
+	if r.Force {
+		b.WriteString("; applies even when content exists at the requested path")
+	}
+
+	b.WriteString(".")
+	return b.String()
+}
+
+// explainStatus returns a status code along with its name.
+func explainStatus(status int) string {
+	if text := http.StatusText(status); text != "" {
+		return fmt.Sprintf("a %d (%s)", status, text)
+	}
+
+	return fmt.Sprintf("a %d", status)
+}
+
+// explainConditions returns the requests the conditions of r restrict it
+// to, in plain English.
+func explainConditions(r Rule) (conds []string) {
+	if len(r.Country) > 0 {
+		countries := make([]string, len(r.Country))
+		for i, c := range r.Country {
+			countries[i] = strings.ToUpper(strings.TrimPrefix(c, "!"))
+		}
+
+		if negated(r.Country) {
+			conds = append(conds, "visitors outside "+strings.Join(countries, " and "))
+		} else {
+			conds = append(conds, "visitors from "+strings.Join(countries, " or "))
+		}
+	}
+
+	if len(r.Language) > 0 {
+		conds = append(conds, "visitors preferring "+strings.Join(r.Language, " or "))
+	}
+
+	if len(r.Method) > 0 {
+		conds = append(conds, strings.Join(r.Method, " or ")+" requests")
+	}
+
+	if len(r.Host) > 0 {
+		hosts := make([]string, len(r.Host))
+		for i, h := range r.Host {
+			hosts[i] = unicodeHost(h)
+		}
+
+		conds = append(conds, "requests for "+strings.Join(hosts, " or "))
+	}
+
+	for _, k := range r.Params.keys() {
+		switch v := r.Params[k].(type) {
+		case string:
+			if strings.HasPrefix(v, ":") {
+				conds = append(conds, fmt.Sprintf("requests with the query param %s, captured as %s", k, v))
+			} else {
+				conds = append(conds, fmt.Sprintf("requests with the query param %s=%s", k, v))
+			}
+		default:
+			conds = append(conds, "requests with the query param "+k)
+		}
+	}
+
+	for _, k := range r.Conditions.keys() {
+		if name := strings.TrimPrefix(k, "Header:"); name != k {
+			conds = append(conds, fmt.Sprintf("requests with the %s header set to %s", name, r.Conditions[k]))
+		} else {
+			conds = append(conds, fmt.Sprintf("requests with %s set to %s", k, r.Conditions[k]))
+		}
+	}
+
+	return
+}
@@ -0,0 +1,40 @@
+package redirects_test
+
+import (
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestExplain(t *testing.T) {
+	cases := []struct {
+		rule     string
+		expected string
+	}{
+		{"/api/*  https://api.example.com/:splat  200  Country=au,nz", "Requests to /api/* are proxied to https://api.example.com preserving the rest of the path; applies only to visitors from AU or NZ."},
+		{"/  /global  302  Country=!cn,!ru", "Requests to / are redirected to /global with a 302 (Found); applies only to visitors outside CN and RU."},
+		{"/home  /", "Requests to /home are redirected to / with a 301 (Moved Permanently)."},
+		{"/google  https://www.google.com  301", "Requests to /google are redirected to https://www.google.com with a 301 (Moved Permanently)."},
+		{"/store id=:id  /blog/:id  302!", "Requests to /store are redirected to /blog/:id with a 302 (Found); applies only to requests with the query param id, captured as :id; applies even when content exists at the requested path."},
+		{"/*  /index.html  200", "Requests to /* are served the content of /index.html."},
+		{"/de/*  /de/404.html  404  Language=de", "Requests to /de/* are answered with the content of /de/404.html and a 404 (Not Found); applies only to visitors preferring de."},
+		{"/form  /thanks  303  Method=POST Host=example.com Header:X-Canary=true", "Requests to /form are redirected to /thanks with a 303 (See Other); applies only to POST requests and requests for example.com and requests with the X-Canary header set to true."},
+		{"/sale  /winter  302  From=2024-12-01T00:00Z Until=2025-01-01T00:00Z", "Requests to /sale are redirected to /winter with a 302 (Found); active from 2024-12-01T00:00:00Z until 2025-01-01T00:00:00Z."},
+	}
+
+	for _, c := range cases {
+		t.Run(c.rule, func(t *testing.T) {
+			rules, err := redirects.ParseString(c.rule)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, redirects.Explain(rules[0]))
+		})
+	}
+
+	t.Run("extensions", func(t *testing.T) {
+		rules, err := redirects.ParseString("!/api/health\n~^/blog/(\\d+)$  /posts/$1", redirects.WithExclusionRules(), redirects.WithRegexRules())
+		assert.NoError(t, err)
+		assert.Equal(t, "Requests to /api/health are not handled by later rules.", redirects.Explain(rules[0]))
+		assert.Equal(t, `Requests matching ^/blog/(\d+)$ are redirected to /posts/$1 with a 301 (Moved Permanently).`, redirects.Explain(rules[1]))
+	})
+}
@@ -0,0 +1,103 @@
+package redirects
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// An Unsupported reports a rule which could not be exported to a format
+// lacking an equivalent feature.
+type Unsupported struct {
+	// Index is the position of the rule in the exported slice.
+	Index int
+
+	// Rule is the skipped rule.
+	Rule Rule
+
+	// Reason describes the missing feature.
+	Reason string
+}
+
+// String returns a description of the skipped rule.
+func (u Unsupported) String() string {
+	return fmt.Sprintf("rule %d (%s): %s", u.Index, u.Rule.From, u.Reason)
+}
+
+// unsupportedCondition returns the reason r cannot be exported to a format
+// without query params or conditions, or an empty string.
+func unsupportedCondition(r Rule) string {
+	switch {
+	case isRegexp(r.From):
+		return "regular expression sources are not supported"
+	case len(r.Params) > 0:
+		return "query params are not supported"
+	case conditions(r) > 0:
+		return "conditions are not supported"
+	case !strings.HasPrefix(r.From, "/"):
+		return "absolute source URLs are not supported"
+	default:
+		return ""
+	}
+}
+
+// patternRegexp returns a regular expression matching the paths matched by
+// the source path pattern, as matchPath does, along with the placeholder
+// names of its capture groups in order, wildcards included as "splat", or
+// "splat1", "splat2" and so on when there are several, see splatTo. The
+// expression uses the syntax common to RE2, PCRE and JavaScript.
+func patternRegexp(pattern string) (expr string, names []string) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	ps := segments(pattern)
+	total := wildcards(ps)
+	n := 0
+
+	for i, s := range ps {
+		switch {
+		case isWildcard(s):
+			n++
+			if s == "**" || i == len(ps)-1 {
+				b.WriteString("(?:/(.*))?")
+			} else {
+				b.WriteString("/([^/]+)")
+			}
+			if total > 1 {
+				names = append(names, "splat"+strconv.Itoa(n))
+			} else {
+				names = append(names, "splat")
+			}
+		case segmentKind(s) == placeholderSegment:
+			name, constraint := placeholderName(s)
+			expr, err := constraintExpr(constraint)
+			if constraint == "" || err != nil {
+				expr = "[^/]+"
+			}
+			b.WriteString("/(" + expr + ")")
+			names = append(names, name)
+		default:
+			b.WriteString("/" + regexp.QuoteMeta(s))
+		}
+	}
+
+	b.WriteString("/?$")
+	return b.String(), names
+}
+
+// splatTo returns the destination with :splat replaced by the numbered
+// splat of the last wildcard when the source path has several.
+func splatTo(from, to string) string {
+	n := wildcards(segments(from))
+	if n < 2 {
+		return to
+	}
+
+	return placeholder.ReplaceAllStringFunc(to, func(s string) string {
+		if s == ":splat" {
+			return ":splat" + strconv.Itoa(n)
+		}
+		return s
+	})
+}
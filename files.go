@@ -0,0 +1,120 @@
+package redirects
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// A MissingFile reports a content rule, such as a rewrite, whose destination
+// is not a file of the site.
+type MissingFile struct {
+	// Index is the position of the rule in the audited slice.
+	Index int
+
+	// Rule is the flagged rule.
+	Rule Rule
+
+	// Reason describes the problem.
+	Reason string
+}
+
+// String returns a description of the finding.
+func (m MissingFile) String() string {
+	return fmt.Sprintf("rule %d (%s): %s", m.Index, m.Rule.From, m.Reason)
+}
+
+// AuditMissingFiles returns the content rules, such as rewrites and custom
+// 404 pages, whose internal destination is not a file of the site's file
+// tree fsys, catching typos such as "/app/* /app/index.htm 200" at build
+// time. Destinations are found as served: a directory is served by its
+// index.html, and "/about" by "about.html". Destinations with placeholders
+// are skipped.
+func AuditMissingFiles(rules []Rule, fsys fs.FS) (found []MissingFile) {
+	for i, r := range rules {
+		if reason := auditFile(r, fsys); reason != "" {
+			found = append(found, MissingFile{
+				Index:  i,
+				Rule:   r,
+				Reason: reason,
+			})
+		}
+	}
+
+	return
+}
+
+// auditFile returns the reason the destination of r is missing, or an
+// empty string.
+func auditFile(r Rule, fsys fs.FS) string {
+	if !r.IsContent() || r.IsProxy() || isExclusion(r.From) || !strings.HasPrefix(r.To, "/") {
+		return ""
+	}
+
+	if placeholder.MatchString(r.To) || strings.Contains(r.To, "$") {
+		return ""
+	}
+
+	to := r.To
+	if i := strings.IndexAny(to, "?#"); i >= 0 {
+		to = to[:i]
+	}
+
+	name := strings.TrimPrefix(path.Clean(to), "/")
+	if name == "" {
+		name = "."
+	}
+
+	if fileExists(fsys, name) {
+		return ""
+	}
+
+	reason := fmt.Sprintf("destination %s does not exist", to)
+	if s := similarFile(fsys, name); s != "" {
+		reason += fmt.Sprintf(", did you mean /%s?", s)
+	}
+
+	return reason
+}
+
+// fileExists returns true if name, its index.html or name.html is a file.
+func fileExists(fsys fs.FS, name string) bool {
+	candidates := []string{name, path.Join(name, "index.html")}
+	if name != "." {
+		candidates = append(candidates, name+".html")
+	}
+
+	for _, c := range candidates {
+		if fi, err := fs.Stat(fsys, c); err == nil && fi.Mode().IsRegular() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// similarFile returns the file in the directory of name most similar to
+// name, or an empty string when none is similar enough to be a typo.
+func similarFile(fsys fs.FS, name string) string {
+	dir, base := path.Split(name)
+	entries, err := fs.ReadDir(fsys, path.Clean("./"+dir))
+	if err != nil {
+		return ""
+	}
+
+	var best string
+	var bestScore float64
+
+	for _, e := range entries {
+		if score := similarity(base, e.Name()); score > bestScore && !e.IsDir() {
+			best, bestScore = e.Name(), score
+		}
+	}
+
+	if bestScore < 0.8 {
+		return ""
+	}
+
+	return dir + best
+}
@@ -0,0 +1,37 @@
+package redirects_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestAuditMissingFiles(t *testing.T) {
+	site := fstest.MapFS{
+		"index.html":      {},
+		"404.html":        {},
+		"about.html":      {},
+		"app/index.html":  {},
+		"docs/index.html": {},
+	}
+
+	rules := redirects.Must(redirects.ParseString(`
+		/app/*      /app/index.htm  200
+		/*          /index.html     200
+		/docs/*     /docs/          200
+		/team       /about          200
+		/de/*       /de/404.html    404
+		/old        /missing
+		/api/*      https://api.example.com/:splat  200
+		/blog/:slug /posts/:slug    200
+		/sale       /campaign?x=1   200!
+	`))
+
+	found := redirects.AuditMissingFiles(rules, site)
+	assert.Len(t, found, 3)
+	assert.Equal(t, "rule 0 (/app/*): destination /app/index.htm does not exist, did you mean /app/index.html?", found[0].String())
+	assert.Equal(t, "rule 4 (/de/*): destination /de/404.html does not exist", found[1].String())
+	assert.Equal(t, "rule 8 (/sale): destination /campaign does not exist", found[2].String())
+}
@@ -0,0 +1,193 @@
+package redirects
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// firebaseConfig is the part of firebase.json holding redirects.
+type firebaseConfig struct {
+	Hosting json.RawMessage `json:"hosting,omitempty"`
+}
+
+// firebaseHosting is the hosting configuration of firebase.json.
+type firebaseHosting struct {
+	Redirects []firebaseRule `json:"redirects"`
+	Rewrites  []firebaseRule `json:"rewrites"`
+}
+
+// firebaseRule is a Firebase Hosting redirect or rewrite.
+type firebaseRule struct {
+	Source      string          `json:"source,omitempty"`
+	Regex       string          `json:"regex,omitempty"`
+	Destination string          `json:"destination,omitempty"`
+	Type        int             `json:"type,omitempty"`
+	Function    string          `json:"function,omitempty"`
+	Run         json.RawMessage `json:"run,omitempty"`
+}
+
+// ExportFirebase writes the rules as the redirects and rewrites of a
+// firebase.json hosting configuration. Sources are translated to Firebase
+// globs, with "/*" becoming "/**", or "/:splat*" when the destination uses
+// :splat. Firebase applies all redirects before rewrites, and only supports
+// 301 and 302 redirects and rewrites to local paths without query params or
+// conditions. Other rules are skipped and reported.
+func ExportFirebase(w io.Writer, rules []Rule) ([]Unsupported, error) {
+	hosting := firebaseHosting{
+		Redirects: []firebaseRule{},
+		Rewrites:  []firebaseRule{},
+	}
+
+	var skipped []Unsupported
+
+	for i, r := range rules {
+		if reason := firebaseUnsupported(r); reason != "" {
+			skipped = append(skipped, Unsupported{Index: i, Rule: r, Reason: reason})
+			continue
+		}
+
+		source := r.From
+		if strings.HasSuffix(source, "*") {
+			if strings.Contains(r.To, ":splat") {
+				source = strings.TrimSuffix(source, "*") + ":splat*"
+			} else {
+				source += "*"
+			}
+		}
+
+		if r.IsRewrite() {
+			hosting.Rewrites = append(hosting.Rewrites, firebaseRule{Source: source, Destination: r.To})
+		} else {
+			hosting.Redirects = append(hosting.Redirects, firebaseRule{Source: source, Destination: r.To, Type: r.Status})
+		}
+	}
+
+	b, err := json.Marshal(hosting)
+	if err != nil {
+		return skipped, err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return skipped, enc.Encode(firebaseConfig{Hosting: b})
+}
+
+// ImportFirebase reads the redirects and rewrites of a firebase.json file,
+// or of its hosting configuration, redirects first as Firebase applies them.
+// Globs are translated to source paths, with "**" becoming a splat and a
+// trailing ":name*" a splat named in the destination. Rules which cannot be
+// converted, such as regex sources, other globs and rewrites to functions,
+// are skipped and reported as errors wrapping ErrUnsupported.
+func ImportFirebase(r io.Reader) (rules []Rule, skipped []error, err error) {
+	var config struct {
+		firebaseConfig
+		firebaseHosting
+	}
+
+	if err := json.NewDecoder(r).Decode(&config); err != nil {
+		return nil, nil, fmt.Errorf("decoding: %w", err)
+	}
+
+	hosting := config.firebaseHosting
+
+	if len(config.Hosting) > 0 {
+		if strings.HasPrefix(strings.TrimSpace(string(config.Hosting)), "[") {
+			return nil, nil, fmt.Errorf("%w: multiple hosting sites", ErrUnsupported)
+		}
+
+		if err := json.Unmarshal(config.Hosting, &hosting); err != nil {
+			return nil, nil, fmt.Errorf("decoding hosting: %w", err)
+		}
+	}
+
+	for i, v := range hosting.Redirects {
+		status := v.Type
+		if status == 0 {
+			status = 301
+		}
+
+		rule, err := firebaseRuleOf(v, status)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("redirects[%d]: %w", i, err))
+			continue
+		}
+
+		rules = append(rules, rule)
+	}
+
+	for i, v := range hosting.Rewrites {
+		rule, err := firebaseRuleOf(v, 200)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("rewrites[%d]: %w", i, err))
+			continue
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, skipped, nil
+}
+
+// firebaseRuleOf returns the rule of a Firebase redirect or rewrite.
+func firebaseRuleOf(v firebaseRule, status int) (rule Rule, err error) {
+	switch {
+	case v.Regex != "":
+		return rule, fmt.Errorf("%w: regex source %q", ErrUnsupported, v.Regex)
+	case v.Function != "" || v.Run != nil:
+		return rule, fmt.Errorf("%w: rewrite to a function or Cloud Run service", ErrUnsupported)
+	case v.Destination == "":
+		return rule, fmt.Errorf("%w: missing destination", ErrUnsupported)
+	}
+
+	to := v.Destination
+	segs := strings.Split(strings.TrimPrefix(v.Source, "/"), "/")
+
+	for i, s := range segs {
+		last := i == len(segs)-1
+
+		switch {
+		case s == "**" && last:
+			segs[i] = "*"
+		case strings.HasPrefix(s, ":") && strings.HasSuffix(s, "*") && last:
+			segs[i] = "*"
+			name := strings.TrimSuffix(s, "*")
+			to = placeholder.ReplaceAllStringFunc(to, func(p string) string {
+				if p == name {
+					return ":splat"
+				}
+				return p
+			})
+		case s == "*":
+			segs[i] = fmt.Sprintf(":glob%d", i)
+		case strings.ContainsAny(s, "*?{}[]!"):
+			return rule, fmt.Errorf("%w: glob %q", ErrUnsupported, v.Source)
+		}
+	}
+
+	return Rule{From: "/" + strings.Join(segs, "/"), To: to, Status: status}, nil
+}
+
+// firebaseUnsupported returns the reason r cannot be exported to
+// firebase.json, or an empty string.
+func firebaseUnsupported(r Rule) string {
+	switch {
+	case isExclusion(r.From):
+		return "exclusion rules are not supported"
+	case r.IsRewrite() && r.IsProxy():
+		return "proxies are not supported"
+	case r.IsContent() && !r.IsRewrite():
+		return "content responses are not supported"
+	case !r.IsContent() && r.Status != 301 && r.Status != 302:
+		return "only 301 and 302 redirects are supported"
+	case r.IsRewrite() && placeholder.MatchString(r.To):
+		return "rewrites with placeholders are not supported"
+	case constrained(r.From):
+		return "placeholder constraints are not supported"
+	case extendedWildcard(r.From):
+		return "double and mid-path wildcards are not supported"
+	default:
+		return unsupportedCondition(r)
+	}
+}
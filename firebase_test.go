@@ -0,0 +1,140 @@
+package redirects_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/pkg/errors"
+	"github.com/tj/assert"
+)
+
+func TestExportFirebase(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/home         /
+		/blog/:slug   /posts/:slug  302
+		/news/*       /blog/:splat
+		/old/*        /archive
+		/*            /index.html  200
+		/api/*        https://api.example.com/:splat  200
+		/temp         /elsewhere  307
+	`))
+
+	var b strings.Builder
+	skipped, err := redirects.ExportFirebase(&b, rules)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `{
+  "hosting": {
+    "redirects": [
+      {
+        "source": "/home",
+        "destination": "/",
+        "type": 301
+      },
+      {
+        "source": "/blog/:slug",
+        "destination": "/posts/:slug",
+        "type": 302
+      },
+      {
+        "source": "/news/:splat*",
+        "destination": "/blog/:splat",
+        "type": 301
+      },
+      {
+        "source": "/old/**",
+        "destination": "/archive",
+        "type": 301
+      }
+    ],
+    "rewrites": [
+      {
+        "source": "/**",
+        "destination": "/index.html"
+      }
+    ]
+  }
+}
+`, b.String())
+
+	assert.Len(t, skipped, 2)
+	assert.Equal(t, "rule 5 (/api/*): proxies are not supported", skipped[0].String())
+	assert.Equal(t, "rule 6 (/temp): only 301 and 302 redirects are supported", skipped[1].String())
+}
+
+func TestImportFirebase(t *testing.T) {
+	t.Run("firebase.json", func(t *testing.T) {
+		rules, skipped, err := redirects.ImportFirebase(strings.NewReader(`{
+			"hosting": {
+				"public": "public",
+				"rewrites": [
+					{"source": "/api/**", "function": "api"},
+					{"source": "**", "destination": "/index.html"}
+				],
+				"redirects": [
+					{"source": "/home", "destination": "/", "type": 301},
+					{"source": "/blog/:post*", "destination": "/news/:post", "type": 302},
+					{"source": "/users/*/profile", "destination": "/profile"},
+					{"source": "/*.html", "destination": "/"},
+					{"regex": "^/old/(.*)$", "destination": "/new/:1"}
+				]
+			}
+		}`))
+
+		assert.NoError(t, err)
+
+		var lines []string
+		for _, r := range rules {
+			lines = append(lines, r.String())
+		}
+
+		assert.Equal(t, []string{
+			"/home / 301",
+			"/blog/* /news/:splat 302",
+			"/users/:glob1/profile /profile 301",
+			"/* /index.html 200",
+		}, lines)
+
+		var reasons []string
+		for _, err := range skipped {
+			assert.True(t, errors.Is(err, redirects.ErrUnsupported))
+			reasons = append(reasons, err.Error())
+		}
+
+		assert.Equal(t, []string{
+			`redirects[3]: unsupported: glob "/*.html"`,
+			`redirects[4]: unsupported: regex source "^/old/(.*)$"`,
+			`rewrites[0]: unsupported: rewrite to a function or Cloud Run service`,
+		}, reasons)
+	})
+
+	t.Run("hosting configuration", func(t *testing.T) {
+		rules, skipped, err := redirects.ImportFirebase(strings.NewReader(`{"redirects": [{"source": "/a", "destination": "/b"}]}`))
+		assert.NoError(t, err)
+		assert.Empty(t, skipped)
+		assert.Equal(t, []redirects.Rule{{From: "/a", To: "/b", Status: 301}}, rules)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/home       /            301
+			/news/*     /blog/:splat  302
+			/*          /index.html  200
+		`))
+
+		var b strings.Builder
+		_, err := redirects.ExportFirebase(&b, rules)
+		assert.NoError(t, err)
+
+		v, skipped, err := redirects.ImportFirebase(strings.NewReader(b.String()))
+		assert.NoError(t, err)
+		assert.Empty(t, skipped)
+		assert.Equal(t, rules, v)
+	})
+
+	t.Run("multiple sites", func(t *testing.T) {
+		_, _, err := redirects.ImportFirebase(strings.NewReader(`{"hosting": [{"target": "a"}]}`))
+		assert.True(t, errors.Is(err, redirects.ErrUnsupported))
+	})
+}
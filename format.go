@@ -0,0 +1,184 @@
+package redirects
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// tabWidth is the tab width assumed when aligning columns with tabs.
+const tabWidth = 8
+
+// FormatOptions configures how MarshalWith and Document.Format lay out
+// rules, so generated files match a team's existing style and diffs stay
+// small. The zero value writes fields separated by a space, as Marshal does.
+type FormatOptions struct {
+	// Align pads the source and params, destination, status and conditions
+	// of rules into columns, as in Netlify's documentation.
+	Align bool
+
+	// MinWidth is the minimum width of aligned columns.
+	MinWidth int
+
+	// Tabs separates fields with tabs rather than spaces. Aligned columns
+	// are padded with tabs, assuming a tab width of 8.
+	Tabs bool
+
+	// GroupByStatus groups rules by status under a comment such as
+	// "# 301 Moved Permanently", with exclusion rules grouped under
+	// "# Exclusions". Groups are ordered by their first rule and keep the
+	// order of their rules. Moving rules changes which applies when rules
+	// overlap, so check the result with Shadows or keep the input order.
+	GroupByStatus bool
+
+	// Status normalizes how the 301 status is written, which is kept as
+	// written by default.
+	Status StatusStyle
+}
+
+// StatusStyle is how a formatter writes the default 301 status.
+type StatusStyle int
+
+// Status styles.
+const (
+	// StatusAsWritten writes the status when it was written, see
+	// Rule.StatusImplied.
+	StatusAsWritten StatusStyle = iota
+
+	// StatusExplicit writes every status, as in "/a /b 301".
+	StatusExplicit
+
+	// StatusImplicit leaves out every 301 status, as in "/a /b", and
+	// "/a /b !" when forced.
+	StatusImplicit
+)
+
+// columns returns the columns of r with its status written per the Status
+// option.
+func (opts FormatOptions) columns(r Rule) [4]string {
+	switch opts.Status {
+	case StatusExplicit:
+		r.StatusImplied = false
+	case StatusImplicit:
+		r.StatusImplied = r.Status == 301
+	}
+
+	return r.columns()
+}
+
+// MarshalWith returns the rules in the _redirects file format laid out as
+// configured by opts, with an annotation comment above rules with metadata,
+// so that Parse returns the same rules unless grouped by status.
+func MarshalWith(rules []Rule, opts FormatOptions) []byte {
+	groups := [][]Rule{rules}
+	if opts.GroupByStatus {
+		groups = groupByStatus(rules)
+	}
+
+	var widths [4]int
+	if opts.Align {
+		widths = columnWidths(rules, opts)
+	}
+
+	var b []byte
+	for i, g := range groups {
+		if opts.GroupByStatus {
+			if i > 0 {
+				b = append(b, '\n')
+			}
+			b = append(b, "# "+groupName(g[0])+"\n"...)
+		}
+
+		for _, r := range g {
+			if len(r.Meta) > 0 {
+				b = append(b, "#@ "...)
+				b = append(b, formatMeta(r.Meta)...)
+				b = append(b, '\n')
+			}
+
+			b = appendColumns(b, opts.columns(r), widths, opts)
+			b = append(b, '\n')
+		}
+	}
+
+	return b
+}
+
+// appendColumns appends the non-empty columns of a rule to b, padded to
+// widths when aligned.
+func appendColumns(b []byte, cols [4]string, widths [4]int, opts FormatOptions) []byte {
+	last := len(cols) - 1
+	for last > 0 && cols[last] == "" {
+		last--
+	}
+
+	for i, c := range cols[:last+1] {
+		if i == last {
+			return append(b, c...)
+		}
+
+		if !opts.Align && c == "" {
+			continue
+		}
+
+		b = append(b, c...)
+
+		switch {
+		case !opts.Align && opts.Tabs:
+			b = append(b, '\t')
+		case !opts.Align:
+			b = append(b, ' ')
+		case opts.Tabs:
+			stop := (widths[i]/tabWidth + 1) * tabWidth
+			b = append(b, strings.Repeat("\t", (stop-len(c)+tabWidth-1)/tabWidth)...)
+		default:
+			b = append(b, strings.Repeat(" ", widths[i]-len(c)+2)...)
+		}
+	}
+
+	return b
+}
+
+// columnWidths returns the width of each column of rules.
+func columnWidths(rules []Rule, opts FormatOptions) (widths [4]int) {
+	for i := range widths {
+		widths[i] = opts.MinWidth
+	}
+
+	for _, r := range rules {
+		for i, c := range opts.columns(r) {
+			if len(c) > widths[i] {
+				widths[i] = len(c)
+			}
+		}
+	}
+
+	return
+}
+
+// groupByStatus returns rules grouped by status, in order of first rule.
+func groupByStatus(rules []Rule) (groups [][]Rule) {
+	index := make(map[string]int)
+
+	for _, r := range rules {
+		name := groupName(r)
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], r)
+	}
+
+	return
+}
+
+// groupName returns the name of the status group of r.
+func groupName(r Rule) string {
+	if isExclusion(r.From) {
+		return "Exclusions"
+	}
+
+	return strings.TrimSpace(fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)))
+}
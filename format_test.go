@@ -0,0 +1,94 @@
+package redirects_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+
+	"github.com/fission-suite/go-redirects"
+)
+
+func TestMarshalWith(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+/home  /  301
+/news  /blog  302
+#@ owner=seo
+/articles/*  /blog/:splat  301
+/admin/*  /admin/:splat  200
+/old  /new  302!  Country=au
+`))
+
+	t.Run("default", func(t *testing.T) {
+		assert.Equal(t, string(redirects.Marshal(rules)), string(redirects.MarshalWith(rules, redirects.FormatOptions{})))
+	})
+
+	t.Run("align", func(t *testing.T) {
+		b := redirects.MarshalWith(rules, redirects.FormatOptions{Align: true})
+		assert.Equal(t, `/home        /              301
+/news        /blog          302
+#@ owner=seo
+/articles/*  /blog/:splat   301
+/admin/*     /admin/:splat  200
+/old         /new           302!  Country=au
+`, string(b))
+
+		parsed, err := redirects.Parse(strings.NewReader(string(b)))
+		assert.NoError(t, err)
+		assert.Equal(t, rules, parsed)
+	})
+
+	t.Run("min width", func(t *testing.T) {
+		b := redirects.MarshalWith(rules[:2], redirects.FormatOptions{Align: true, MinWidth: 6})
+		assert.Equal(t, "/home   /       301\n/news   /blog   302\n", string(b))
+	})
+
+	t.Run("tabs", func(t *testing.T) {
+		b := redirects.MarshalWith(rules[:2], redirects.FormatOptions{Tabs: true})
+		assert.Equal(t, "/home\t/\t301\n/news\t/blog\t302\n", string(b))
+	})
+
+	t.Run("aligned tabs", func(t *testing.T) {
+		b := redirects.MarshalWith(rules[2:4], redirects.FormatOptions{Align: true, Tabs: true})
+		assert.Equal(t, "#@ owner=seo\n/articles/*\t/blog/:splat\t301\n/admin/*\t/admin/:splat\t200\n", string(b))
+	})
+
+	t.Run("status", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString("/a  /b\n/c  /d  301\n/e  /f  301!\n/g  /h  302\n/i  /j  !\n"))
+
+		b := redirects.MarshalWith(rules, redirects.FormatOptions{})
+		assert.Equal(t, "/a /b\n/c /d 301\n/e /f 301!\n/g /h 302\n/i /j !\n", string(b))
+
+		b = redirects.MarshalWith(rules, redirects.FormatOptions{Status: redirects.StatusExplicit})
+		assert.Equal(t, "/a /b 301\n/c /d 301\n/e /f 301!\n/g /h 302\n/i /j 301!\n", string(b))
+
+		b = redirects.MarshalWith(rules, redirects.FormatOptions{Status: redirects.StatusImplicit, Align: true})
+		assert.Equal(t, "/a  /b\n/c  /d\n/e  /f  !\n/g  /h  302\n/i  /j  !\n", string(b))
+	})
+
+	t.Run("group by status", func(t *testing.T) {
+		b := redirects.MarshalWith(rules, redirects.FormatOptions{GroupByStatus: true})
+		assert.Equal(t, `# 301 Moved Permanently
+/home / 301
+#@ owner=seo
+/articles/* /blog/:splat 301
+
+# 302 Found
+/news /blog 302
+/old /new 302! Country=au
+
+# 200 OK
+/admin/* /admin/:splat 200
+`, string(b))
+	})
+}
+
+func TestDocument_Format(t *testing.T) {
+	d, err := redirects.ParseDocument(strings.NewReader("/home  /  301\n## [section: blog]\n/news  /blog  302\n/feed  /rss.xml  200\n"))
+	assert.NoError(t, err)
+
+	var b strings.Builder
+	_, err = d.Format(&b, redirects.FormatOptions{Align: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "/home  /  301\n\n## [section: blog]\n/news  /blog     302\n/feed  /rss.xml  200\n", b.String())
+}
@@ -0,0 +1,270 @@
+package redirects
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// HTTPRouteOptions configures the HTTPRoute written by WriteHTTPRoute.
+type HTTPRouteOptions struct {
+	// Name is the route name.
+	Name string
+
+	// Namespace is the route namespace, defaulting to that of kubectl.
+	Namespace string
+
+	// Gateway is the name of the parent Gateway.
+	Gateway string
+
+	// Service is the backend service serving rewrites.
+	Service string
+
+	// Port is the backend service port.
+	Port int
+}
+
+// httpRoute is a Gateway API HTTPRoute.
+type httpRoute struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   httpRouteMetadata `json:"metadata"`
+	Spec       httpRouteSpec     `json:"spec"`
+}
+
+// httpRouteMetadata is the object metadata of an HTTPRoute.
+type httpRouteMetadata struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// httpRouteSpec is the spec of an HTTPRoute.
+type httpRouteSpec struct {
+	ParentRefs []httpRouteRef  `json:"parentRefs"`
+	Rules      []httpRouteRule `json:"rules"`
+}
+
+// httpRouteRef is a reference to a parent Gateway or backend service.
+type httpRouteRef struct {
+	Name string `json:"name"`
+	Port int    `json:"port,omitempty"`
+}
+
+// httpRouteRule is a rule of an HTTPRoute.
+type httpRouteRule struct {
+	Matches     []httpRouteMatch  `json:"matches"`
+	Filters     []httpRouteFilter `json:"filters"`
+	BackendRefs []httpRouteRef    `json:"backendRefs,omitempty"`
+}
+
+// httpRouteMatch is a request match of an HTTPRoute rule.
+type httpRouteMatch struct {
+	Path        httpRoutePath      `json:"path"`
+	Method      string             `json:"method,omitempty"`
+	Headers     []httpRouteNameVal `json:"headers,omitempty"`
+	QueryParams []httpRouteNameVal `json:"queryParams,omitempty"`
+}
+
+// httpRoutePath is a path match.
+type httpRoutePath struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// httpRouteNameVal is an exact header or query param match.
+type httpRouteNameVal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// httpRouteFilter is a filter of an HTTPRoute rule.
+type httpRouteFilter struct {
+	Type            string                    `json:"type"`
+	RequestRedirect *httpRouteRequestRedirect `json:"requestRedirect,omitempty"`
+	URLRewrite      *httpRouteURLRewrite      `json:"urlRewrite,omitempty"`
+}
+
+// httpRouteRequestRedirect is the RequestRedirect filter.
+type httpRouteRequestRedirect struct {
+	Scheme     string                 `json:"scheme,omitempty"`
+	Hostname   string                 `json:"hostname,omitempty"`
+	Path       *httpRoutePathModifier `json:"path,omitempty"`
+	StatusCode int                    `json:"statusCode"`
+}
+
+// httpRouteURLRewrite is the URLRewrite filter.
+type httpRouteURLRewrite struct {
+	Path *httpRoutePathModifier `json:"path,omitempty"`
+}
+
+// httpRoutePathModifier replaces the request path.
+type httpRoutePathModifier struct {
+	Type               string `json:"type"`
+	ReplaceFullPath    string `json:"replaceFullPath,omitempty"`
+	ReplacePrefixMatch string `json:"replacePrefixMatch,omitempty"`
+}
+
+// WriteHTTPRoute writes a Kubernetes Gateway API HTTPRoute implementing
+// rules as JSON, which kubectl accepts as well as YAML. Static sources match
+// exactly and wildcards by prefix, which may only be replaced by a prefix
+// destination ending with :splat. Redirects use the RequestRedirect filter,
+// limited to 301 and 302, and rewrites the URLRewrite filter to the backend
+// service. Method, header and fixed query param conditions are supported,
+// while other rules are skipped and reported.
+//
+// The Gateway API orders matches by specificity rather than by position,
+// so review rules which overlap.
+func WriteHTTPRoute(w io.Writer, rules []Rule, opts HTTPRouteOptions) ([]Unsupported, error) {
+	route := httpRoute{
+		APIVersion: "gateway.networking.k8s.io/v1",
+		Kind:       "HTTPRoute",
+		Metadata: httpRouteMetadata{
+			Name:      opts.Name,
+			Namespace: opts.Namespace,
+		},
+		Spec: httpRouteSpec{
+			ParentRefs: []httpRouteRef{{Name: opts.Gateway}},
+			Rules:      []httpRouteRule{},
+		},
+	}
+
+	var skipped []Unsupported
+
+	for i, r := range rules {
+		v, reason := gatewayRule(r, opts)
+		if reason != "" {
+			skipped = append(skipped, Unsupported{Index: i, Rule: r, Reason: reason})
+			continue
+		}
+
+		route.Spec.Rules = append(route.Spec.Rules, v)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return skipped, enc.Encode(route)
+}
+
+// gatewayRule returns the HTTPRoute rule of r, or the reason it is unsupported.
+func gatewayRule(r Rule, opts HTTPRouteOptions) (v httpRouteRule, reason string) {
+	switch {
+	case isExclusion(r.From):
+		return v, "exclusion rules are not supported"
+	case isRegexp(r.From):
+		return v, "regular expression sources are not supported"
+	case extendedWildcard(r.From):
+		return v, "double and mid-path wildcards are not supported"
+	case !strings.HasPrefix(r.From, "/"):
+		return v, "absolute source URLs are not supported"
+	case len(r.Country) > 0 || len(r.Language) > 0 || len(r.Host) > 0:
+		return v, "Country, Language and Host conditions are not supported"
+	case !r.ActiveFrom.IsZero() || !r.ActiveUntil.IsZero():
+		return v, "scheduled rules are not supported"
+	case r.IsRewrite() && r.IsProxy():
+		return v, "proxies are not supported"
+	case r.IsContent() && !r.IsRewrite():
+		return v, "content responses are not supported"
+	case !r.IsContent() && r.Status != 301 && r.Status != 302:
+		return v, "only 301 and 302 redirects are supported"
+	}
+
+	to, err := url.Parse(r.To)
+	if err != nil {
+		return v, "invalid destination"
+	}
+
+	if to.RawQuery != "" {
+		return v, "destination query strings are not supported"
+	}
+
+	from := r.From
+	wildcard := strings.HasSuffix(from, "*")
+	path := to.Path
+
+	if wildcard {
+		if !strings.HasSuffix(path, ":splat") {
+			return v, "wildcards must lead to a destination ending with :splat"
+		}
+		from = strings.TrimSuffix(from, "*")
+		path = strings.TrimSuffix(path, ":splat")
+	}
+
+	if placeholder.MatchString(from) || placeholder.MatchString(path) {
+		return v, "placeholders are not supported"
+	}
+
+	var match httpRouteMatch
+	var modifier *httpRoutePathModifier
+
+	if wildcard {
+		match.Path = httpRoutePath{Type: "PathPrefix", Value: trimSlash(from)}
+		modifier = &httpRoutePathModifier{Type: "ReplacePrefixMatch", ReplacePrefixMatch: trimSlash(path)}
+	} else {
+		if path == "" {
+			path = "/"
+		}
+		match.Path = httpRoutePath{Type: "Exact", Value: trimSlash(from)}
+		modifier = &httpRoutePathModifier{Type: "ReplaceFullPath", ReplaceFullPath: path}
+	}
+
+	for _, k := range r.Params.keys() {
+		value, ok := r.Params[k].(string)
+		if !ok || strings.HasPrefix(value, ":") {
+			return v, "only fixed query param values are supported"
+		}
+		match.QueryParams = append(match.QueryParams, httpRouteNameVal{Name: k, Value: value})
+	}
+
+	for _, k := range r.Conditions.keys() {
+		match.Headers = append(match.Headers, httpRouteNameVal{Name: headerCondition(k), Value: r.Conditions[k]})
+	}
+
+	paths := []httpRoutePath{match.Path}
+	if !wildcard && match.Path.Value != "/" {
+		paths = append(paths, httpRoutePath{Type: "Exact", Value: match.Path.Value + "/"})
+	}
+
+	methods := []string{""}
+	if len(r.Method) > 0 {
+		methods = r.Method
+	}
+
+	for _, p := range paths {
+		for _, m := range methods {
+			match.Path = p
+			match.Method = strings.ToUpper(m)
+			v.Matches = append(v.Matches, match)
+		}
+	}
+
+	if r.IsRewrite() {
+		v.Filters = []httpRouteFilter{{
+			Type:       "URLRewrite",
+			URLRewrite: &httpRouteURLRewrite{Path: modifier},
+		}}
+		v.BackendRefs = []httpRouteRef{{Name: opts.Service, Port: opts.Port}}
+		return v, ""
+	}
+
+	v.Filters = []httpRouteFilter{{
+		Type: "RequestRedirect",
+		RequestRedirect: &httpRouteRequestRedirect{
+			Scheme:     to.Scheme,
+			Hostname:   to.Hostname(),
+			Path:       modifier,
+			StatusCode: r.Status,
+		},
+	}}
+
+	return v, ""
+}
+
+// trimSlash returns p without a trailing slash, or "/" for the root path.
+func trimSlash(p string) string {
+	if p = strings.TrimSuffix(p, "/"); p == "" {
+		return "/"
+	}
+
+	return p
+}
@@ -0,0 +1,71 @@
+package redirects_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+// httpRoute returns the compact HTTPRoute JSON of rules.
+func httpRoute(t testing.TB, rules []redirects.Rule) (string, []redirects.Unsupported) {
+	var b strings.Builder
+	skipped, err := redirects.WriteHTTPRoute(&b, rules, redirects.HTTPRouteOptions{
+		Name:    "redirects",
+		Gateway: "web",
+		Service: "site",
+		Port:    8080,
+	})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, json.Compact(&buf, []byte(b.String())))
+	return buf.String(), skipped
+}
+
+func TestWriteHTTPRoute(t *testing.T) {
+	t.Run("redirects", func(t *testing.T) {
+		js, skipped := httpRoute(t, redirects.Must(redirects.ParseString(`
+			/home     /
+			/docs/*   https://docs.example.com/:splat  302
+			/beta     /new  302  Method=GET,HEAD  Header:X-Beta=1
+		`)))
+
+		assert.Empty(t, skipped)
+		assert.Contains(t, js, `{"apiVersion":"gateway.networking.k8s.io/v1","kind":"HTTPRoute","metadata":{"name":"redirects"},"spec":{"parentRefs":[{"name":"web"}]`)
+		assert.Contains(t, js, `{"matches":[{"path":{"type":"Exact","value":"/home"}},{"path":{"type":"Exact","value":"/home/"}}],"filters":[{"type":"RequestRedirect","requestRedirect":{"path":{"type":"ReplaceFullPath","replaceFullPath":"/"},"statusCode":301}}]}`)
+		assert.Contains(t, js, `{"matches":[{"path":{"type":"PathPrefix","value":"/docs"}}],"filters":[{"type":"RequestRedirect","requestRedirect":{"scheme":"https","hostname":"docs.example.com","path":{"type":"ReplacePrefixMatch","replacePrefixMatch":"/"},"statusCode":302}}]}`)
+		assert.Contains(t, js, `{"path":{"type":"Exact","value":"/beta/"},"method":"HEAD","headers":[{"name":"X-Beta","value":"1"}]}`)
+	})
+
+	t.Run("rewrite", func(t *testing.T) {
+		js, skipped := httpRoute(t, redirects.Must(redirects.ParseString(`/app/* /spa/:splat 200`)))
+
+		assert.Empty(t, skipped)
+		assert.Contains(t, js, `{"matches":[{"path":{"type":"PathPrefix","value":"/app"}}],"filters":[{"type":"URLRewrite","urlRewrite":{"path":{"type":"ReplacePrefixMatch","replacePrefixMatch":"/spa"}}}],"backendRefs":[{"name":"site","port":8080}]}`)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, skipped := httpRoute(t, redirects.Must(redirects.ParseString(`
+			/app/*       /index.html  200
+			/blog/:slug  /posts/:slug
+			/temp        /elsewhere  307
+			/            /anz  302  Country=au
+		`)))
+
+		var reasons []string
+		for _, s := range skipped {
+			reasons = append(reasons, s.String())
+		}
+
+		assert.Equal(t, []string{
+			"rule 0 (/app/*): wildcards must lead to a destination ending with :splat",
+			"rule 1 (/blog/:slug): placeholders are not supported",
+			"rule 2 (/temp): only 301 and 302 redirects are supported",
+			"rule 3 (/): Country, Language and Host conditions are not supported",
+		}, reasons)
+	})
+}
@@ -0,0 +1,349 @@
+package redirects
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A Mapping is an old URL along with the new URL its content moved to, as
+// collected during a site migration.
+type Mapping struct {
+	// From is the old URL or path.
+	From string
+
+	// To is the new URL or path.
+	To string
+}
+
+// ReadMappings returns the mappings of a CSV file whose first two columns
+// are the old and new URL of each row. A first row which is not a pair of
+// URLs or paths, such as "old,new", is skipped as a header.
+func ReadMappings(r io.Reader) (mappings []Mapping, err error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	for row := 1; ; row++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "row %d", row)
+		}
+
+		if len(record) < 2 {
+			return nil, errors.Errorf("row %d: expected old and new URL columns", row)
+		}
+
+		m := Mapping{From: strings.TrimSpace(record[0]), To: strings.TrimSpace(record[1])}
+		if row == 1 && (!isURL(m.From) || !isURL(m.To)) {
+			continue
+		}
+
+		mappings = append(mappings, m)
+	}
+
+	return
+}
+
+// isURL returns true if s is a path or absolute URL.
+func isURL(s string) bool {
+	if strings.HasPrefix(s, "/") {
+		return true
+	}
+
+	u, err := url.Parse(s)
+	return err == nil && u.Host != ""
+}
+
+// sitemap is a sitemaps.org URL set.
+type sitemap struct {
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+}
+
+// readSitemap returns the URLs of a sitemap.
+func readSitemap(r io.Reader) (urls []*url.URL, err error) {
+	var s sitemap
+	if err := xml.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+
+	for _, l := range s.URLs {
+		u, err := url.Parse(strings.TrimSpace(l.Loc))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid location %q", l.Loc)
+		}
+		urls = append(urls, u)
+	}
+
+	return
+}
+
+// minSlugSimilarity is the similarity of slugs from which sitemap URLs are
+// considered the same content.
+const minSlugSimilarity = 0.6
+
+// MatchSitemaps returns mappings from the URLs of the old sitemap which are
+// missing from the new sitemap to the new URL with the most similar slug,
+// being the last path segment without its extension. New URLs are mapped to
+// as paths when they share the host of the old URL. Old URLs without a new
+// URL whose slug is similar enough are returned as unmatched, to be mapped
+// by hand.
+func MatchSitemaps(old, new io.Reader) (mappings []Mapping, unmatched []string, err error) {
+	olds, err := readSitemap(old)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "reading old sitemap")
+	}
+
+	news, err := readSitemap(new)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "reading new sitemap")
+	}
+
+	existing := make(map[string]bool, len(news))
+	for _, u := range news {
+		existing[u.Host+cleanPath(u.Path)] = true
+	}
+
+	for _, o := range olds {
+		if existing[o.Host+cleanPath(o.Path)] {
+			continue
+		}
+
+		var best *url.URL
+		var bestScore float64
+
+		for _, n := range news {
+			score := similarity(slug(o.Path), slug(n.Path))
+			if score > bestScore || (score == bestScore && best != nil && sharedSegments(o.Path, n.Path) > sharedSegments(o.Path, best.Path)) {
+				best, bestScore = n, score
+			}
+		}
+
+		if best == nil || bestScore < minSlugSimilarity {
+			unmatched = append(unmatched, o.String())
+			continue
+		}
+
+		to := best.String()
+		if best.Host == o.Host {
+			to = best.RequestURI()
+		}
+
+		mappings = append(mappings, Mapping{From: o.RequestURI(), To: to})
+	}
+
+	return
+}
+
+// cleanPath returns p without a trailing slash, other than the root.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+
+	if len(p) > 1 {
+		p = strings.TrimSuffix(p, "/")
+	}
+
+	return p
+}
+
+// slug returns the last segment of p without its extension, in lowercase.
+func slug(p string) string {
+	base := path.Base(cleanPath(p))
+	return strings.ToLower(strings.TrimSuffix(base, path.Ext(base)))
+}
+
+// sharedSegments returns the number of leading segments a and b share.
+func sharedSegments(a, b string) (n int) {
+	as, bs := segments(a), segments(b)
+	for n < len(as) && n < len(bs) && as[n] == bs[n] {
+		n++
+	}
+	return
+}
+
+// similarity returns how similar a and b are from 0 to 1, as one minus
+// their edit distance relative to the length of the longer.
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+
+	return 1 - float64(editDistance(a, b))/float64(n)
+}
+
+// editDistance returns the Levenshtein distance of a and b in bytes.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+// min3 returns the smallest of a, b and c.
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// Generate returns 301 redirects for mappings, without duplicates and with
+// chains collapsed as with Optimize. Mappings whose old path is under a
+// common prefix, such as /blog/a → /posts/a and /blog/b → /posts/b, are
+// collapsed into a wildcard rule such as /blog/* → /posts/:splat when every
+// mapping under the prefix follows it and the destination is outside it.
+// Old URLs are matched by path and query, while a new URL sharing the host
+// of the old URL becomes a path. Mappings to themselves are dropped.
+func Generate(mappings []Mapping) ([]Rule, error) {
+	var exact []Rule
+
+	for _, m := range mappings {
+		from, err := url.Parse(m.From)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid old URL %q", m.From)
+		}
+
+		to, err := url.Parse(m.To)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid new URL %q", m.To)
+		}
+
+		r := Rule{From: cleanPath(from.Path), To: m.To, Status: 301}
+		if to.Host == from.Host {
+			r.To = to.RequestURI()
+		}
+
+		if q := from.Query(); len(q) > 0 {
+			r.Params = make(Params, len(q))
+			for k, v := range q {
+				r.Params[k] = v[0]
+			}
+		}
+
+		if len(r.Params) == 0 && r.From == cleanPath(r.To) {
+			continue
+		}
+
+		exact = append(exact, r)
+	}
+
+	sort.SliceStable(exact, func(i, j int) bool {
+		return exact[i].From < exact[j].From
+	})
+
+	chosen := make(map[string]string)
+	for _, r := range exact {
+		for _, p := range collapsible(r) {
+			if collapses(exact, p[0], p[1]) {
+				chosen[p[0]] = p[1]
+				break
+			}
+		}
+	}
+
+	var rules, wildcards []Rule
+	for _, r := range exact {
+		if !coveredBy(r, chosen) {
+			rules = append(rules, r)
+		}
+	}
+
+	for from, to := range chosen {
+		wildcards = append(wildcards, Rule{From: from + "/*", To: to + "/:splat", Status: 301})
+	}
+
+	sort.Slice(wildcards, func(i, j int) bool {
+		return wildcards[i].From < wildcards[j].From
+	})
+
+	return Optimize(append(rules, wildcards...)), nil
+}
+
+// collapsible returns the source and destination prefixes r could be
+// collapsed under, most general first.
+func collapsible(r Rule) (prefixes [][2]string) {
+	if len(r.Params) > 0 || strings.ContainsAny(r.To, "?#") {
+		return
+	}
+
+	segs := segments(r.From)
+	for k := 1; k < len(segs); k++ {
+		suffix := "/" + strings.Join(segs[k:], "/")
+		if strings.HasSuffix(r.To, suffix) {
+			prefixes = append(prefixes, [2]string{"/" + strings.Join(segs[:k], "/"), strings.TrimSuffix(r.To, suffix)})
+		}
+	}
+
+	return
+}
+
+// collapses returns true if the rules under the source prefix from are at
+// least two, and all lead to the same path under the destination prefix to,
+// which is outside of from.
+func collapses(rules []Rule, from, to string) bool {
+	if strings.HasPrefix(to+"/", from+"/") {
+		return false
+	}
+
+	n := 0
+	for _, r := range rules {
+		if !strings.HasPrefix(r.From, from+"/") {
+			continue
+		}
+
+		if len(r.Params) > 0 || r.To != to+strings.TrimPrefix(r.From, from) {
+			return false
+		}
+		n++
+	}
+
+	return n >= 2
+}
+
+// coveredBy returns true if r is under one of the collapsed source prefixes.
+func coveredBy(r Rule, collapsed map[string]string) bool {
+	for from := range collapsed {
+		if strings.HasPrefix(r.From, from+"/") {
+			return true
+		}
+	}
+
+	return false
+}
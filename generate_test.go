@@ -0,0 +1,114 @@
+package redirects_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestReadMappings(t *testing.T) {
+	t.Run("header", func(t *testing.T) {
+		m, err := redirects.ReadMappings(strings.NewReader("old,new\n/a,/b\nhttps://example.com/c,https://example.com/d\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, []redirects.Mapping{
+			{From: "/a", To: "/b"},
+			{From: "https://example.com/c", To: "https://example.com/d"},
+		}, m)
+	})
+
+	t.Run("no header", func(t *testing.T) {
+		m, err := redirects.ReadMappings(strings.NewReader("/a,/b\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, []redirects.Mapping{{From: "/a", To: "/b"}}, m)
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		_, err := redirects.ReadMappings(strings.NewReader("/a,/b\n/c\n"))
+		assert.EqualError(t, err, "row 2: expected old and new URL columns")
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	generate := func(t *testing.T, mappings ...redirects.Mapping) string {
+		rules, err := redirects.Generate(mappings)
+		assert.NoError(t, err)
+		return string(redirects.Marshal(rules))
+	}
+
+	t.Run("collapse", func(t *testing.T) {
+		assert.Equal(t, "/blog /posts 301\n/blog/* /posts/:splat 301\n", generate(t,
+			redirects.Mapping{From: "https://example.com/blog/2020/a", To: "https://example.com/posts/2020/a"},
+			redirects.Mapping{From: "/blog/2021/b/", To: "/posts/2021/b"},
+			redirects.Mapping{From: "/blog/c", To: "/posts/c"},
+			redirects.Mapping{From: "/blog", To: "/posts"},
+		))
+	})
+
+	t.Run("unsafe", func(t *testing.T) {
+		assert.Equal(t, "/blog/c /archive/c 301\n/blog/2020/* /posts/2020/:splat 301\n", generate(t,
+			redirects.Mapping{From: "/blog/2020/a", To: "/posts/2020/a"},
+			redirects.Mapping{From: "/blog/2020/b", To: "/posts/2020/b"},
+			redirects.Mapping{From: "/blog/c", To: "/archive/c"},
+		))
+	})
+
+	t.Run("destination under source", func(t *testing.T) {
+		assert.Equal(t, "/docs/a /docs/v2/a 301\n/docs/b /docs/v2/b 301\n", generate(t,
+			redirects.Mapping{From: "/docs/a", To: "/docs/v2/a"},
+			redirects.Mapping{From: "/docs/b", To: "/docs/v2/b"},
+		))
+	})
+
+	t.Run("query and hosts", func(t *testing.T) {
+		assert.Equal(t, "/old https://new.example.com/old 301\n/post id=1 /posts/1 301\n", generate(t,
+			redirects.Mapping{From: "/post?id=1", To: "/posts/1"},
+			redirects.Mapping{From: "https://example.com/old", To: "https://new.example.com/old"},
+			redirects.Mapping{From: "/same/", To: "/same"},
+		))
+	})
+
+	t.Run("chains", func(t *testing.T) {
+		assert.Equal(t, "/a /c 301\n/b /c 301\n", generate(t,
+			redirects.Mapping{From: "/a", To: "/b"},
+			redirects.Mapping{From: "/b", To: "/c"},
+		))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := redirects.Generate([]redirects.Mapping{{From: "%", To: "/b"}})
+		assert.Contains(t, err.Error(), `invalid old URL "%"`)
+	})
+}
+
+func TestMatchSitemaps(t *testing.T) {
+	old := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/</loc></url>
+  <url><loc>https://example.com/blog/hello-world.html</loc></url>
+  <url><loc>https://example.com/blog/2019/goodbye</loc></url>
+  <url><loc>https://example.com/about-us</loc></url>
+  <url><loc>https://example.com/careers</loc></url>
+  <url><loc>https://example.com/team</loc></url>
+</urlset>`
+
+	new := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/</loc></url>
+  <url><loc>https://example.com/posts/hello-world/</loc></url>
+  <url><loc>https://example.com/posts/good-bye</loc></url>
+  <url><loc>https://example.com/about</loc></url>
+  <url><loc>https://jobs.example.com/careers</loc></url>
+</urlset>`
+
+	mappings, unmatched, err := redirects.MatchSitemaps(strings.NewReader(old), strings.NewReader(new))
+	assert.NoError(t, err)
+	assert.Equal(t, []redirects.Mapping{
+		{From: "/blog/hello-world.html", To: "/posts/hello-world/"},
+		{From: "/blog/2019/goodbye", To: "/posts/good-bye"},
+		{From: "/about-us", To: "/about"},
+		{From: "/careers", To: "https://jobs.example.com/careers"},
+	}, mappings)
+	assert.Equal(t, []string{"https://example.com/team"}, unmatched)
+}
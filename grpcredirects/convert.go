@@ -0,0 +1,90 @@
+package grpcredirects
+
+import (
+	"sort"
+	"time"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/fission-suite/go-redirects/grpcredirects/redirectspb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ToProto returns the message of a rule.
+func ToProto(r redirects.Rule) *redirectspb.Rule {
+	m := &redirectspb.Rule{
+		From:        r.From,
+		To:          r.To,
+		Status:      int32(r.Status),
+		Force:       r.Force,
+		Country:     r.Country,
+		Language:    r.Language,
+		Method:      r.Method,
+		Host:        r.Host,
+		Conditions:  r.Conditions,
+		ActiveFrom:  timestamp(r.ActiveFrom),
+		ActiveUntil: timestamp(r.ActiveUntil),
+		Meta:        r.Meta,
+	}
+
+	keys := make([]string, 0, len(r.Params))
+	for k := range r.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		p := &redirectspb.Param{Key: k}
+		if v, ok := r.Params[k].(string); ok {
+			p.Value = &v
+		}
+		m.Params = append(m.Params, p)
+	}
+
+	return m
+}
+
+// FromProto returns the rule of a message.
+func FromProto(m *redirectspb.Rule) redirects.Rule {
+	r := redirects.Rule{
+		From:       m.From,
+		To:         m.To,
+		Status:     int(m.Status),
+		Force:      m.Force,
+		Country:    m.Country,
+		Language:   m.Language,
+		Method:     m.Method,
+		Host:       m.Host,
+		Conditions: m.Conditions,
+		Meta:       m.Meta,
+	}
+
+	if len(m.Params) > 0 {
+		r.Params = make(redirects.Params, len(m.Params))
+		for _, p := range m.Params {
+			if p.Value != nil {
+				r.Params[p.Key] = *p.Value
+			} else {
+				r.Params[p.Key] = true
+			}
+		}
+	}
+
+	if m.ActiveFrom != nil {
+		r.ActiveFrom = m.ActiveFrom.AsTime()
+	}
+
+	if m.ActiveUntil != nil {
+		r.ActiveUntil = m.ActiveUntil.AsTime()
+	}
+
+	return r
+}
+
+// timestamp returns the message of t, or nil when it is zero.
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
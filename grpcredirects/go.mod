@@ -0,0 +1,25 @@
+module github.com/fission-suite/go-redirects/grpcredirects
+
+go 1.18
+
+require (
+	github.com/fission-suite/go-redirects v0.0.0
+	github.com/tj/assert v0.0.3
+	google.golang.org/grpc v1.52.3
+	google.golang.org/protobuf v1.28.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/testify v1.6.1 // indirect
+	golang.org/x/net v0.7.0 // indirect
+	golang.org/x/sys v0.5.0 // indirect
+	golang.org/x/text v0.7.0 // indirect
+	google.golang.org/genproto v0.0.0-20230202175211-008b39050e57 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200605160147-a5ece683394c // indirect
+)
+
+replace github.com/fission-suite/go-redirects => ../
@@ -0,0 +1,5 @@
+// Package redirectspb holds the protocol buffer messages and gRPC service
+// generated from redirects.proto.
+package redirectspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative redirects.proto
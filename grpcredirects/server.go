@@ -0,0 +1,105 @@
+// Package grpcredirects serves compiled rules of
+// github.com/fission-suite/go-redirects over gRPC, so edge nodes written in
+// other languages can list, match and stream them. The service is defined
+// in redirectspb/redirects.proto.
+package grpcredirects
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/fission-suite/go-redirects/grpcredirects/redirectspb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server implements the RedirectsService for compiled rules, streaming
+// them again each time they are reloaded.
+type Server struct {
+	redirectspb.UnimplementedRedirectsServiceServer
+
+	// Rules are the served rules.
+	Rules *redirects.CompiledRules
+}
+
+// ListRules implementation.
+func (s *Server) ListRules(ctx context.Context, req *redirectspb.ListRulesRequest) (*redirectspb.RuleSet, error) {
+	return ruleSet(s.Rules.Snapshot()), nil
+}
+
+// Match implementation.
+func (s *Server) Match(ctx context.Context, req *redirectspb.MatchRequest) (*redirectspb.MatchResponse, error) {
+	query, err := url.ParseQuery(req.Query)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "parsing query: %s", err)
+	}
+
+	header := make(http.Header, len(req.Headers))
+	for k, v := range req.Headers {
+		header.Set(k, v)
+	}
+
+	r := redirects.Request{
+		Host:     req.Host,
+		Method:   req.Method,
+		Path:     req.Path,
+		Query:    query,
+		Header:   header,
+		Country:  req.Country,
+		Language: req.Language,
+	}
+
+	if req.Time != nil {
+		r.Time = req.Time.AsTime()
+	}
+
+	res, ok := s.Rules.Match(r)
+	if !ok {
+		return &redirectspb.MatchResponse{}, nil
+	}
+
+	return &redirectspb.MatchResponse{
+		Matched:    true,
+		Index:      int32(res.Index),
+		Rule:       ToProto(*res.Rule),
+		To:         res.To,
+		Captures:   res.Captures.Map(),
+		Conditions: res.Conditions,
+		Force:      res.Force,
+	}, nil
+}
+
+// StreamUpdates implementation.
+func (s *Server) StreamUpdates(req *redirectspb.StreamUpdatesRequest, stream redirectspb.RedirectsService_StreamUpdatesServer) error {
+	for {
+		changed := s.Rules.Changed()
+
+		if err := stream.Send(ruleSet(s.Rules.Snapshot())); err != nil {
+			return err
+		}
+
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// ruleSet returns the rule set message of a snapshot.
+func ruleSet(s redirects.Snapshot) *redirectspb.RuleSet {
+	set := &redirectspb.RuleSet{
+		Rules:  make([]*redirectspb.Rule, len(s.Rules)),
+		Hash:   s.Hash,
+		Loaded: timestamppb.New(s.Loaded),
+	}
+
+	for i, r := range s.Rules {
+		set.Rules[i] = ToProto(r)
+	}
+
+	return set
+}
@@ -0,0 +1,96 @@
+package grpcredirects_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/fission-suite/go-redirects/grpcredirects"
+	"github.com/fission-suite/go-redirects/grpcredirects/redirectspb"
+	"github.com/tj/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestServer(t *testing.T) {
+	compiled := redirects.Compile(redirects.Must(redirects.ParseString(`
+		/store id=:id  /blog/:id  301
+		/news/*        /blog/:splat  301
+		/api/*         https://api.example.com/:splat  200
+	`)))
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	redirectspb.RegisterRedirectsServiceServer(s, &grpcredirects.Server{Rules: compiled})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	client := redirectspb.NewRedirectsServiceClient(conn)
+	ctx := context.Background()
+
+	t.Run("list rules", func(t *testing.T) {
+		set, err := client.ListRules(ctx, &redirectspb.ListRulesRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, set.Rules, 3)
+		assert.Equal(t, compiled.Snapshot().Hash, set.Hash)
+
+		for i, r := range compiled.Rules() {
+			assert.Equal(t, r, grpcredirects.FromProto(set.Rules[i]))
+		}
+	})
+
+	t.Run("match", func(t *testing.T) {
+		res, err := client.Match(ctx, &redirectspb.MatchRequest{Path: "/news/hello"})
+		assert.NoError(t, err)
+		assert.True(t, res.Matched)
+		assert.Equal(t, int32(1), res.Index)
+		assert.Equal(t, "/blog/hello", res.To)
+		assert.Equal(t, "hello", res.Captures["splat"])
+
+		res, err = client.Match(ctx, &redirectspb.MatchRequest{Path: "/store", Query: "id=5"})
+		assert.NoError(t, err)
+		assert.Equal(t, "/blog/5", res.To)
+
+		res, err = client.Match(ctx, &redirectspb.MatchRequest{Path: "/about"})
+		assert.NoError(t, err)
+		assert.False(t, res.Matched)
+	})
+
+	t.Run("stream updates", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		stream, err := client.StreamUpdates(ctx, &redirectspb.StreamUpdatesRequest{})
+		assert.NoError(t, err)
+
+		set, err := stream.Recv()
+		assert.NoError(t, err)
+		assert.Len(t, set.Rules, 3)
+
+		compiled.Reload(redirects.Must(redirects.ParseString(`/home  /`)))
+
+		set, err = stream.Recv()
+		assert.NoError(t, err)
+		assert.Len(t, set.Rules, 1)
+		assert.Equal(t, "/home", set.Rules[0].From)
+	})
+}
+
+func TestFromProto(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/store id=:id  /blog/:id  301  Country=us,ca Until=2030-01-01
+	`))
+
+	// params only present are not written in the file format
+	rules[0].Params["preview"] = true
+
+	assert.Equal(t, rules[0], grpcredirects.FromProto(grpcredirects.ToProto(rules[0])))
+}
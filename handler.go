@@ -0,0 +1,488 @@
+package redirects
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ForwardedPolicy is which X-Forwarded headers are sent to proxy upstreams.
+type ForwardedPolicy int
+
+// Forwarded header policies.
+const (
+	// ForwardedFor appends the client address to X-Forwarded-For, the default.
+	ForwardedFor ForwardedPolicy = iota
+
+	// ForwardedAll also sets X-Forwarded-Proto and X-Forwarded-Host to the
+	// scheme and host of the original request, as Netlify's proxy does.
+	ForwardedAll
+
+	// ForwardedNone sends no X-Forwarded headers, removing the client's.
+	ForwardedNone
+)
+
+// A Handler serves requests according to rules, redirecting, rewriting
+// or proxying the first rule matching each request. Requests matching
+// no rule, and rewrites, are served by Next.
+//
+// Content responses, such as 404, 410 and 451, serve the destination from
+// Next with the rule's status, or an empty body when Next has none unless
+// ErrorTemplate is set.
+//
+// Redirects respond with the rule's status, so clients keep the method and
+// body of 307 and 308 redirects and may switch to GET for 301, 302 and 303.
+// Rewrites and proxies forward the method and body unchanged.
+//
+// Rules are applied whether or not content exists at the requested path,
+// as though every rule were forced, unless Content is set.
+type Handler struct {
+	// Rules are matched in order.
+	Rules []Rule
+
+	// Compiled, when set, is matched instead of Rules, so rules reloaded
+	// at runtime, such as by an AdminHandler, take effect.
+	Compiled *CompiledRules
+
+	// Matcher, when set, is matched instead of Compiled and Rules, for
+	// custom matching. Responses vary by the request headers of the
+	// conditions of the matched rule only, unless it has a Rules method
+	// returning every rule as CompiledRules does.
+	Matcher Matcher
+
+	// Content, when set, reports whether content exists at the requested
+	// path, in which case only forced rules apply, as on Netlify. Matchers
+	// without a Rules method, see Matcher, then apply only when the rule
+	// they match is forced.
+	Content ShadowChecker
+
+	// Next serves unmatched requests and the destination of rewrites,
+	// typically a static file server. Defaults to http.NotFoundHandler.
+	Next http.Handler
+
+	// AllowedProxyHosts restricts the hosts proxy rules may forward to,
+	// responding with 502 Bad Gateway for any other host. Hosts may use
+	// a leading wildcard such as "*.example.com". Empty allows all hosts.
+	AllowedProxyHosts []string
+
+	// Country returns the visitor's ISO 3166-1 alpha-2 country code, used by
+	// rules with Country conditions. Defaults to an unknown country.
+	Country func(*http.Request) string
+
+	// Clock is consulted by rules active for a period of time.
+	// Defaults to the system clock.
+	Clock Clock
+
+	// PreserveHost forwards the Host header of the original request to proxy
+	// upstreams rather than the host of the destination.
+	PreserveHost bool
+
+	// Forwarded is which X-Forwarded headers are sent to proxy upstreams.
+	// Hop-by-hop headers, such as Connection and Keep-Alive, are always
+	// removed.
+	Forwarded ForwardedPolicy
+
+	// Upstreams configures retries, a circuit breaker and a fallback for
+	// proxy upstreams by host, including any port, such as "api.example.com".
+	Upstreams map[string]UpstreamPolicy
+
+	// upstreams is the circuit breaker state of each upstream by host.
+	upstreams sync.Map
+
+	// limiters are the limiters of upstreams and rules, see Limits.
+	limiters sync.Map
+
+	// CacheControl is the Cache-Control header of redirect and content
+	// responses by status, such as "public, max-age=86400" for 301 and
+	// "no-cache" for 302, after StatusOverrides. Statuses without an entry
+	// have no header.
+	CacheControl map[int]string
+
+	// StatusOverrides remaps the status of rules when serving them, such as
+	// 301 to 308 and 302 to 307 to standardize on method-preserving
+	// redirects, without editing the rules. Redirects are only remapped to
+	// redirect statuses, and content responses to content statuses.
+	StatusOverrides map[int]int
+
+	// RedirectTemplate, when set, renders the body of redirects to GET
+	// requests with TemplateData, such as a page linking to the destination
+	// for clients not following redirects, in place of a bare link.
+	RedirectTemplate Template
+
+	// ErrorTemplate, when set, renders the body of content rules with an
+	// error status, such as 404, 410 and 451, with TemplateData when their
+	// destination is missing from Next, in place of an empty body.
+	ErrorTemplate Template
+
+	// CountryHeader is the request header Country is derived from, such as
+	// "CloudFront-Viewer-Country", which is listed in the Vary header of
+	// responses depending on Country conditions.
+	CountryHeader string
+
+	// Overrides, when set, lets query parameters override the visitor's
+	// country and languages for testing. Visitors may then bypass Country
+	// and Language conditions, so leave it unset where they restrict access.
+	Overrides *Overrides
+
+	// Candidate, when set, is a candidate rule set, such as CompiledRules,
+	// matched alongside the active rules for canarying changes. Requests are
+	// always served by the active rules, with OnDivergence called for those
+	// the candidate would serve differently.
+	Candidate Matcher
+
+	// OnDivergence is called with each request the Candidate rules would
+	// serve differently than the active rules. Defaults to logging the divergence
+	// with the standard logger.
+	OnDivergence func(Divergence)
+}
+
+// ServeHTTP implementation.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, h.next())
+}
+
+// Middleware returns next with the rules served in front of it, in place
+// of Next. Its signature suits routers taking net/http middleware, such as
+// chi's Use.
+func (h *Handler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.serve(w, r, next)
+	})
+}
+
+// Middleware returns net/http middleware serving rules in front of the
+// wrapped handler, see Handler.Middleware.
+func Middleware(rules []Rule) func(http.Handler) http.Handler {
+	h := &Handler{Rules: rules}
+	return h.Middleware
+}
+
+// serve serves r according to the rules, or with next when none match.
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	req := NewRequest(r)
+	if h.Country != nil {
+		req.Country = h.Country(r)
+	}
+
+	req.Time = h.clock().Now()
+
+	if h.Overrides != nil {
+		req = h.Overrides.Apply(req)
+	}
+
+	if h.Content != nil {
+		req.FileExists = h.Content.Exists(req.Path)
+	}
+
+	res, ok := h.match(req)
+	if h.Candidate != nil {
+		h.candidate(req, res, ok)
+	}
+
+	if !ok {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	switch {
+	case res.Rule.IsRewrite() && res.Rule.IsProxy():
+		h.proxy(w, r, res.Rule, res.To, next)
+	case res.Rule.IsContent():
+		h.cacheHeaders(w, req, res)
+		status := h.status(res.Rule.status())
+		h.rewrite(w, r, res.To, status, h.errorPage(r, res, status), next)
+	default:
+		h.cacheHeaders(w, req, res)
+		h.redirect(w, r, res, h.status(res.Rule.status()))
+	}
+}
+
+// cacheHeaders sets the Cache-Control header for the status of the matched
+// rule, and the Vary header for the request headers of the conditions of
+// each rule up to it matching the path, and of those after it with the same
+// source, as a request with other headers could match another rule.
+func (h *Handler) cacheHeaders(w http.ResponseWriter, req Request, res Result) {
+	if v, ok := h.CacheControl[h.status(res.Rule.status())]; ok {
+		w.Header().Set("Cache-Control", v)
+	}
+
+	seen := make(map[string]bool)
+	vary := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			w.Header().Add("Vary", name)
+		}
+	}
+
+	rules := h.Rules
+	switch m := h.matcher().(type) {
+	case nil:
+	case interface{ Rules() []Rule }:
+		rules = m.Rules()
+	default:
+		rules = []Rule{*res.Rule}
+		res.Index = 0
+	}
+
+	for i := range rules {
+		r := &rules[i]
+		if i > res.Index && r.From != res.Rule.From || !matchSource(r, req) {
+			continue
+		}
+
+		if len(r.Language) > 0 {
+			vary("Accept-Language")
+		}
+
+		if len(r.Country) > 0 {
+			vary(h.CountryHeader)
+		}
+
+		for _, k := range r.Conditions.keys() {
+			vary(headerCondition(k))
+		}
+	}
+}
+
+// status returns the status served for rules with the given status.
+func (h *Handler) status(code int) int {
+	s, ok := h.StatusOverrides[code]
+	if !ok || isRedirectStatus(s) != isRedirectStatus(code) {
+		return code
+	}
+
+	return s
+}
+
+// isRedirectStatus returns true if code is a redirect status.
+func isRedirectStatus(code int) bool {
+	return code >= 300 && code < 400
+}
+
+// proxy forwards the request to the absolute URL to of the proxy rule.
+// Upgrade requests, such as WebSocket connections, are tunnelled to the
+// upstream, which may be written with a ws or wss scheme.
+func (h *Handler) proxy(w http.ResponseWriter, r *http.Request, rule *Rule, to string, next http.Handler) {
+	target, err := url.Parse(to)
+	if err != nil {
+		http.Error(w, "invalid proxy destination", http.StatusBadGateway)
+		return
+	}
+
+	switch target.Scheme {
+	case "ws":
+		target.Scheme = "http"
+	case "wss":
+		target.Scheme = "https"
+	}
+
+	if len(h.AllowedProxyHosts) > 0 && !hostAllowed(target.Hostname(), h.AllowedProxyHosts) {
+		http.Error(w, "proxy destination not allowed", http.StatusBadGateway)
+		return
+	}
+
+	release, ok := h.limit(w, target.Host, rule)
+	if !ok {
+		return
+	}
+	defer release()
+
+	proxy := &httputil.ReverseProxy{
+		Director: func(out *http.Request) {
+			out.URL = target
+			if !h.PreserveHost {
+				out.Host = target.Host
+			}
+			h.forwarded(out, r)
+		},
+	}
+
+	if policy, ok := h.Upstreams[target.Host]; ok {
+		v, _ := h.upstreams.LoadOrStore(target.Host, &upstream{policy: policy})
+		proxy.Transport = &upstreamTransport{
+			upstream: v.(*upstream),
+			clock:    h.clock(),
+			next:     http.DefaultTransport,
+		}
+		proxy.ErrorHandler = func(w http.ResponseWriter, _ *http.Request, err error) {
+			if policy.Fallback != "" {
+				h.rewrite(w, r, policy.Fallback, http.StatusBadGateway, nil, next)
+				return
+			}
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}
+
+	proxy.ServeHTTP(w, r)
+}
+
+// forwarded sets the X-Forwarded headers of the proxied request out of r.
+func (h *Handler) forwarded(out, r *http.Request) {
+	switch h.Forwarded {
+	case ForwardedAll:
+		proto := "http"
+		if r.TLS != nil {
+			proto = "https"
+		}
+		out.Header.Set("X-Forwarded-Proto", proto)
+		out.Header.Set("X-Forwarded-Host", r.Host)
+	case ForwardedNone:
+		for k := range out.Header {
+			if strings.HasPrefix(k, "X-Forwarded-") {
+				out.Header.Del(k)
+			}
+		}
+		// a nil value stops ReverseProxy adding the header
+		out.Header["X-Forwarded-For"] = nil
+	}
+}
+
+// rewrite serves the path to from next with the given status, or page when
+// not nil and next has no such path. Paths with ".." segments, such as from
+// a splat of "../../etc/passwd", are refused as they may escape the site
+// root of next.
+func (h *Handler) rewrite(w http.ResponseWriter, r *http.Request, to string, status int, page []byte, next http.Handler) {
+	u, err := url.Parse(to)
+	if err != nil {
+		http.Error(w, "invalid rewrite destination", http.StatusInternalServerError)
+		return
+	}
+
+	if traverses(u.Path) {
+		http.Error(w, "rewrite destination escapes the site root", http.StatusBadRequest)
+		return
+	}
+
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = u.Path
+	r2.URL.RawPath = u.RawPath
+	r2.URL.RawQuery = u.RawQuery
+	r2.RequestURI = u.RequestURI()
+
+	if status != http.StatusOK {
+		w = &statusWriter{ResponseWriter: w, status: status, page: page}
+	}
+
+	next.ServeHTTP(w, r2)
+}
+
+// traverses returns true if the path p has a ".." segment, separated by
+// slashes or the backslashes of Windows paths.
+func traverses(p string) bool {
+	for _, s := range strings.FieldsFunc(p, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if s == ".." {
+			return true
+		}
+	}
+
+	return false
+}
+
+// match returns the result of the first rule matching req.
+func (h *Handler) match(req Request) (Result, bool) {
+	return matchContent(h.matcher(), h.Rules, req)
+}
+
+// matchContent returns the result of the first rule of m, or of rules when
+// m is nil, matching req. When content exists at the path only forced rules
+// apply.
+func matchContent(m Matcher, rules []Rule, req Request) (Result, bool) {
+	if !req.FileExists {
+		if m != nil {
+			return m.Match(req)
+		}
+		return Match(rules, req)
+	}
+
+	switch m := m.(type) {
+	case nil:
+		return matchForced(rules, req)
+	case interface{ Rules() []Rule }:
+		return matchForced(m.Rules(), req)
+	default:
+		if res, ok := m.Match(req); ok && res.Force {
+			return res, true
+		}
+		return Result{}, false
+	}
+}
+
+// matcher returns the matcher of the rules, or nil to match Rules.
+func (h *Handler) matcher() Matcher {
+	switch {
+	case h.Matcher != nil:
+		return h.Matcher
+	case h.Compiled != nil:
+		return h.Compiled
+	default:
+		return nil
+	}
+}
+
+// clock returns the clock for scheduled rules.
+func (h *Handler) clock() Clock {
+	if h.Clock == nil {
+		return systemClock{}
+	}
+
+	return h.Clock
+}
+
+// next returns the handler for unmatched requests.
+func (h *Handler) next() http.Handler {
+	if h.Next == nil {
+		return http.NotFoundHandler()
+	}
+
+	return h.Next
+}
+
+// statusWriter replaces the status code of a response. The body of a
+// 404 response is discarded when replaced by another status, so a missing
+// destination yields an empty body, or page when set.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	page   []byte
+	wrote  bool
+	empty  bool
+}
+
+// WriteHeader implementation.
+func (w *statusWriter) WriteHeader(code int) {
+	if w.wrote {
+		return
+	}
+
+	w.wrote = true
+
+	if code == http.StatusNotFound && (w.status != http.StatusNotFound || w.page != nil) {
+		w.empty = true
+		h := w.Header()
+		h.Del("Content-Type")
+		h.Del("Content-Length")
+		h.Del("X-Content-Type-Options")
+
+		if w.page != nil {
+			h.Set("Content-Type", "text/html; charset=utf-8")
+			w.ResponseWriter.WriteHeader(w.status)
+			w.ResponseWriter.Write(w.page)
+			return
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// Write implementation.
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+
+	if w.empty {
+		return len(b), nil
+	}
+
+	return w.ResponseWriter.Write(b)
+}
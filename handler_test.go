@@ -0,0 +1,676 @@
+package redirects_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+// files is a Next handler echoing the path it serves.
+var files = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "file %s", r.URL.RequestURI())
+})
+
+func TestHandler(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "upstream %s %s", r.Host, r.URL.RequestURI())
+	}))
+	defer upstream.Close()
+
+	h := &redirects.Handler{
+		Rules: redirects.Must(redirects.ParseString(fmt.Sprintf(`
+			/home           /
+			/temp           /elsewhere  302
+			/google         https://www.google.com
+			/blog/:slug     /posts/:slug
+			/app/*          /app/index.html  200
+			/closed         /404.html  404
+			/removed        /gone.html  410
+			/blocked        /missing.html  451
+			/api/*          %s/v1/:splat  200
+			/               /anz  302  Country=au
+		`, upstream.URL))),
+		Next: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/missing.html" {
+				http.NotFound(w, r)
+				return
+			}
+			files.ServeHTTP(w, r)
+		}),
+		Country: func(r *http.Request) string {
+			return r.Header.Get("X-Country")
+		},
+	}
+
+	serve := func(path string, header ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		for i := 0; i < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("redirect", func(t *testing.T) {
+		w := serve("/home")
+		assert.Equal(t, 301, w.Code)
+		assert.Equal(t, "/", w.Header().Get("Location"))
+	})
+
+	t.Run("redirect with status", func(t *testing.T) {
+		w := serve("/temp")
+		assert.Equal(t, 302, w.Code)
+		assert.Equal(t, "/elsewhere", w.Header().Get("Location"))
+	})
+
+	t.Run("redirect to absolute URL", func(t *testing.T) {
+		w := serve("/google")
+		assert.Equal(t, 301, w.Code)
+		assert.Equal(t, "https://www.google.com", w.Header().Get("Location"))
+	})
+
+	t.Run("redirect with placeholders and query", func(t *testing.T) {
+		w := serve("/blog/hello?ref=home")
+		assert.Equal(t, 301, w.Code)
+		assert.Equal(t, "/posts/hello?ref=home", w.Header().Get("Location"))
+	})
+
+	t.Run("rewrite", func(t *testing.T) {
+		w := serve("/app/settings")
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "file /app/index.html", w.Body.String())
+	})
+
+	t.Run("custom status", func(t *testing.T) {
+		w := serve("/closed")
+		assert.Equal(t, 404, w.Code)
+		assert.Equal(t, "file /404.html", w.Body.String())
+	})
+
+	t.Run("gone", func(t *testing.T) {
+		w := serve("/removed")
+		assert.Equal(t, 410, w.Code)
+		assert.Equal(t, "file /gone.html", w.Body.String())
+	})
+
+	t.Run("content status without content", func(t *testing.T) {
+		w := serve("/blocked")
+		assert.Equal(t, 451, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Empty(t, w.Header().Get("Content-Type"))
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		w := serve("/api/users?page=2")
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "upstream "+upstream.Listener.Addr().String()+" /v1/users?page=2", w.Body.String())
+	})
+
+	t.Run("country", func(t *testing.T) {
+		w := serve("/", "X-Country", "AU")
+		assert.Equal(t, 302, w.Code)
+		assert.Equal(t, "/anz", w.Header().Get("Location"))
+	})
+
+	t.Run("unmatched", func(t *testing.T) {
+		w := serve("/about")
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "file /about", w.Body.String())
+	})
+}
+
+// clock is a Clock returning a fixed time.
+type clock time.Time
+
+// Now implementation.
+func (c clock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestHandler_Clock(t *testing.T) {
+	h := &redirects.Handler{
+		Rules: redirects.Must(redirects.ParseString(`/sale /winter 302 From=2024-12-01 Until=2025-01-01`)),
+		Next:  files,
+	}
+
+	h.Clock = clock(time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/sale", nil))
+	assert.Equal(t, 302, w.Code)
+
+	h.Clock = clock(time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/sale", nil))
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestHandler_zeroStatus(t *testing.T) {
+	h := &redirects.Handler{
+		Rules: []redirects.Rule{{From: "/a", To: "/b"}},
+		Next:  files,
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/a", nil))
+	assert.Equal(t, 301, w.Code)
+	assert.Equal(t, "/b", w.Header().Get("Location"))
+
+	res := redirects.Apply(h.Rules, redirects.Request{Path: "/a"})
+	assert.Equal(t, redirects.ActionRedirect, res.Action)
+	assert.Equal(t, 301, res.Status)
+}
+
+func TestHandler_Overrides(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/  /de     302  Country=de
+		/  /de-fr  302  Country=de Language=fr
+	`))
+
+	t.Run("enabled", func(t *testing.T) {
+		h := &redirects.Handler{Rules: rules, Next: files, Overrides: &redirects.Overrides{}}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/?_country=DE&page=2", nil))
+		assert.Equal(t, 302, w.Code)
+		assert.Equal(t, "/de?page=2", w.Header().Get("Location"))
+
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/?_country=de&_lang=fr", nil))
+		assert.Equal(t, "/de-fr", w.Header().Get("Location"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		h := &redirects.Handler{Rules: rules, Next: files}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/?_country=de", nil))
+		assert.Equal(t, 200, w.Code)
+	})
+}
+
+func TestHandler_AllowedProxyHosts(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "upstream")
+	}))
+	defer upstream.Close()
+
+	rules := redirects.Must(redirects.ParseString(`/api/* ` + upstream.URL + `/:splat 200`))
+
+	t.Run("allowed", func(t *testing.T) {
+		h := &redirects.Handler{Rules: rules, AllowedProxyHosts: []string{"127.0.0.1"}}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/api/users", nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "upstream", w.Body.String())
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		h := &redirects.Handler{Rules: rules, AllowedProxyHosts: []string{"api.example.com"}}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/api/users", nil))
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+	})
+}
+
+func TestHandler_cacheHeaders(t *testing.T) {
+	h := &redirects.Handler{
+		Rules: redirects.Must(redirects.ParseString(`
+			/docs  /docs/fr  302  Language=fr
+			/docs  /docs/us  302  Country=us
+			/docs  /docs/en  301
+			/beta  /next  302  Header:Cookie=beta=1
+			/old   /new
+		`)),
+		Next: files,
+		CacheControl: map[int]string{
+			301: "public, max-age=86400",
+			302: "no-cache",
+		},
+		CountryHeader: "CloudFront-Viewer-Country",
+	}
+
+	t.Run("permanent", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/old", nil))
+		assert.Equal(t, 301, w.Code)
+		assert.Equal(t, "public, max-age=86400", w.Header().Get("Cache-Control"))
+		assert.Empty(t, w.Header().Values("Vary"))
+	})
+
+	t.Run("conditions of earlier rules", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/docs", nil))
+		assert.Equal(t, 301, w.Code)
+		assert.Equal(t, []string{"Accept-Language", "CloudFront-Viewer-Country"}, w.Header().Values("Vary"))
+	})
+
+	t.Run("header condition", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/beta", nil)
+		r.Header.Set("Cookie", "beta=1")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, 302, w.Code)
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+		assert.Equal(t, []string{"Cookie"}, w.Header().Values("Vary"))
+	})
+}
+
+func TestHandler_methods(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.URL.Path, body)
+	})
+
+	upstream := httptest.NewServer(echo)
+	defer upstream.Close()
+
+	h := &redirects.Handler{
+		Rules: redirects.Must(redirects.ParseString(fmt.Sprintf(`
+			/301    /echo  301
+			/302    /echo  302
+			/303    /echo  303
+			/307    /echo  307
+			/308    /echo  308
+			/local  /echo  200
+			/api/*  %s/:splat  200
+		`, upstream.URL))),
+		Next: echo,
+	}
+
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"/301", "GET /echo "},
+		{"/302", "GET /echo "},
+		{"/303", "GET /echo "},
+		{"/307", "POST /echo data"},
+		{"/308", "POST /echo data"},
+		{"/local", "POST /echo data"},
+		{"/api/echo", "POST /echo data"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			res, err := http.Post(s.URL+c.path, "text/plain", strings.NewReader("data"))
+			assert.NoError(t, err)
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, 200, res.StatusCode)
+			assert.Equal(t, c.want, string(body))
+		})
+	}
+
+	t.Run("redirect status", func(t *testing.T) {
+		for _, code := range []int{301, 302, 303, 307, 308} {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("PUT", fmt.Sprintf("/%d", code), strings.NewReader("data")))
+			assert.Equal(t, code, w.Code)
+			assert.Equal(t, "/echo", w.Header().Get("Location"))
+		}
+	})
+}
+
+func TestHandler_webSocket(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+			return
+		}
+
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nX-Path: %s\r\n\r\n", r.URL.Path)
+		rw.Flush()
+		io.Copy(conn, rw)
+	}))
+	defer upstream.Close()
+
+	u, _ := url.Parse(upstream.URL)
+
+	for _, scheme := range []string{"http", "ws"} {
+		t.Run(scheme, func(t *testing.T) {
+			h := &redirects.Handler{
+				Rules: redirects.Must(redirects.ParseString(fmt.Sprintf(`/ws/*  %s://%s/:splat  200`, scheme, u.Host))),
+			}
+
+			s := httptest.NewServer(h)
+			defer s.Close()
+
+			conn, err := net.Dial("tcp", strings.TrimPrefix(s.URL, "http://"))
+			assert.NoError(t, err)
+			defer conn.Close()
+
+			fmt.Fprint(conn, "GET /ws/chat HTTP/1.1\r\nHost: example.com\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+
+			br := bufio.NewReader(conn)
+			res, err := http.ReadResponse(br, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+			assert.Equal(t, "/chat", res.Header.Get("X-Path"))
+
+			fmt.Fprint(conn, "ping")
+			buf := make([]byte, 4)
+			_, err = io.ReadFull(br, buf)
+			assert.NoError(t, err)
+			assert.Equal(t, "ping", string(buf))
+		})
+	}
+}
+
+func TestHandler_proxyHeaders(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "host=%s for=%s proto=%s fhost=%s conn=%s",
+			r.Host,
+			r.Header.Get("X-Forwarded-For"),
+			r.Header.Get("X-Forwarded-Proto"),
+			r.Header.Get("X-Forwarded-Host"),
+			r.Header.Get("X-Custom-Hop"))
+	}))
+	defer upstream.Close()
+
+	u, _ := url.Parse(upstream.URL)
+	rules := redirects.Must(redirects.ParseString(fmt.Sprintf(`/api/*  %s/:splat  200`, upstream.URL)))
+
+	serve := func(h *redirects.Handler) string {
+		r := httptest.NewRequest("GET", "/api/users", nil)
+		r.Host = "example.com"
+		r.RemoteAddr = "192.0.2.1:1234"
+		r.Header.Set("X-Forwarded-Proto", "spoofed")
+		r.Header.Set("Connection", "X-Custom-Hop")
+		r.Header.Set("X-Custom-Hop", "1")
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+
+	t.Run("default", func(t *testing.T) {
+		body := serve(&redirects.Handler{Rules: rules})
+		assert.Equal(t, fmt.Sprintf("host=%s for=192.0.2.1 proto=spoofed fhost= conn=", u.Host), body)
+	})
+
+	t.Run("all", func(t *testing.T) {
+		body := serve(&redirects.Handler{Rules: rules, Forwarded: redirects.ForwardedAll})
+		assert.Equal(t, fmt.Sprintf("host=%s for=192.0.2.1 proto=http fhost=example.com conn=", u.Host), body)
+	})
+
+	t.Run("none", func(t *testing.T) {
+		body := serve(&redirects.Handler{Rules: rules, Forwarded: redirects.ForwardedNone})
+		assert.Equal(t, fmt.Sprintf("host=%s for= proto= fhost= conn=", u.Host), body)
+	})
+
+	t.Run("preserve host", func(t *testing.T) {
+		body := serve(&redirects.Handler{Rules: rules, PreserveHost: true})
+		assert.Equal(t, "host=example.com for=192.0.2.1 proto=spoofed fhost= conn=", body)
+	})
+}
+
+func TestHandler_Middleware(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/home   /
+		/app/*  /index.html  200
+	`))
+
+	for name, mw := range map[string]func(http.Handler) http.Handler{
+		"handler":  (&redirects.Handler{Rules: rules, Next: http.NotFoundHandler()}).Middleware,
+		"function": redirects.Middleware(rules),
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := mw(files)
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/home", nil))
+			assert.Equal(t, 301, w.Code)
+			assert.Equal(t, "/", w.Header().Get("Location"))
+
+			w = httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/app/settings", nil))
+			assert.Equal(t, 200, w.Code)
+			assert.Equal(t, "file /index.html", w.Body.String())
+
+			w = httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/about", nil))
+			assert.Equal(t, 200, w.Code)
+			assert.Equal(t, "file /about", w.Body.String())
+		})
+	}
+}
+
+func TestHandler_Matcher(t *testing.T) {
+	compiled := redirects.Compile(redirects.Must(redirects.ParseString(`
+		/docs  /docs/fr  302  Language=fr
+		/docs  /docs/en  301
+	`)))
+
+	beta := redirects.Must(redirects.ParseString(`/docs  /beta/docs  302  Header:Cookie=beta=1`))
+
+	// a feature flag serving beta rules ahead of the compiled rules
+	flagged := redirects.MatcherFunc(func(req redirects.Request) (redirects.Result, bool) {
+		if res, ok := redirects.Match(beta, req); ok {
+			return res, true
+		}
+		return compiled.Match(req)
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		h := &redirects.Handler{Matcher: flagged, Next: files}
+
+		r := httptest.NewRequest("GET", "/docs", nil)
+		r.Header.Set("Cookie", "beta=1")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, 302, w.Code)
+		assert.Equal(t, "/beta/docs", w.Header().Get("Location"))
+		assert.Equal(t, []string{"Cookie"}, w.Header().Values("Vary"))
+
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/docs", nil))
+		assert.Equal(t, 301, w.Code)
+		assert.Equal(t, "/docs/en", w.Header().Get("Location"))
+	})
+
+	t.Run("rules", func(t *testing.T) {
+		h := &redirects.Handler{Matcher: compiled, Next: files}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/docs", nil))
+		assert.Equal(t, 301, w.Code)
+		assert.Equal(t, []string{"Accept-Language"}, w.Header().Values("Vary"))
+	})
+}
+
+func TestHandler_params(t *testing.T) {
+	h := &redirects.Handler{
+		Rules: redirects.Must(redirects.ParseString(`
+			/store  id=:id  /item/:id  301
+			/store          /shop
+		`)),
+		Next: files,
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/store?id=42&utm_source=x", nil))
+	assert.Equal(t, 301, w.Code)
+	assert.Equal(t, "/item/42", w.Header().Get("Location"))
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/store?utm_source=x", nil))
+	assert.Equal(t, "/shop?utm_source=x", w.Header().Get("Location"))
+}
+
+func TestHandler_traversal(t *testing.T) {
+	h := &redirects.Handler{
+		Rules: redirects.Must(redirects.ParseString(`
+			/static/*      /assets/:splat  200
+			/docs/:page    /pages/:page  200
+			/escape        /../secret  200
+			/windows/*     /assets\:splat  200
+		`)),
+		Next: files,
+	}
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	t.Run("safe", func(t *testing.T) {
+		w := serve("/static/css/site..css")
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "file /assets/css/site..css", w.Body.String())
+	})
+
+	for _, path := range []string{
+		"/static/../../etc/passwd",
+		"/static/%2e%2e/%2e%2e/etc/passwd",
+		"/static/css/..%2f..%2f..%2fetc/passwd",
+		"/docs/..",
+		"/escape",
+		`/windows/..\..\secret`,
+	} {
+		t.Run(path, func(t *testing.T) {
+			w := serve(path)
+			assert.Equal(t, 400, w.Code)
+			assert.Equal(t, "rewrite destination escapes the site root\n", w.Body.String())
+		})
+	}
+}
+
+func TestHandler_Content(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/about     /company
+		/pricing   /plans  301!
+		/blog/*    /posts/:splat
+		/blog/*    /archive/:splat  302!
+	`))
+
+	content := redirects.FSShadowChecker(fstest.MapFS{
+		"about.html":      {},
+		"pricing.html":    {},
+		"blog/index.html": {},
+	})
+
+	serve := func(h *redirects.Handler, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	for name, h := range map[string]*redirects.Handler{
+		"rules":    {Rules: rules, Content: content, Next: files},
+		"compiled": {Compiled: redirects.Compile(rules), Content: content, Next: files},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(h, "/about")
+			assert.Equal(t, 200, w.Code)
+			assert.Equal(t, "file /about", w.Body.String())
+
+			w = serve(h, "/pricing")
+			assert.Equal(t, 301, w.Code)
+			assert.Equal(t, "/plans", w.Header().Get("Location"))
+
+			w = serve(h, "/blog/")
+			assert.Equal(t, 302, w.Code)
+			assert.Equal(t, "/archive/", w.Header().Get("Location"))
+
+			w = serve(h, "/blog/hello")
+			assert.Equal(t, 301, w.Code)
+			assert.Equal(t, "/posts/hello", w.Header().Get("Location"))
+		})
+	}
+
+	t.Run("matcher", func(t *testing.T) {
+		compiled := redirects.Compile(rules)
+		h := &redirects.Handler{Matcher: redirects.MatcherFunc(compiled.Match), Content: content, Next: files}
+
+		w := serve(h, "/about")
+		assert.Equal(t, "file /about", w.Body.String())
+
+		w = serve(h, "/pricing")
+		assert.Equal(t, 301, w.Code)
+
+		// the first match is not forced, so content is served
+		w = serve(h, "/blog/")
+		assert.Equal(t, 200, w.Code)
+	})
+
+	t.Run("without content", func(t *testing.T) {
+		h := &redirects.Handler{Rules: rules, Next: files}
+
+		w := serve(h, "/about")
+		assert.Equal(t, 301, w.Code)
+		assert.Equal(t, "/company", w.Header().Get("Location"))
+	})
+}
+
+func TestHandler_StatusOverrides(t *testing.T) {
+	h := &redirects.Handler{
+		Rules: redirects.Must(redirects.ParseString(`
+			/old      /new
+			/temp     /elsewhere  302
+			/closed   /404.html  404
+			/app/*    /index.html  200
+		`)),
+		Next: files,
+		StatusOverrides: map[int]int{
+			301: 308,
+			302: 307,
+			404: 410,
+			200: 302,
+		},
+		CacheControl: map[int]string{
+			308: "public, max-age=86400",
+		},
+	}
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", path, nil))
+		return w
+	}
+
+	t.Run("redirect", func(t *testing.T) {
+		w := serve("/old")
+		assert.Equal(t, 308, w.Code)
+		assert.Equal(t, "/new", w.Header().Get("Location"))
+		assert.Equal(t, "public, max-age=86400", w.Header().Get("Cache-Control"))
+
+		w = serve("/temp")
+		assert.Equal(t, 307, w.Code)
+		assert.Empty(t, w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("content", func(t *testing.T) {
+		w := serve("/closed")
+		assert.Equal(t, 410, w.Code)
+		assert.Equal(t, "file /404.html", w.Body.String())
+	})
+
+	t.Run("other kind", func(t *testing.T) {
+		w := serve("/app/settings")
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "file /index.html", w.Body.String())
+	})
+}
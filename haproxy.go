@@ -0,0 +1,87 @@
+package redirects
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// HAProxyFrontend is a sample HAProxy frontend serving the map files written
+// by WriteHAProxyMaps from /etc/haproxy. HAProxy requires a constant status
+// code per redirect, so there is a directive per status. Query strings are
+// not passed through.
+const HAProxyFrontend = `frontend www
+  bind :80
+  http-request set-var(txn.redirect) path,map(/etc/haproxy/redirects.map)
+  http-request set-var(txn.status) path,map(/etc/haproxy/redirects-status.map)
+  http-request redirect location %[var(txn.redirect)] code 301 if { var(txn.status) -m str 301 }
+  http-request redirect location %[var(txn.redirect)] code 302 if { var(txn.status) -m str 302 }
+  http-request redirect location %[var(txn.redirect)] code 303 if { var(txn.status) -m str 303 }
+  http-request redirect location %[var(txn.redirect)] code 307 if { var(txn.status) -m str 307 }
+  http-request redirect location %[var(txn.redirect)] code 308 if { var(txn.status) -m str 308 }
+  default_backend site
+`
+
+// WriteHAProxyMaps writes HAProxy map files for exact match redirect
+// tables, mapping each source path to its destination in destinations and
+// to its status code in statuses, see HAProxyFrontend. Paths are written
+// with and without a trailing slash. Redirects from static paths without
+// query params or conditions are supported, while other rules, and rules
+// shadowed by an earlier rule with the same source, are skipped and reported.
+func WriteHAProxyMaps(destinations, statuses io.Writer, rules []Rule) ([]Unsupported, error) {
+	var skipped []Unsupported
+	seen := make(map[string]int)
+
+	dw := bufio.NewWriter(destinations)
+	sw := bufio.NewWriter(statuses)
+
+	for i, r := range rules {
+		reason := haproxyUnsupported(r)
+
+		key := trimSlash(r.From)
+		if j, ok := seen[key]; ok && reason == "" {
+			reason = fmt.Sprintf("shadowed by rule %d", j)
+		}
+
+		if reason != "" {
+			skipped = append(skipped, Unsupported{Index: i, Rule: r, Reason: reason})
+			continue
+		}
+
+		seen[key] = i
+
+		keys := []string{key}
+		if key != "/" {
+			keys = append(keys, key+"/")
+		}
+
+		for _, k := range keys {
+			fmt.Fprintf(dw, "%s %s\n", k, r.To)
+			fmt.Fprintf(sw, "%s %d\n", k, r.Status)
+		}
+	}
+
+	if err := dw.Flush(); err != nil {
+		return skipped, err
+	}
+
+	return skipped, sw.Flush()
+}
+
+// haproxyUnsupported returns the reason r cannot be written to an HAProxy
+// map file, or an empty string.
+func haproxyUnsupported(r Rule) string {
+	switch {
+	case isExclusion(r.From):
+		return "exclusion rules are not supported"
+	case r.IsContent():
+		return "only redirects are supported"
+	case isPattern(r.From) || placeholder.MatchString(r.To):
+		return "placeholders and wildcards are not supported"
+	case strings.ContainsAny(r.From+r.To, " \t"):
+		return "paths with whitespace are not supported"
+	default:
+		return unsupportedCondition(r)
+	}
+}
@@ -0,0 +1,51 @@
+package redirects_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestWriteHAProxyMaps(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/old-page   /new-page
+		/legacy/    https://example.com/  302
+		/           /home  307
+		/old-page/  /other
+		/blog/*     /posts/:splat
+		/app        /index.html  200
+		/fr         /fr-fr  302  Language=fr
+	`))
+
+	var destinations, statuses strings.Builder
+	skipped, err := redirects.WriteHAProxyMaps(&destinations, &statuses, rules)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `/old-page /new-page
+/old-page/ /new-page
+/legacy https://example.com/
+/legacy/ https://example.com/
+/ /home
+`, destinations.String())
+
+	assert.Equal(t, `/old-page 301
+/old-page/ 301
+/legacy 302
+/legacy/ 302
+/ 307
+`, statuses.String())
+
+	var reasons []string
+	for _, s := range skipped {
+		reasons = append(reasons, s.String())
+	}
+
+	assert.Equal(t, []string{
+		"rule 3 (/old-page/): shadowed by rule 0",
+		"rule 4 (/blog/*): placeholders and wildcards are not supported",
+		"rule 5 (/app): only redirects are supported",
+		"rule 6 (/fr): conditions are not supported",
+	}, reasons)
+}
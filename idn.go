@@ -0,0 +1,307 @@
+package redirects
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Punycode parameters, see RFC 3492.
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+)
+
+// acePrefix prefixes labels encoded as punycode.
+const acePrefix = "xn--"
+
+// errPunycode is returned for labels which are not valid punycode.
+var errPunycode = errors.New("invalid punycode")
+
+// asciiHost returns host with its internationalized labels encoded as
+// punycode, so "bücher.example" becomes "xn--bcher-kva.example", as
+// requests and DNS carry them. Hosts which can not be encoded are returned
+// as is.
+func asciiHost(host string) string {
+	if isASCII(host) {
+		return host
+	}
+
+	labels := strings.Split(host, ".")
+	for i, l := range labels {
+		if isASCII(l) {
+			continue
+		}
+
+		s, err := punycodeEncode(strings.ToLower(l))
+		if err != nil {
+			return host
+		}
+
+		labels[i] = acePrefix + s
+	}
+
+	return strings.Join(labels, ".")
+}
+
+// unicodeHost returns host with its punycode labels decoded, for display.
+// Labels which are not valid punycode are left as is.
+func unicodeHost(host string) string {
+	if !strings.Contains(strings.ToLower(host), acePrefix) {
+		return host
+	}
+
+	labels := strings.Split(host, ".")
+	for i, l := range labels {
+		if len(l) <= len(acePrefix) || !strings.EqualFold(l[:len(acePrefix)], acePrefix) {
+			continue
+		}
+
+		if s, err := punycodeDecode(strings.ToLower(l[len(acePrefix):])); err == nil {
+			labels[i] = s
+		}
+	}
+
+	return strings.Join(labels, ".")
+}
+
+// asciiURL returns the absolute URL to with its host encoded by asciiHost,
+// leaving the rest of it, including any placeholders, as written.
+func asciiURL(to string) string {
+	if isASCII(to) {
+		return to
+	}
+
+	return mapURLHost(to, asciiHost)
+}
+
+// unicodeURL returns the absolute URL to with its host decoded by
+// unicodeHost, for display.
+func unicodeURL(to string) string {
+	return mapURLHost(to, unicodeHost)
+}
+
+// mapURLHost returns the URL to with its host, and any port, replaced by
+// fn. Paths are returned as is.
+func mapURLHost(to string, fn func(string) string) string {
+	i := strings.Index(to, "://")
+	if i <= 0 || strings.ContainsAny(to[:i], "/?#") {
+		return to
+	}
+
+	start := i + len("://")
+	end := strings.IndexAny(to[start:], "/?#")
+	if end < 0 {
+		end = len(to)
+	} else {
+		end += start
+	}
+
+	return to[:start] + fn(to[start:end]) + to[end:]
+}
+
+// isASCII returns true if s only contains ASCII characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
+
+// punycodeEncode returns the punycode encoding of the label s, without
+// its "xn--" prefix.
+func punycodeEncode(s string) (string, error) {
+	runes := []rune(s)
+	if len(runes) > 63 {
+		return "", errPunycode
+	}
+
+	var out []byte
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+		}
+	}
+
+	basic := len(out)
+	if basic > 0 {
+		out = append(out, '-')
+	}
+
+	n, delta, bias := rune(punyInitialN), 0, punyInitialBias
+
+	for h := basic; h < len(runes); {
+		m := rune(utf8.MaxRune + 1)
+		for _, r := range runes {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+
+		delta += int(m-n) * (h + 1)
+		n = m
+
+		for _, r := range runes {
+			if r < n {
+				delta++
+			}
+
+			if r != n {
+				continue
+			}
+
+			q := delta
+			for k := punyBase; ; k += punyBase {
+				t := punyThreshold(k, bias)
+				if q < t {
+					break
+				}
+
+				out = append(out, punyDigit(t+(q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+
+			out = append(out, punyDigit(q))
+			bias = punyAdapt(delta, h+1, h == basic)
+			delta = 0
+			h++
+		}
+
+		delta++
+		n++
+	}
+
+	return string(out), nil
+}
+
+// punycodeDecode returns the label encoded as punycode by s, without its
+// "xn--" prefix.
+func punycodeDecode(s string) (string, error) {
+	if len(s) > 63 {
+		return "", errPunycode
+	}
+
+	var out []rune
+	pos := 0
+
+	if i := strings.LastIndexByte(s, '-'); i >= 0 {
+		for _, r := range s[:i] {
+			if r >= utf8.RuneSelf {
+				return "", errPunycode
+			}
+			out = append(out, r)
+		}
+		pos = i + 1
+	}
+
+	n, i, bias := punyInitialN, 0, punyInitialBias
+
+	for pos < len(s) {
+		prev, w := i, 1
+
+		for k := punyBase; ; k += punyBase {
+			if pos == len(s) {
+				return "", errPunycode
+			}
+
+			d := punyValue(s[pos])
+			pos++
+			if d < 0 {
+				return "", errPunycode
+			}
+
+			i += d * w
+			if i > math.MaxInt32 {
+				return "", errPunycode
+			}
+
+			t := punyThreshold(k, bias)
+			if d < t {
+				break
+			}
+
+			w *= punyBase - t
+			if w > math.MaxInt32 {
+				return "", errPunycode
+			}
+		}
+
+		bias = punyAdapt(i-prev, len(out)+1, prev == 0)
+		n += i / (len(out) + 1)
+		i %= len(out) + 1
+
+		if n > utf8.MaxRune {
+			return "", errPunycode
+		}
+
+		out = append(out, 0)
+		copy(out[i+1:], out[i:])
+		out[i] = rune(n)
+		i++
+	}
+
+	return string(out), nil
+}
+
+// punyThreshold returns the threshold of the digit at position k.
+func punyThreshold(k, bias int) int {
+	switch {
+	case k <= bias:
+		return punyTMin
+	case k >= bias+punyTMax:
+		return punyTMax
+	default:
+		return k - bias
+	}
+}
+
+// punyAdapt returns the bias following a delta.
+func punyAdapt(delta, points int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+
+	delta += delta / points
+
+	k := 0
+	for delta > ((punyBase-punyTMin)*punyTMax)/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
+
+// punyDigit returns the character of the digit d.
+func punyDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+
+	return byte('0' + d - 26)
+}
+
+// punyValue returns the digit of the character c, or -1.
+func punyValue(c byte) int {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return int(c - 'a')
+	case c >= 'A' && c <= 'Z':
+		return int(c - 'A')
+	case c >= '0' && c <= '9':
+		return int(c-'0') + 26
+	default:
+		return -1
+	}
+}
@@ -0,0 +1,57 @@
+package redirects_test
+
+import (
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestMatch_internationalizedHosts(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		https://bücher.example/*  /books/:splat  301!
+		/shop/*  /münchen/:splat  200  Host=münchen.example
+		/api/*   https://bücher.example:8443/api/:splat  200
+		/old/*   https://xn--mnchen-3ya.example/:splat
+	`))
+
+	t.Run("source", func(t *testing.T) {
+		res, ok := redirects.Match(rules, redirects.Request{Host: "xn--bcher-kva.example", Path: "/a"})
+		assert.True(t, ok)
+		assert.Equal(t, "/books/a", res.To)
+
+		res, ok = redirects.Match(rules, redirects.Request{Host: "Bücher.example", Path: "/a"})
+		assert.True(t, ok)
+		assert.Equal(t, "/books/a", res.To)
+	})
+
+	t.Run("host condition", func(t *testing.T) {
+		res, ok := redirects.Match(rules, redirects.Request{Host: "xn--mnchen-3ya.example:443", Path: "/shop/a"})
+		assert.True(t, ok)
+		assert.Equal(t, "/münchen/a", res.To)
+	})
+
+	t.Run("destination", func(t *testing.T) {
+		res, ok := redirects.Match(rules, redirects.Request{Path: "/api/v1"})
+		assert.True(t, ok)
+		assert.True(t, res.Rule.IsProxy())
+		assert.Equal(t, "https://xn--bcher-kva.example:8443/api/v1", res.To)
+		assert.Equal(t, "https://bücher.example:8443/api/:splat", res.Rule.To)
+	})
+
+	t.Run("allowed proxy hosts", func(t *testing.T) {
+		_, err := redirects.ParseString(`/api/*  https://xn--bcher-kva.example/:splat  200`, redirects.WithAllowedProxyHosts("bücher.example"))
+		assert.NoError(t, err)
+
+		_, err = redirects.ParseString(`/api/*  https://bücher.example/:splat  200`, redirects.WithAllowedProxyHosts("xn--mnchen-3ya.example"))
+		assert.Equal(t, redirects.CodeProxyNotAllowed, redirects.ErrorCode(err))
+	})
+}
+
+func TestExplain_internationalizedHosts(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/old/*  https://xn--mnchen-3ya.example/:splat  200  Host=xn--bcher-kva.example
+	`))
+
+	assert.Equal(t, "Requests to /old/* are proxied to https://münchen.example preserving the rest of the path; applies only to requests for bücher.example.", redirects.Explain(rules[0]))
+}
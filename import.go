@@ -0,0 +1,120 @@
+package redirects
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// regexpPattern returns the source path pattern equivalent to a regular
+// expression matching paths, as used by web server rewrite directives, along
+// with the placeholder names of its capture groups in order. Only anchored
+// expressions of literal segments, "([^/]+)" and "([0-9]+)" segments and a
+// final "(.*)" or "(.+)" are supported, the latter becoming a splat.
+func regexpPattern(expr string) (from string, names []string, err error) {
+	unsupported := func(reason string) (string, []string, error) {
+		return "", nil, fmt.Errorf("%w: %s in %q", ErrUnsupported, reason, expr)
+	}
+
+	if !strings.HasPrefix(expr, "^") {
+		return unsupported("unanchored regular expression")
+	}
+
+	body := expr[1:]
+	exact := strings.HasSuffix(body, "$") && !strings.HasSuffix(body, `\$`)
+	body = strings.TrimSuffix(body, "$")
+	body = strings.TrimSuffix(body, "/?")
+
+	if !strings.HasPrefix(body, "/") {
+		return unsupported("relative path")
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+
+		switch {
+		case c == '\\' && i+1 < len(body):
+			i++
+			b.WriteByte(body[i])
+
+		case c == '(':
+			end := strings.IndexByte(body[i:], ')')
+			if end < 0 {
+				return unsupported("unbalanced group")
+			}
+
+			group := body[i+1 : i+end]
+			i += end
+			last := i == len(body)-1
+			whole := strings.HasSuffix(b.String(), "/") && (last || body[i+1] == '/')
+
+			switch {
+			case (group == ".*" || group == ".+") && last && whole:
+				b.WriteString("*")
+				names = append(names, "splat")
+			case group == "[^/]+" && whole:
+				name := "p" + strconv.Itoa(len(names)+1)
+				b.WriteString(":" + name)
+				names = append(names, name)
+			case (group == "[0-9]+" || group == `\d+`) && whole:
+				name := "p" + strconv.Itoa(len(names)+1)
+				b.WriteString(":" + name + "(int)")
+				names = append(names, name)
+			default:
+				return unsupported(fmt.Sprintf("group %q", "("+group+")"))
+			}
+
+		case strings.IndexByte("[]{}|+*?^$)", c) >= 0:
+			return unsupported(fmt.Sprintf("%q", c))
+
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	from = b.String()
+
+	if !exact && !strings.HasSuffix(from, "*") {
+		if !strings.HasSuffix(from, "/") {
+			return unsupported("prefix match")
+		}
+		from += "*"
+	}
+
+	return from, names, nil
+}
+
+// regexpReplacement returns the destination equivalent to a replacement
+// referencing capture groups as $1 to $9, see regexpPattern.
+func regexpReplacement(to string, names []string) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(to); i++ {
+		c := to[i]
+
+		if c == '%' && i+1 < len(to) && to[i+1] == '{' {
+			return "", fmt.Errorf("%w: variable in %q", ErrUnsupported, to)
+		}
+
+		if c != '$' {
+			b.WriteByte(c)
+			continue
+		}
+
+		if i+1 == len(to) || to[i+1] < '1' || to[i+1] > '9' {
+			return "", fmt.Errorf("%w: variable in %q", ErrUnsupported, to)
+		}
+
+		n := int(to[i+1] - '0')
+		if n > len(names) {
+			return "", fmt.Errorf("%w: reference $%d to a missing group in %q", ErrUnsupported, n, to)
+		}
+
+		b.WriteString(":" + names[n-1])
+		i++
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,133 @@
+package redirects
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IPFSMaxSize is the largest _redirects file an IPFS gateway accepts.
+const IPFSMaxSize = 64 << 10
+
+// ipfsSpec is the IPFS web redirects file specification, for error messages.
+const ipfsSpec = "https://specs.ipfs.tech/http-gateways/web-redirects-file/"
+
+// A UnixFS reads files of UnixFS directories, such as through a kubo
+// client or a gateway's block backend.
+type UnixFS interface {
+	// Open returns the size and content of the file at path in the
+	// directory root, such as a CID, or an error wrapping fs.ErrNotExist
+	// when there is none.
+	Open(ctx context.Context, root, path string) (size int64, r io.ReadCloser, err error)
+}
+
+// WithIPFSGateway restricts rules to those IPFS gateways support, rejecting
+// forced rules, query params, conditions and extensions to the format, as
+// well as rewrites to other hosts and destinations escaping the site root.
+// Gateways apply these rules only on subdomain and DNSLink origins, where
+// each site has its own root.
+func WithIPFSGateway() Option {
+	return func(c *config) {
+		c.ipfs = true
+	}
+}
+
+// checkIPFS returns an error if r is not supported by IPFS gateways.
+func (c *config) checkIPFS(r Rule) error {
+	if !c.ipfs {
+		return nil
+	}
+
+	from := sourcePattern(r.From)
+
+	switch {
+	case isExclusion(r.From):
+		return ipfsError("exclusion rules are not supported")
+	case isRegexp(from):
+		return ipfsError("regular expression sources are not supported")
+	case extendedWildcard(from):
+		return ipfsError("double and mid-path wildcards are not supported")
+	case constrained(from):
+		return ipfsError("placeholder constraints are not supported")
+	case r.Force:
+		return ipfsError("forced redirects are not supported")
+	case len(r.Params) > 0:
+		return ipfsError("query params are not supported")
+	case len(r.Country) > 0 || len(r.Language) > 0 || len(r.Method) > 0 || len(r.Host) > 0 ||
+		len(r.Conditions) > 0 || !r.ActiveFrom.IsZero() || !r.ActiveUntil.IsZero():
+		return ipfsError("conditions are not supported")
+	case r.IsContent() && r.IsProxy():
+		return ipfsError("status %d destinations must be paths within the site, proxying to other hosts is not supported", r.Status)
+	case escapesRoot(r.To):
+		return ipfsError("destination %q escapes the site root", r.To)
+	}
+
+	return nil
+}
+
+// escapesRoot returns true if the path of the relative destination to has
+// more ".." segments than it descends, leaving the site root.
+func escapesRoot(to string) bool {
+	u, err := url.Parse(to)
+	if err != nil || u.Host != "" {
+		return false
+	}
+
+	depth := 0
+	for _, s := range strings.Split(u.Path, "/") {
+		switch s {
+		case "", ".":
+		case "..":
+			if depth == 0 {
+				return true
+			}
+			depth--
+		default:
+			depth++
+		}
+	}
+
+	return false
+}
+
+// ipfsError returns an error referencing the IPFS specification.
+func ipfsError(format string, args ...interface{}) error {
+	return errorf(CodeIPFSUnsupported, format+", see %s", append(args, ipfsSpec)...)
+}
+
+// LoadIPFS returns the rules of the _redirects file at the root of a UnixFS
+// directory, such as a CID, as an IPFS gateway serves them. The file must
+// not exceed IPFSMaxSize and rules are parsed WithIPFSGateway. A directory
+// without a _redirects file has no rules.
+func LoadIPFS(ctx context.Context, fsys UnixFS, root string, opts ...Option) ([]Rule, error) {
+	size, r, err := fsys.Open(ctx, root, "_redirects")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening %s", path.Join(root, "_redirects"))
+	}
+	defer r.Close()
+
+	if size > IPFSMaxSize {
+		return nil, ipfsError("_redirects is %d bytes, larger than the maximum of %d", size, IPFSMaxSize)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(r, IPFSMaxSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", path.Join(root, "_redirects"))
+	}
+
+	if len(b) > IPFSMaxSize {
+		return nil, ipfsError("_redirects is larger than the maximum of %d bytes", IPFSMaxSize)
+	}
+
+	return Parse(bytes.NewReader(b), append([]Option{WithIPFSGateway()}, opts...)...)
+}
@@ -0,0 +1,90 @@
+package redirects_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+// unixFS is an in-memory UnixFS keyed by root and path.
+type unixFS map[string]string
+
+func (u unixFS) Open(ctx context.Context, root, path string) (int64, io.ReadCloser, error) {
+	s, ok := u[root+"/"+path]
+	if !ok {
+		return 0, nil, fmt.Errorf("resolving %s/%s: %w", root, path, fs.ErrNotExist)
+	}
+
+	return int64(len(s)), io.NopCloser(strings.NewReader(s)), nil
+}
+
+func TestLoadIPFS(t *testing.T) {
+	ctx := context.Background()
+	fsys := unixFS{
+		"bafyok/_redirects":    "/home  /\n/posts/:id  /blog/:id  302\n/*  /index.html  200\n",
+		"bafyforce/_redirects": "/app/*  /app/index.html  200!\n",
+		"bafylarge/_redirects": strings.Repeat("/a  /b\n", redirects.IPFSMaxSize/7+1),
+	}
+
+	t.Run("rules", func(t *testing.T) {
+		rules, err := redirects.LoadIPFS(ctx, fsys, "bafyok")
+		assert.NoError(t, err)
+		assert.Len(t, rules, 3)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		rules, err := redirects.LoadIPFS(ctx, fsys, "bafyempty")
+		assert.NoError(t, err)
+		assert.Empty(t, rules)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := redirects.LoadIPFS(ctx, fsys, "bafyforce")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "forced redirects are not supported, see https://specs.ipfs.tech/")
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		_, err := redirects.LoadIPFS(ctx, fsys, "bafylarge")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "larger than the maximum of 65536")
+	})
+}
+
+func TestWithIPFSGateway(t *testing.T) {
+	cases := map[string]string{
+		"~^/a$  /b":                                   "regular expression sources are not supported",
+		"/a  /b  307":                                 "",
+		"/a  /b  302!":                                "forced redirects are not supported",
+		"/a/:id(int)  /b/:id":                         "placeholder constraints are not supported",
+		"/a id=:id  /b/:id":                           "query params are not supported",
+		"/a  /b  302  Country=us":                     "conditions are not supported",
+		"/a  /b  302  Header:X-A=b":                   "conditions are not supported",
+		"/a  /b  302  From=2024-01-01":                "conditions are not supported",
+		"/api/*  https://api.example.com/:splat  200": "proxying to other hosts is not supported",
+		"/gone  https://example.com/gone  404":        "proxying to other hosts is not supported",
+		"/a  https://example.com/a  302":              "",
+		"/a  /../b  200":                              "escapes the site root",
+		"/a  /b/%2e%2e/../c  301":                     "escapes the site root",
+		"/a  /b/../c  301":                            "",
+	}
+
+	for line, msg := range cases {
+		t.Run(line, func(t *testing.T) {
+			_, err := redirects.ParseString(line, redirects.WithIPFSGateway(), redirects.WithRegexRules())
+			if msg == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), msg)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package redirects
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Annotations limiting the requests proxied by a rule, such as
+// "#@ max-concurrent=20 rate=50 burst=100".
+const (
+	metaMaxConcurrent = "max-concurrent"
+	metaRate          = "rate"
+	metaBurst         = "burst"
+)
+
+// A Limits caps the requests sent to a proxy upstream, so a traffic spike
+// cannot exhaust the connections of a fragile upstream. Zero values are
+// unlimited.
+type Limits struct {
+	// MaxConcurrent is the number of requests which may be in flight at
+	// once, beyond which requests are rejected with 503 Service Unavailable.
+	MaxConcurrent int
+
+	// Rate is the sustained number of requests per second, beyond which
+	// requests are rejected with 429 Too Many Requests.
+	Rate float64
+
+	// Burst is the number of requests which may exceed Rate at once.
+	// Defaults to Rate rounded up.
+	Burst int
+}
+
+// enabled returns true if any limit is set.
+func (l Limits) enabled() bool {
+	return l.MaxConcurrent > 0 || l.Rate > 0
+}
+
+// ruleLimits returns the limits of the "max-concurrent", "rate" and
+// "burst" annotations of r. Values which are not positive numbers are
+// ignored.
+func ruleLimits(r *Rule) (l Limits) {
+	if n, err := strconv.Atoi(r.Meta[metaMaxConcurrent]); err == nil && n > 0 {
+		l.MaxConcurrent = n
+	}
+
+	if f, err := strconv.ParseFloat(r.Meta[metaRate], 64); err == nil && f > 0 && !math.IsInf(f, 0) {
+		l.Rate = f
+	}
+
+	if n, err := strconv.Atoi(r.Meta[metaBurst]); err == nil && n > 0 {
+		l.Burst = n
+	}
+
+	return
+}
+
+// limiter enforces Limits with a counter of requests in flight and a token
+// bucket.
+type limiter struct {
+	limits Limits
+
+	mu     sync.Mutex
+	active int
+	tokens float64
+	last   time.Time
+}
+
+// newLimiter returns a limiter with a full bucket.
+func newLimiter(l Limits) *limiter {
+	if l.Rate > 0 && l.Burst == 0 {
+		l.Burst = int(math.Ceil(l.Rate))
+	}
+
+	return &limiter{limits: l, tokens: float64(l.Burst)}
+}
+
+// acquire reserves a request at now, returning zero when it is allowed
+// or the status to reject it with. Allowed requests must be released.
+func (l *limiter) acquire(now time.Time) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limits.MaxConcurrent > 0 && l.active >= l.limits.MaxConcurrent {
+		return http.StatusServiceUnavailable
+	}
+
+	if l.limits.Rate > 0 {
+		if !l.last.IsZero() {
+			l.tokens = math.Min(float64(l.limits.Burst), l.tokens+now.Sub(l.last).Seconds()*l.limits.Rate)
+		}
+		l.last = now
+
+		if l.tokens < 1 {
+			return http.StatusTooManyRequests
+		}
+		l.tokens--
+	}
+
+	l.active++
+	return 0
+}
+
+// release ends a request reserved with acquire.
+func (l *limiter) release() {
+	l.mu.Lock()
+	l.active--
+	l.mu.Unlock()
+}
+
+// limit reserves a request to the upstream host by rule r under the limits
+// of the host's UpstreamPolicy and of the annotations of r, responding
+// with the rejection and returning false when a limit is reached. The
+// returned function releases the request.
+func (h *Handler) limit(w http.ResponseWriter, host string, r *Rule) (func(), bool) {
+	var acquired []*limiter
+	release := func() {
+		for _, l := range acquired {
+			l.release()
+		}
+	}
+
+	limits := []Limits{h.Upstreams[host].Limits, ruleLimits(r)}
+	keys := []string{"host " + host, "rule " + r.String()}
+
+	for i, key := range keys {
+		if !limits[i].enabled() {
+			continue
+		}
+
+		// the limits are part of the key, so changing them starts afresh
+		key = fmt.Sprintf("%s %v", key, limits[i])
+		v, ok := h.limiters.Load(key)
+		if !ok {
+			v, _ = h.limiters.LoadOrStore(key, newLimiter(limits[i]))
+		}
+		l := v.(*limiter)
+
+		if status := l.acquire(h.clock().Now()); status != 0 {
+			release()
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "1")
+			}
+			http.Error(w, http.StatusText(status), status)
+			return nil, false
+		}
+
+		acquired = append(acquired, l)
+	}
+
+	return release, true
+}
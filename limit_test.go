@@ -0,0 +1,89 @@
+package redirects_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestHandler_limits(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "upstream")
+	}))
+	defer upstream.Close()
+
+	u, _ := url.Parse(upstream.URL)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	get := func(h *redirects.Handler, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	t.Run("rate", func(t *testing.T) {
+		h := &redirects.Handler{
+			Rules:     redirects.Must(redirects.ParseString(fmt.Sprintf("/api/*  %s/:splat  200", upstream.URL))),
+			Clock:     clock(now),
+			Upstreams: map[string]redirects.UpstreamPolicy{u.Host: {Limits: redirects.Limits{Rate: 1, Burst: 2}}},
+		}
+
+		assert.Equal(t, 200, get(h, "/api/a").Code)
+		assert.Equal(t, 200, get(h, "/api/b").Code)
+
+		w := get(h, "/api/c")
+		assert.Equal(t, 429, w.Code)
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+		h.Clock = clock(now.Add(time.Second))
+		assert.Equal(t, 200, get(h, "/api/c").Code)
+	})
+
+	t.Run("annotations", func(t *testing.T) {
+		h := &redirects.Handler{
+			Rules: redirects.Must(redirects.ParseString(fmt.Sprintf("#@ rate=1\n/api/*  %s/:splat  200\n/other/*  %s/:splat  200", upstream.URL, upstream.URL))),
+			Clock: clock(now),
+		}
+
+		assert.Equal(t, 200, get(h, "/api/a").Code)
+		assert.Equal(t, 429, get(h, "/api/b").Code)
+		assert.Equal(t, 200, get(h, "/other/a").Code)
+		assert.Equal(t, 200, get(h, "/other/b").Code)
+	})
+
+	t.Run("concurrency", func(t *testing.T) {
+		started := make(chan struct{})
+		unblock := make(chan struct{})
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started <- struct{}{}
+			<-unblock
+		}))
+		defer slow.Close()
+
+		h := &redirects.Handler{
+			Rules: redirects.Must(redirects.ParseString(fmt.Sprintf("#@ max-concurrent=1\n/api/*  %s/:splat  200", slow.URL))),
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			get(h, "/api/a")
+		}()
+
+		<-started
+		assert.Equal(t, 503, get(h, "/api/b").Code)
+		close(unblock)
+		wg.Wait()
+
+		go func() { <-started }()
+		assert.Equal(t, 200, get(h, "/api/c").Code)
+	})
+}
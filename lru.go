@@ -0,0 +1,75 @@
+package redirects
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lru is a fixed size least recently used cache.
+type lru struct {
+	mu    sync.Mutex
+	size  int
+	list  *list.List
+	items map[string]*list.Element
+}
+
+// entry is a cache entry.
+type entry struct {
+	key   string
+	value interface{}
+}
+
+// newLRU returns a cache holding up to size entries.
+func newLRU(size int) *lru {
+	return &lru{
+		size:  size,
+		list:  list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+// get returns the value for key, marking it as recently used.
+func (c *lru) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.list.MoveToFront(e)
+	return e.Value.(*entry).value, true
+}
+
+// add adds the value for key, evicting the least recently used entry when full.
+func (c *lru) add(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		e.Value.(*entry).value = value
+		c.list.MoveToFront(e)
+		return
+	}
+
+	c.items[key] = c.list.PushFront(&entry{key, value})
+
+	if c.list.Len() > c.size {
+		e := c.list.Back()
+		c.list.Remove(e)
+		delete(c.items, e.Value.(*entry).key)
+	}
+}
+
+// stats returns the number of entries and the length of their keys.
+func (c *lru) stats() (entries, keyBytes int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k := range c.items {
+		keyBytes += len(k)
+	}
+
+	return len(c.items), keyBytes
+}
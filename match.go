@@ -0,0 +1,846 @@
+package redirects
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Request is the part of an incoming request which rules are matched against.
+type Request struct {
+	// Host is the requested host, used by rules with an absolute source URL.
+	Host string
+
+	// Method is the request method.
+	Method string
+
+	// Path is the request path.
+	Path string
+
+	// Query is the parsed query string.
+	Query url.Values
+
+	// Header is the request header.
+	Header http.Header
+
+	// Country is the visitor's ISO 3166-1 alpha-2 country code, if known.
+	Country string
+
+	// Language is the visitor's preferred languages, most preferred first.
+	Language []string
+
+	// Time is when the request was made, used by scheduled rules.
+	// Defaults to the current time when zero.
+	Time time.Time
+
+	// FileExists is true when content exists at the path, so that Apply,
+	// and a Handler with a ShadowChecker, only apply forced rules. Match
+	// ignores it.
+	FileExists bool
+}
+
+// A Clock returns the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock using the system time.
+type systemClock struct{}
+
+// Now implementation.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewRequest returns the Request for r, reading languages from the
+// Accept-Language header. The country is left empty as it depends on
+// how the visitor is located.
+func NewRequest(r *http.Request) Request {
+	return Request{
+		Host:     r.Host,
+		Method:   r.Method,
+		Path:     r.URL.Path,
+		Query:    r.URL.Query(),
+		Header:   r.Header,
+		Language: parseAcceptLanguage(r.Header.Get("Accept-Language")),
+	}
+}
+
+// A Matcher matches requests against rules, such as CompiledRules. Custom
+// implementations may wrap one with their own caching, feature flags or
+// lookup of each tenant's rules, while a Handler serves the results.
+type Matcher interface {
+	// Match returns the result of the first rule matching req, and false
+	// when none match.
+	Match(req Request) (Result, bool)
+}
+
+// MatcherFunc adapts a function to a Matcher.
+type MatcherFunc func(req Request) (Result, bool)
+
+// Match implementation.
+func (f MatcherFunc) Match(req Request) (Result, bool) {
+	return f(req)
+}
+
+// A Result is the outcome of matching a request, carrying everything
+// needed to serve it.
+type Result struct {
+	// Rule is the matched rule.
+	Rule *Rule
+
+	// Index is the position of the matched rule.
+	Index int
+
+	// To is the destination with placeholders expanded. The request query
+	// string is passed through unless the rule matches query params or the
+	// destination has a query string of its own.
+	To string
+
+	// Captures are the placeholder values captured from the path and query
+	// params, keyed without the leading colon, including "splat".
+	Captures Captures
+
+	// Conditions are the conditions the request met, such as "Country",
+	// "Method" or "Header:X-Canary".
+	Conditions []string
+
+	// Force is true when the rule applies even when content exists.
+	Force bool
+}
+
+// Captures are the values a match captured, by placeholder name or by
+// group for regular expression sources. The first few are held in place
+// rather than in a map, so a match allocates no more than its destination.
+type Captures struct {
+	n      int
+	inline [inlineCaptures]capture
+	more   []capture
+}
+
+// inlineCaptures is the number of captures held without allocating.
+const inlineCaptures = 4
+
+// capture is a captured value and its name.
+type capture struct {
+	name, value string
+}
+
+// Get returns the value captured as name, and false when there is none.
+func (c Captures) Get(name string) (string, bool) {
+	for i := 0; i < c.n; i++ {
+		if p := c.at(i); p.name == name {
+			return p.value, true
+		}
+	}
+
+	return "", false
+}
+
+// Len returns the number of captures.
+func (c Captures) Len() int {
+	return c.n
+}
+
+// Map returns the captures keyed by name, or nil when there are none.
+func (c Captures) Map() map[string]string {
+	if c.n == 0 {
+		return nil
+	}
+
+	m := make(map[string]string, c.n)
+	for i := 0; i < c.n; i++ {
+		p := c.at(i)
+		m[p.name] = p.value
+	}
+	return m
+}
+
+// MarshalJSON implementation, encoding the captures as an object.
+func (c Captures) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Map())
+}
+
+// set records value as the capture of name, replacing any earlier one.
+func (c *Captures) set(name, value string) {
+	for i := 0; i < c.n; i++ {
+		if p := c.at(i); p.name == name {
+			p.value = value
+			return
+		}
+	}
+
+	if c.n < inlineCaptures {
+		c.inline[c.n] = capture{name, value}
+	} else {
+		c.more = append(c.more, capture{name, value})
+	}
+	c.n++
+}
+
+// at returns capture i.
+func (c *Captures) at(i int) *capture {
+	if i < inlineCaptures {
+		return &c.inline[i]
+	}
+	return &c.more[i-inlineCaptures]
+}
+
+// Match returns the result of the first rule matching req, and false
+// when none match or the first is an exclusion rule.
+//
+// As with Netlify, rules sharing a source and params may differ by Country
+// and Language, the most conditional of them matching req applying before
+// those with fewer conditions regardless of their order, so an unconditional
+// rule is a fallback for the conditional ones.
+func Match(rules []Rule, req Request) (Result, bool) {
+	for i := range rules {
+		m := newMatcher(&rules[i])
+		if res, ok := m.match(req); ok {
+			for j := i + 1; j < len(rules); j++ {
+				if localeVariant(res.Rule, &rules[j]) {
+					v := newMatcher(&rules[j])
+					if vres, ok := v.match(req); ok {
+						res, i = vres, j
+					}
+				}
+			}
+			return matched(res, i)
+		}
+	}
+
+	return Result{}, false
+}
+
+// matched returns the result of rule i, and false for an exclusion rule.
+func matched(res Result, i int) (Result, bool) {
+	if isExclusion(res.Rule.From) {
+		return Result{}, false
+	}
+
+	res.Index = i
+	return res, true
+}
+
+// matchAll returns the result of the first of the matchers matching req,
+// and false when none match or the first is an exclusion rule.
+func matchAll(matchers []matcher, req Request) (Result, bool) {
+	for i := range matchers {
+		if res, ok := matchers[i].match(req); ok {
+			for _, j := range matchers[i].variants {
+				if localeVariant(res.Rule, matchers[j].rule) {
+					if vres, ok := matchers[j].match(req); ok {
+						res, i = vres, j
+					}
+				}
+			}
+			return matched(res, i)
+		}
+	}
+
+	return Result{}, false
+}
+
+// localeVariant returns true if v has the same source and params as r and
+// more Country and Language conditions, or narrower languages such as
+// "pt-br" for "pt", so it applies instead of r when both match.
+func localeVariant(r, v *Rule) bool {
+	if v.From != r.From || !sameParams(v.Params, r.Params) {
+		return false
+	}
+
+	n, rn := localeConditions(v), localeConditions(r)
+	return n > rn || n == rn && coversCountries(r.Country, v.Country) && narrowerLanguages(v.Language, r.Language)
+}
+
+// localeVariants returns the indices of the locale variants of each rule
+// following it.
+func localeVariants(rules []Rule) [][]int {
+	bySource := make(map[string][]int)
+	for i := range rules {
+		bySource[rules[i].From] = append(bySource[rules[i].From], i)
+	}
+
+	variants := make([][]int, len(rules))
+	for _, group := range bySource {
+		for n, i := range group {
+			for _, j := range group[n+1:] {
+				if localeVariant(&rules[i], &rules[j]) {
+					variants[i] = append(variants[i], j)
+				}
+			}
+		}
+	}
+
+	return variants
+}
+
+// localeConditions returns the number of Country and Language conditions.
+func localeConditions(r *Rule) (n int) {
+	if len(r.Country) > 0 {
+		n++
+	}
+
+	if len(r.Language) > 0 {
+		n++
+	}
+
+	return
+}
+
+// sameParams returns true if a and b are the same params.
+func sameParams(a, b Params) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// A matcher is a rule with its source parsed for matching, so requests
+// it does not match are rejected without allocating.
+type matcher struct {
+	rule *Rule
+
+	// re is the expression of a regular expression source.
+	re *regexp.Regexp
+
+	// host is the host of an absolute source URL.
+	host     string
+	absolute bool
+
+	// ps are the source path segments, with total wildcards.
+	ps    []string
+	total int
+
+	// captures is true when the rule captures values for its destination.
+	captures bool
+
+	// to is the destination, prepared when the rules are compiled.
+	to destination
+
+	// never is true when the source is malformed and matches nothing.
+	never bool
+
+	// variants are the indices of the rules applying instead of this one
+	// when they match, see localeVariant.
+	variants []int
+}
+
+// newMatcher returns the matcher of r.
+func newMatcher(r *Rule) matcher {
+	m := matcher{rule: r}
+	from := sourcePattern(r.From)
+
+	for _, v := range r.Params {
+		if s, ok := v.(string); ok && strings.HasPrefix(s, ":") {
+			m.captures = true
+		}
+	}
+
+	if isRegexp(from) {
+		re, err := compileRegexp(from)
+		m.re, m.never, m.captures = re, err != nil, true
+		return m
+	}
+
+	if !strings.HasPrefix(from, "/") {
+		u, err := url.Parse(from)
+		if err != nil {
+			m.never = true
+			return m
+		}
+		m.host, m.absolute, from = asciiHost(u.Host), true, u.Path
+	}
+
+	m.ps = segments(from)
+	m.total = wildcards(m.ps)
+
+	for _, s := range m.ps {
+		if segmentKind(s) != staticSegment {
+			m.captures = true
+		}
+	}
+
+	return m
+}
+
+// match returns the result when the rule matches req. Captures are only
+// recorded once the whole rule is known to match, and only for rules which
+// capture values.
+func (m *matcher) match(req Request) (res Result, ok bool) {
+	if !m.matchSource(req, nil) || !m.matchConditions(req) {
+		return
+	}
+
+	r := m.rule
+
+	var captures Captures
+	if m.captures {
+		m.matchSource(req, &captures)
+
+		// in key order, so the last param binding a placeholder wins
+		for _, k := range r.Params.keys() {
+			if s, ok := r.Params[k].(string); ok && strings.HasPrefix(s, ":") {
+				captures.set(s[1:], req.Query.Get(k))
+			}
+		}
+	}
+
+	var conditions []string
+
+	if len(r.Country) > 0 {
+		conditions = append(conditions, "Country")
+	}
+
+	if len(r.Language) > 0 {
+		conditions = append(conditions, "Language")
+	}
+
+	if len(r.Method) > 0 {
+		conditions = append(conditions, "Method")
+	}
+
+	if len(r.Host) > 0 {
+		conditions = append(conditions, "Host")
+	}
+
+	if !r.ActiveFrom.IsZero() {
+		conditions = append(conditions, "From")
+	}
+
+	if !r.ActiveUntil.IsZero() {
+		conditions = append(conditions, "Until")
+	}
+
+	conditions = append(conditions, r.Conditions.keys()...)
+
+	dest := m.to
+	if dest == nil {
+		dest = newDestination(r)
+	}
+
+	to := asciiURL(dest.expand(&captures))
+	if len(r.Params) == 0 && len(req.Query) > 0 && !strings.Contains(to, "?") {
+		to += "?" + req.Query.Encode()
+	}
+
+	return Result{
+		Rule:       r,
+		To:         to,
+		Captures:   captures,
+		Conditions: conditions,
+		Force:      r.Force,
+	}, true
+}
+
+// matchConditions returns true if req has the params and meets the
+// conditions of the rule.
+func (m *matcher) matchConditions(req Request) bool {
+	r := m.rule
+
+	for k, v := range r.Params {
+		if !req.Query.Has(k) {
+			return false
+		}
+
+		if s, ok := v.(string); ok && !strings.HasPrefix(s, ":") && s != req.Query.Get(k) {
+			return false
+		}
+	}
+
+	if len(r.Country) > 0 && !matchCountry(r.Country, req.Country) {
+		return false
+	}
+
+	if len(r.Language) > 0 && !matchLanguage(r.Language, req.Language) {
+		return false
+	}
+
+	if len(r.Method) > 0 && !containsFold(r.Method, req.Method) {
+		return false
+	}
+
+	if len(r.Host) > 0 && !hostAllowed(hostname(req.Host), r.Host) {
+		return false
+	}
+
+	if !r.ActiveFrom.IsZero() || !r.ActiveUntil.IsZero() {
+		now := req.Time
+		if now.IsZero() {
+			now = time.Now()
+		}
+
+		if !r.ActiveFrom.IsZero() && now.Before(r.ActiveFrom) {
+			return false
+		}
+
+		if !r.ActiveUntil.IsZero() && !now.Before(r.ActiveUntil) {
+			return false
+		}
+	}
+
+	for k, v := range r.Conditions {
+		name := headerCondition(k)
+		if name == "" || req.Header.Get(name) != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchSource returns true if the source matches the host and path of req,
+// regardless of params and conditions, recording the captures unless nil.
+func (m *matcher) matchSource(req Request, captures *Captures) bool {
+	switch {
+	case m.never:
+		return false
+	case m.re != nil:
+		return matchRegexp(m.re, req.Path, captures)
+	case m.absolute && !strings.EqualFold(m.host, asciiHost(req.Host)):
+		return false
+	}
+
+	var buf [16]span
+	return matchSegments(m.ps, req.Path, appendSegments(buf[:0], req.Path), captures, m.total, 0)
+}
+
+// matchSource returns true if the source of r matches the host and path of
+// req, regardless of params and conditions.
+func matchSource(r *Rule, req Request) bool {
+	m := newMatcher(r)
+	return m.matchSource(req, nil)
+}
+
+// span is the offsets of a segment in a path.
+type span struct {
+	start, end int
+}
+
+// appendSegments appends the spans of the non-empty segments of path p, so
+// that consecutive segments are joined without allocating.
+func appendSegments(spans []span, p string) []span {
+	for i := 0; i < len(p); {
+		j := strings.IndexByte(p[i:], '/')
+		if j < 0 {
+			return append(spans, span{i, len(p)})
+		}
+
+		if j > 0 {
+			spans = append(spans, span{i, i + j})
+		}
+		i += j + 1
+	}
+
+	return spans
+}
+
+// segment returns the segment of path at sp.
+func segment(path string, sp span) string {
+	return path[sp.start:sp.end]
+}
+
+// joinSegments returns the segments of path at spans joined by slashes.
+func joinSegments(path string, spans []span) string {
+	if len(spans) == 0 {
+		return ""
+	}
+
+	s := path[spans[0].start:spans[len(spans)-1].end]
+	if !strings.Contains(s, "//") {
+		return s
+	}
+
+	segs := make([]string, len(spans))
+	for i, sp := range spans {
+		segs[i] = segment(path, sp)
+	}
+	return strings.Join(segs, "/")
+}
+
+// matchSegments matches the pattern segments ps against the segments of
+// path at spans, recording captures unless nil. A final wildcard matches the
+// remaining segments, a "**" zero or more segments and another "*" exactly
+// one segment. Wildcards are numbered from n+1, of total in the whole pattern.
+func matchSegments(ps []string, path string, spans []span, captures *Captures, total, n int) bool {
+	if len(ps) == 0 {
+		return len(spans) == 0
+	}
+
+	s := ps[0]
+
+	switch {
+	case isWildcard(s) && len(ps) == 1:
+		if captures != nil {
+			splat(captures, total, n+1, joinSegments(path, spans))
+		}
+		return true
+	case s == "**":
+		for i := len(spans); i >= 0; i-- {
+			if matchSegments(ps[1:], path, spans[i:], captures, total, n+1) {
+				if captures != nil {
+					splat(captures, total, n+1, joinSegments(path, spans[:i]))
+				}
+				return true
+			}
+		}
+		return false
+	case len(spans) == 0:
+		return false
+	case s == "*":
+		if captures != nil {
+			splat(captures, total, n+1, segment(path, spans[0]))
+		}
+		return matchSegments(ps[1:], path, spans[1:], captures, total, n+1)
+	case segmentKind(s) == placeholderSegment:
+		name, constraint := placeholderName(s)
+		if !matchConstraint(constraint, segment(path, spans[0])) {
+			return false
+		}
+		if captures != nil {
+			captures.set(name, segment(path, spans[0]))
+		}
+		return matchSegments(ps[1:], path, spans[1:], captures, total, n)
+	case s != segment(path, spans[0]):
+		return false
+	default:
+		return matchSegments(ps[1:], path, spans[1:], captures, total, n)
+	}
+}
+
+// splat records the capture of wildcard n of total, as "splat" for the last
+// wildcard and also as "splat1", "splat2" and so on when there are several.
+func splat(captures *Captures, total, n int, v string) {
+	if total > 1 {
+		captures.set("splat"+strconv.Itoa(n), v)
+	}
+
+	if n == total {
+		captures.set("splat", v)
+	}
+}
+
+// isWildcard returns true if the path segment is "*" or "**".
+func isWildcard(s string) bool {
+	return s == "*" || s == "**"
+}
+
+// wildcards returns the number of wildcard segments.
+func wildcards(ps []string) (n int) {
+	for _, s := range ps {
+		if isWildcard(s) {
+			n++
+		}
+	}
+	return
+}
+
+// extendedWildcard returns true if the source path uses "**" or a wildcard
+// other than a single trailing "*".
+func extendedWildcard(from string) bool {
+	if isRegexp(from) {
+		return false
+	}
+
+	ps := segments(from)
+	for i, s := range ps {
+		if s == "**" || s == "*" && i < len(ps)-1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// regexps are the compiled regular expression sources.
+var regexps sync.Map
+
+// isRegexp returns true if from is a regular expression source.
+func isRegexp(from string) bool {
+	return strings.HasPrefix(from, "~")
+}
+
+// isExclusion returns true if from is an exclusion source.
+func isExclusion(from string) bool {
+	return strings.HasPrefix(from, "!")
+}
+
+// sourcePattern returns the source from without an exclusion prefix.
+func sourcePattern(from string) string {
+	return strings.TrimPrefix(from, "!")
+}
+
+// compileRegexp returns the compiled regular expression of the source from.
+func compileRegexp(from string) (*regexp.Regexp, error) {
+	if re, ok := regexps.Load(from); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(from[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	regexps.Store(from, re)
+	return re, nil
+}
+
+// matchRegexp returns true if the expression of a regular expression
+// source matches path, recording the groups captured by number and by name
+// unless captures is nil.
+func matchRegexp(re *regexp.Regexp, path string, captures *Captures) bool {
+	if captures == nil {
+		return re.MatchString(path)
+	}
+
+	m := re.FindStringSubmatch(path)
+	if m == nil {
+		return false
+	}
+
+	for i, name := range re.SubexpNames() {
+		if i == 0 {
+			continue
+		}
+
+		captures.set(strconv.Itoa(i), m[i])
+		if name != "" {
+			captures.set(name, m[i])
+		}
+	}
+
+	return true
+}
+
+// hostname returns host without a port.
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+
+	return host
+}
+
+// matchCountry returns true if country is one of the listed countries, or
+// is none of them when they are negated as in "Country=!cn,!ru".
+func matchCountry(list []string, country string) bool {
+	neg := negated(list)
+
+	// entries negated unlike the first, and empty ones, are ignored
+	for _, c := range list {
+		name := strings.TrimPrefix(c, "!")
+		if name == "" || (name != c) != neg {
+			continue
+		}
+
+		if strings.EqualFold(name, country) {
+			return !neg
+		}
+	}
+
+	return neg
+}
+
+// negated returns true if the values of list are negated with "!".
+func negated(list []string) bool {
+	return len(list) > 0 && strings.HasPrefix(list[0], "!")
+}
+
+// matchLanguage returns true if any of the visitor's languages is allowed.
+func matchLanguage(allowed, languages []string) bool {
+	for _, l := range languages {
+		for _, a := range allowed {
+			if languageMatches(a, l) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// languageMatches returns true if the language range, such as "pt",
+// matches the language tag, such as "pt-BR", by the basic filtering of
+// BCP 47: the range equals the tag or a prefix of it ending at a hyphen,
+// ignoring case.
+func languageMatches(rng, tag string) bool {
+	if len(tag) > len(rng) && tag[len(rng)] == '-' {
+		tag = tag[:len(rng)]
+	}
+
+	return strings.EqualFold(rng, tag)
+}
+
+// narrowerLanguages returns true if every language of v is matched by one
+// of r's, and v has one which is not among r's, as "pt-br" is narrower than
+// "pt".
+func narrowerLanguages(v, r []string) bool {
+	if len(r) == 0 || !coversLanguages(r, v) {
+		return false
+	}
+
+	for _, l := range v {
+		if !containsFold(r, l) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseAcceptLanguage returns the language tags of an Accept-Language
+// header ordered by preference.
+func parseAcceptLanguage(header string) []string {
+	type tag struct {
+		name string
+		q    float64
+	}
+
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.TrimSpace(fields[0])
+		if name == "" || name == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				if v, err := strconv.ParseFloat(f[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		if q > 0 {
+			tags = append(tags, tag{name, q})
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].q > tags[j].q
+	})
+
+	languages := make([]string, len(tags))
+	for i, t := range tags {
+		languages[i] = t.name
+	}
+
+	return languages
+}
@@ -0,0 +1,399 @@
+package redirects_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestMatch(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/home                /
+		/blog/:year/:slug    /posts/:year/:slug
+		/store id=:id        /item/:id
+		/store               /shop
+		/news/*              /blog/:splat
+		/                    /anz       302  Country=au,nz
+		/                    /fr        302  Language=fr
+		/form                https://api.example.com/form  200  Method=POST,PUT
+		/form                /form.html  200
+		/                    /canary    200  Header:X-Canary=true
+		/                    /shop      200  Host=shop.example.com,*.shop.example.com
+		https://old.example.com/*  https://new.example.com/:splat  301!
+	`))
+
+	cases := []struct {
+		name string
+		req  redirects.Request
+		to   string
+	}{
+		{"static", redirects.Request{Path: "/home"}, "/"},
+		{"trailing slash", redirects.Request{Path: "/home/"}, "/"},
+		{"placeholders", redirects.Request{Path: "/blog/2020/hello"}, "/posts/:year/:slug"},
+		{"params", redirects.Request{Path: "/store", Query: url.Values{"id": {"5"}}}, "/item/:id"},
+		{"params missing", redirects.Request{Path: "/store"}, "/shop"},
+		{"splat", redirects.Request{Path: "/news/2020/hello"}, "/blog/:splat"},
+		{"empty splat", redirects.Request{Path: "/news"}, "/blog/:splat"},
+		{"country", redirects.Request{Path: "/", Country: "NZ"}, "/anz"},
+		{"language", redirects.Request{Path: "/", Language: []string{"de", "fr"}}, "/fr"},
+		{"method", redirects.Request{Method: "POST", Path: "/form"}, "https://api.example.com/form"},
+		{"other method", redirects.Request{Method: "GET", Path: "/form"}, "/form.html"},
+		{"header", redirects.Request{Path: "/", Header: http.Header{"X-Canary": {"true"}}}, "/canary"},
+		{"host condition", redirects.Request{Host: "shop.example.com:8080", Path: "/"}, "/shop"},
+		{"host condition wildcard", redirects.Request{Host: "EU.shop.example.com", Path: "/"}, "/shop"},
+		{"host", redirects.Request{Host: "old.example.com", Path: "/a"}, "https://new.example.com/:splat"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res, ok := redirects.Match(rules, c.req)
+			assert.True(t, ok)
+			assert.Equal(t, c.to, res.Rule.To)
+		})
+	}
+
+	t.Run("no match", func(t *testing.T) {
+		for _, req := range []redirects.Request{
+			{Path: "/"},
+			{Path: "/", Header: http.Header{"X-Canary": {"false"}}},
+			{Path: "/blog/2020"},
+			{Host: "example.com", Path: "/a"},
+			{Host: "blog.example.com", Path: "/"},
+		} {
+			_, ok := redirects.Match(rules, req)
+			assert.False(t, ok)
+		}
+	})
+}
+
+func TestMatch_schedule(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/sale  /winter  302  From=2024-12-01T00:00Z Until=2025-01-01T00:00Z
+		/sale  /sales
+	`))
+
+	at := func(s string) redirects.Request {
+		ts, _ := time.Parse(time.RFC3339, s)
+		return redirects.Request{Path: "/sale", Time: ts}
+	}
+
+	to := func(req redirects.Request) string {
+		res, _ := redirects.Match(rules, req)
+		return res.To
+	}
+
+	assert.Equal(t, "/sales", to(at("2024-11-30T23:59:59Z")))
+	assert.Equal(t, "/winter", to(at("2024-12-01T00:00:00Z")))
+	assert.Equal(t, "/winter", to(at("2024-12-31T23:59:59Z")))
+	assert.Equal(t, "/sales", to(at("2025-01-01T00:00:00Z")))
+}
+
+func TestMatch_result(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/home                      /
+		/store/:tag id=:id         /item/:tag/:id   302!  Country=au  Header:X-Canary=true
+		/news/*                    https://blog.example.com/:splat  200  Method=GET
+	`))
+
+	t.Run("captures and conditions", func(t *testing.T) {
+		res, ok := redirects.Match(rules, redirects.Request{
+			Path:    "/store/shoes",
+			Query:   url.Values{"id": {"5"}},
+			Country: "au",
+			Header:  http.Header{"X-Canary": {"true"}},
+		})
+
+		assert.True(t, ok)
+		assert.Equal(t, &rules[1], res.Rule)
+		assert.Equal(t, 1, res.Index)
+		assert.Equal(t, "/item/shoes/5", res.To)
+		assert.Equal(t, map[string]string{"tag": "shoes", "id": "5"}, res.Captures.Map())
+		assert.Equal(t, []string{"Country", "Header:X-Canary"}, res.Conditions)
+		assert.True(t, res.Force)
+	})
+
+	t.Run("splat and query", func(t *testing.T) {
+		res, ok := redirects.Match(rules, redirects.Request{
+			Method: "GET",
+			Path:   "/news/2020/hello",
+			Query:  url.Values{"ref": {"home"}},
+		})
+
+		assert.True(t, ok)
+		assert.Equal(t, 2, res.Index)
+		assert.Equal(t, "https://blog.example.com/2020/hello?ref=home", res.To)
+		assert.Equal(t, map[string]string{"splat": "2020/hello"}, res.Captures.Map())
+		assert.Equal(t, []string{"Method"}, res.Conditions)
+		assert.False(t, res.Force)
+	})
+}
+
+func TestCaptures(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/:a/:b/:c/:d/:e/*  /x/:e/:a/:splat
+	`))
+
+	res, ok := redirects.Match(rules, redirects.Request{Path: "/1/2/3/4/5/6/7"})
+	assert.True(t, ok)
+	assert.Equal(t, "/x/5/1/6/7", res.To)
+	assert.Equal(t, 6, res.Captures.Len())
+
+	v, ok := res.Captures.Get("e")
+	assert.True(t, ok)
+	assert.Equal(t, "5", v)
+
+	_, ok = res.Captures.Get("f")
+	assert.False(t, ok)
+
+	b, err := json.Marshal(res.Captures)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":"1","b":"2","c":"3","d":"4","e":"5","splat":"6/7"}`, string(b))
+
+	assert.Nil(t, redirects.Captures{}.Map())
+}
+
+func TestNewRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://example.com/blog?page=2", nil)
+	r.Header.Set("Accept-Language", "en;q=0.5, fr-CH, de;q=0.7, *;q=0.1")
+
+	assert.Equal(t, redirects.Request{
+		Host:     "example.com",
+		Method:   "GET",
+		Path:     "/blog",
+		Query:    url.Values{"page": {"2"}},
+		Header:   r.Header,
+		Language: []string{"fr-CH", "de", "en"},
+	}, redirects.NewRequest(r))
+}
+
+func TestMatch_regexp(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		~^/blog/(\d{4})/(?P<slug>[a-z-]+)$  /posts/$1/${slug}
+		~^/p/(\d+)$                      /products?id=$1  302
+		/search q=:q                     /find/:q
+	`, redirects.WithRegexRules()))
+
+	cases := []struct {
+		path string
+		to   string
+		ok   bool
+	}{
+		{"/blog/2020/hello-world", "/posts/2020/hello-world", true},
+		{"/blog/20/hello-world", "", false},
+		{"/blog/2020/Hello", "", false},
+		{"/p/42", "/products?id=42", true},
+		{"/p/abc", "", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			res, ok := redirects.Match(rules, redirects.Request{Path: c.path})
+			assert.Equal(t, c.ok, ok)
+			assert.Equal(t, c.to, res.To)
+		})
+	}
+
+	t.Run("captures", func(t *testing.T) {
+		res, ok := redirects.Match(rules, redirects.Request{Path: "/blog/2020/hello"})
+		assert.True(t, ok)
+		assert.Equal(t, map[string]string{"1": "2020", "2": "hello", "slug": "hello"}, res.Captures.Map())
+	})
+
+	t.Run("query passthrough", func(t *testing.T) {
+		res, ok := redirects.Match(rules, redirects.Request{Path: "/blog/2020/hello", Query: url.Values{"ref": {"x"}}})
+		assert.True(t, ok)
+		assert.Equal(t, "/posts/2020/hello?ref=x", res.To)
+	})
+}
+
+func TestMatch_extendedWildcards(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/docs/*/images/*  /assets/:splat1/:splat2
+		/files/**/raw     /raw/:splat
+		/a/**/b/*         /c/:splat1/:splat
+	`, redirects.WithExtendedWildcards()))
+
+	cases := []struct {
+		path string
+		to   string
+		ok   bool
+	}{
+		{"/docs/v1/images/logo.png", "/assets/v1/logo.png", true},
+		{"/docs/v1/images/icons/logo.png", "/assets/v1/icons/logo.png", true},
+		{"/docs/images/logo.png", "", false},
+		{"/files/raw", "/raw/", true},
+		{"/files/x/y/raw", "/raw/x/y", true},
+		{"/files/x/y", "", false},
+		{"/a/x/b/y/b/z", "/c/x/b/y/z", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			res, ok := redirects.Match(rules, redirects.Request{Path: c.path})
+			assert.Equal(t, c.ok, ok)
+			assert.Equal(t, c.to, res.To)
+		})
+	}
+
+	t.Run("captures", func(t *testing.T) {
+		res, ok := redirects.Match(rules, redirects.Request{Path: "/docs/v1/images/logo.png"})
+		assert.True(t, ok)
+		assert.Equal(t, map[string]string{"splat": "logo.png", "splat1": "v1", "splat2": "logo.png"}, res.Captures.Map())
+	})
+}
+
+func TestMatch_exclusion(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		!/api/health
+		!/api/internal/*  Country=us
+		/api/*  https://api.example.com/:splat  200
+	`, redirects.WithExclusionRules()))
+
+	cases := []struct {
+		path    string
+		country string
+		ok      bool
+	}{
+		{"/api/users", "", true},
+		{"/api/health", "", false},
+		{"/api/health/", "", false},
+		{"/api/internal/x", "us", false},
+		{"/api/internal/x", "fr", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path+" "+c.country, func(t *testing.T) {
+			res, ok := redirects.Match(rules, redirects.Request{Path: c.path, Country: c.country})
+			assert.Equal(t, c.ok, ok)
+			if ok {
+				assert.Equal(t, 2, res.Index)
+			}
+		})
+	}
+}
+
+func TestMatch_languageRegion(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/  /pt     302  Language=pt
+		/  /pt-br  302  Language=pt-br
+		/  /en     302
+	`))
+
+	compiled := redirects.Compile(rules)
+
+	cases := []struct {
+		language string
+		to       string
+	}{
+		{"pt", "/pt"},
+		{"pt-PT", "/pt"},
+		{"pt-BR", "/pt-br"},
+		{"pt-br-x-foo", "/pt-br"},
+		{"ptb", "/en"},
+		{"en-GB", "/en"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.language, func(t *testing.T) {
+			req := redirects.Request{Path: "/", Language: []string{c.language}}
+
+			res, ok := redirects.Match(rules, req)
+			assert.True(t, ok)
+			assert.Equal(t, c.to, res.To)
+
+			cres, ok := compiled.Match(req)
+			assert.True(t, ok)
+			assert.Equal(t, c.to, cres.To)
+		})
+	}
+
+	assert.Empty(t, redirects.Shadows(rules))
+}
+
+func TestMatch_countryNegation(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/shop  /shop/global  302  Country=!cn,!ru
+		/shop  /shop/local   302
+	`))
+
+	cases := []struct {
+		country string
+		to      string
+	}{
+		{"US", "/shop/global"},
+		{"", "/shop/global"},
+		{"CN", "/shop/local"},
+		{"ru", "/shop/local"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.country, func(t *testing.T) {
+			res, ok := redirects.Match(rules, redirects.Request{Path: "/shop", Country: c.country})
+			assert.True(t, ok)
+			assert.Equal(t, c.to, res.To)
+		})
+	}
+
+	assert.Equal(t, "/shop /shop/global 302 Country=!cn,!ru", rules[0].String())
+
+	t.Run("programmatic", func(t *testing.T) {
+		rules := []redirects.Rule{{From: "/shop", To: "/shop/global", Status: 302, Country: []string{"!cn", "", "us"}}}
+
+		_, ok := redirects.Match(rules, redirects.Request{Path: "/shop", Country: "cn"})
+		assert.False(t, ok)
+
+		_, ok = redirects.Match(rules, redirects.Request{Path: "/shop", Country: "s"})
+		assert.True(t, ok)
+
+		_, ok = redirects.Match([]redirects.Rule{{From: "/shop", To: "/a", Status: 302, Country: []string{"us", ""}}}, redirects.Request{Path: "/shop"})
+		assert.False(t, ok)
+	})
+}
+
+func TestMatch_localeFallback(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/            /en       302
+		/            /fr       302  Language=fr
+		/            /ca       302  Country=ca
+		/            /ca-fr    302  Country=ca Language=fr
+		/store id=:id  /item/:id
+		/store id=:id  /de/item/:id  Country=de
+		/about       /team
+		/about       /de/team  302  Country=de Header:X-Beta=1
+	`))
+
+	compiled := redirects.Compile(rules)
+
+	cases := []struct {
+		name string
+		req  redirects.Request
+		to   string
+	}{
+		{"fallback", redirects.Request{Path: "/"}, "/en"},
+		{"language", redirects.Request{Path: "/", Language: []string{"fr"}}, "/fr"},
+		{"country", redirects.Request{Path: "/", Country: "CA"}, "/ca"},
+		{"country and language", redirects.Request{Path: "/", Country: "ca", Language: []string{"fr"}}, "/ca-fr"},
+		{"other country", redirects.Request{Path: "/", Country: "us", Language: []string{"fr"}}, "/fr"},
+		{"params", redirects.Request{Path: "/store", Query: url.Values{"id": {"5"}}, Country: "de"}, "/de/item/5"},
+		{"other conditions", redirects.Request{Path: "/about", Country: "de", Header: http.Header{"X-Beta": {"1"}}}, "/de/team"},
+		{"other conditions unmet", redirects.Request{Path: "/about", Country: "de"}, "/team"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res, ok := redirects.Match(rules, c.req)
+			assert.True(t, ok)
+			assert.Equal(t, c.to, res.To)
+
+			cres, ok := compiled.Match(c.req)
+			assert.True(t, ok)
+			assert.Equal(t, res.Index, cres.Index)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package redirects
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// ExportNDJSON writes the rules as newline delimited JSON, one rule per line.
+func ExportNDJSON(w io.Writer, rules []Rule) error {
+	enc := json.NewEncoder(w)
+
+	for _, r := range rules {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ImportNDJSON reads rules written by ExportNDJSON, skipping empty lines.
+// A missing status defaults to 301. Errors reference the line number.
+func ImportNDJSON(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+
+	err := EachNDJSON(r, func(rule Rule) error {
+		rules = append(rules, rule)
+		return nil
+	})
+
+	return rules, err
+}
+
+// EachNDJSON streams rules written by ExportNDJSON, calling fn for each
+// rule as it is read, so large rule sets need not be held in memory.
+// Reading stops at the first error returned by fn.
+func EachNDJSON(r io.Reader, fn func(Rule) error) error {
+	s := bufio.NewScanner(r)
+
+	for line := 1; s.Scan(); line++ {
+		b := bytes.TrimSpace(s.Bytes())
+
+		if len(b) == 0 {
+			continue
+		}
+
+		rule := Rule{Status: 301}
+		if err := json.Unmarshal(b, &rule); err != nil {
+			return errors.Wrapf(err, "line %d", line)
+		}
+
+		if errs := validateRule(rule, nil); len(errs) > 0 {
+			return errors.Wrapf(errs[0], "line %d", line)
+		}
+
+		if err := fn(rule); err != nil {
+			return err
+		}
+	}
+
+	return s.Err()
+}
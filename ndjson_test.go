@@ -0,0 +1,61 @@
+package redirects_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestExportNDJSON(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/home   /  301
+		/store  id=:id  /item/:id  302!
+	`))
+
+	var buf bytes.Buffer
+	assert.NoError(t, redirects.ExportNDJSON(&buf, rules))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `{"From":"/home","To":"/","Status":301,"Force":false,`)
+	assert.Contains(t, lines[1], `{"From":"/store","To":"/item/:id","Status":302,"Force":true,"Params":{"id":":id"},`)
+
+	imported, err := redirects.ImportNDJSON(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, rules, imported)
+}
+
+func TestImportNDJSON(t *testing.T) {
+	t.Run("default status", func(t *testing.T) {
+		rules, err := redirects.ImportNDJSON(strings.NewReader("\n" + `{"From":"/a","To":"/b"}` + "\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, []redirects.Rule{{From: "/a", To: "/b", Status: 301}}, rules)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := redirects.ImportNDJSON(strings.NewReader(`{"From":"/a","To":"/b"}` + "\n{\n"))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "line 2")
+	})
+
+	t.Run("invalid rule", func(t *testing.T) {
+		_, err := redirects.ImportNDJSON(strings.NewReader(`{"From":"a","To":"/b"}`))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "line 1: invalid source path")
+	})
+}
+
+func TestEachNDJSON(t *testing.T) {
+	var froms []string
+
+	err := redirects.EachNDJSON(strings.NewReader(`{"From":"/a","To":"/b"}`+"\n"+`{"From":"/c","To":"/d"}`), func(r redirects.Rule) error {
+		froms = append(froms, r.From)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/a", "/c"}, froms)
+}
@@ -0,0 +1,285 @@
+package redirects
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// nginxToken is a word of an nginx configuration, or one of ";", "{" and "}".
+type nginxToken struct {
+	text   string
+	line   int
+	quoted bool
+}
+
+// nginxBlock is an enclosing block of an nginx directive.
+type nginxBlock struct {
+	// name is the block directive, such as "server" or "location".
+	name string
+
+	// args are the block directive arguments.
+	args []string
+}
+
+// ImportNginx reads the redirects of an nginx configuration fragment,
+// converting "return" directives with a redirect status and "rewrite"
+// directives into rules in the order they appear. Exact, prefix and simple
+// regular expression locations are supported. Unconvertible directives,
+// such as those using complex regular expressions, variables or "if"
+// blocks, are skipped and reported as a *ParseError wrapping ErrUnsupported
+// with the line they start on.
+//
+// The conversion is best-effort: nginx evaluates rewrites and locations
+// in its own order rather than the order written, so review the result.
+func ImportNginx(r io.Reader) (rules []Rule, skipped []*ParseError, err error) {
+	tokens, err := nginxTokens(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var blocks []nginxBlock
+	var stmt []nginxToken
+
+	for _, tok := range tokens {
+		switch {
+		case tok.text == "{" && !tok.quoted:
+			if len(stmt) == 0 {
+				return nil, nil, fmt.Errorf("line %d: unexpected \"{\"", tok.line)
+			}
+			blocks = append(blocks, nginxBlock{name: stmt[0].text, args: nginxTexts(stmt[1:])})
+			stmt = nil
+
+		case tok.text == "}" && !tok.quoted:
+			if len(blocks) == 0 {
+				return nil, nil, fmt.Errorf("line %d: unexpected \"}\"", tok.line)
+			}
+			blocks = blocks[:len(blocks)-1]
+			stmt = nil
+
+		case tok.text == ";" && !tok.quoted:
+			if len(stmt) == 0 {
+				continue
+			}
+
+			rule, ok, err := nginxRule(nginxTexts(stmt), blocks)
+			if err != nil {
+				skipped = append(skipped, &ParseError{
+					Line: stmt[0].line,
+					Text: strings.Join(nginxTexts(stmt), " ") + ";",
+					Err:  err,
+				})
+			} else if ok {
+				rules = append(rules, rule)
+			}
+			stmt = nil
+
+		default:
+			stmt = append(stmt, tok)
+		}
+	}
+
+	if len(blocks) > 0 {
+		return nil, nil, fmt.Errorf("unclosed %q block", blocks[len(blocks)-1].name)
+	}
+
+	return rules, skipped, nil
+}
+
+// nginxRule returns the rule of a "return" or "rewrite" directive within
+// blocks, and false for other directives.
+func nginxRule(args []string, blocks []nginxBlock) (rule Rule, ok bool, err error) {
+	if args[0] != "return" && args[0] != "rewrite" {
+		return rule, false, nil
+	}
+
+	from, names := "/*", []string{"splat"}
+	prefix := "/"
+	regexp := false
+
+	for _, b := range blocks {
+		switch b.name {
+		case "if":
+			return rule, false, fmt.Errorf("%w: directive in an if block", ErrUnsupported)
+		case "location":
+			if from, names, prefix, regexp, err = nginxLocation(b.args); err != nil {
+				return rule, false, err
+			}
+		}
+	}
+
+	switch args[0] {
+	case "return":
+		return nginxReturn(args[1:], from, names, prefix, regexp)
+	default:
+		return nginxRewrite(args[1:])
+	}
+}
+
+// nginxLocation returns the source pattern of a location block, the prefix
+// it matches for a prefix location and whether it is a regular expression.
+func nginxLocation(args []string) (from string, names []string, prefix string, regexp bool, err error) {
+	switch {
+	case len(args) == 1 && strings.HasPrefix(args[0], "/"):
+		prefix = strings.TrimSuffix(args[0], "/") + "/"
+		return prefix + "*", []string{"splat"}, prefix, false, nil
+	case len(args) == 2 && args[0] == "=":
+		return args[1], nil, "", false, nil
+	case len(args) == 2 && args[0] == "^~":
+		prefix = strings.TrimSuffix(args[1], "/") + "/"
+		return prefix + "*", []string{"splat"}, prefix, false, nil
+	case len(args) == 2 && args[0] == "~":
+		from, names, err = regexpPattern(args[1])
+		return from, names, "", true, err
+	default:
+		return "", nil, "", false, fmt.Errorf("%w: location %s", ErrUnsupported, strings.Join(args, " "))
+	}
+}
+
+// nginxReturn returns the rule of "return code url" within a location.
+func nginxReturn(args []string, from string, names []string, prefix string, regexp bool) (rule Rule, ok bool, err error) {
+	if len(args) != 2 {
+		return rule, false, fmt.Errorf("%w: return without a redirect", ErrUnsupported)
+	}
+
+	code, err := strconv.Atoi(args[0])
+	if err != nil || code < 300 || code > 399 {
+		return rule, false, fmt.Errorf("%w: return %s", ErrUnsupported, args[0])
+	}
+
+	to := args[1]
+
+	for _, v := range []string{"$request_uri", "$uri"} {
+		if !strings.HasSuffix(to, v) {
+			continue
+		}
+
+		if prefix == "" {
+			return rule, false, fmt.Errorf("%w: %s outside a prefix location", ErrUnsupported, v)
+		}
+
+		to = strings.TrimSuffix(strings.TrimSuffix(to, v), "/") + prefix + ":splat"
+		break
+	}
+
+	if regexp {
+		if to, err = regexpReplacement(to, names); err != nil {
+			return rule, false, err
+		}
+	} else if strings.Contains(to, "$") {
+		return rule, false, fmt.Errorf("%w: variable in %q", ErrUnsupported, to)
+	}
+
+	return Rule{From: from, To: to, Status: code}, true, nil
+}
+
+// nginxRewrite returns the rule of "rewrite regex replacement [flag]".
+func nginxRewrite(args []string) (rule Rule, ok bool, err error) {
+	if len(args) < 2 || len(args) > 3 {
+		return rule, false, fmt.Errorf("%w: malformed rewrite", ErrUnsupported)
+	}
+
+	from, names, err := regexpPattern(args[0])
+	if err != nil {
+		return rule, false, err
+	}
+
+	to := strings.TrimSuffix(args[1], "?")
+	if to, err = regexpReplacement(to, names); err != nil {
+		return rule, false, err
+	}
+
+	rule = Rule{From: from, To: to, Status: 200}
+
+	flag := ""
+	if len(args) == 3 {
+		flag = args[2]
+	}
+
+	switch flag {
+	case "permanent":
+		rule.Status = 301
+	case "redirect":
+		rule.Status = 302
+	case "", "last", "break":
+		if isAbsolute(to) {
+			rule.Status = 302
+		}
+	default:
+		return rule, false, fmt.Errorf("%w: rewrite flag %q", ErrUnsupported, flag)
+	}
+
+	return rule, true, nil
+}
+
+// nginxTokens returns the tokens of an nginx configuration.
+func nginxTokens(r io.Reader) (tokens []nginxToken, err error) {
+	br := bufio.NewReader(r)
+	line := 1
+
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, nginxToken{text: word.String(), line: line})
+			word.Reset()
+		}
+	}
+
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			flush()
+			return tokens, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch c {
+		case '\n', ' ', '\t', '\r':
+			flush()
+			if c == '\n' {
+				line++
+			}
+
+		case ';', '{', '}':
+			flush()
+			tokens = append(tokens, nginxToken{text: string(c), line: line})
+
+		case '#':
+			if word.Len() > 0 {
+				word.WriteByte(c)
+				continue
+			}
+			if _, err := br.ReadString('\n'); err != nil && err != io.EOF {
+				return nil, err
+			}
+			line++
+
+		case '"', '\'':
+			flush()
+			start := line
+			s, err := br.ReadString(c)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: unterminated string", start)
+			}
+			line += strings.Count(s, "\n")
+			tokens = append(tokens, nginxToken{text: s[:len(s)-1], line: start, quoted: true})
+
+		default:
+			word.WriteByte(c)
+		}
+	}
+}
+
+// nginxTexts returns the text of tokens.
+func nginxTexts(tokens []nginxToken) []string {
+	v := make([]string, len(tokens))
+	for i, t := range tokens {
+		v[i] = t.text
+	}
+	return v
+}
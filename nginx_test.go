@@ -0,0 +1,87 @@
+package redirects_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/pkg/errors"
+	"github.com/tj/assert"
+)
+
+func TestImportNginx(t *testing.T) {
+	conf := `
+server {
+  listen 80;
+  server_name example.com;
+
+  # Legacy pages
+  rewrite ^/old-page$ /new-page permanent;
+  rewrite ^/blog/([^/]+)/(.*)$ /posts/$1/$2 redirect;
+  rewrite ^/app/(.*)$ /index.html last;
+  rewrite ^/(foo|bar)$ /baz permanent;
+  rewrite ^/search$ /find?q=all? permanent;
+
+  location = /about {
+    return 301 /company;
+  }
+
+  location /docs/ {
+    return 308 https://docs.example.com$request_uri;
+  }
+
+  location ~ ^/item/([^/]+)$ {
+    return 302 /products/$1;
+  }
+
+  location /legacy {
+    if ($http_user_agent ~ bot) {
+      return 301 /bots;
+    }
+    return 301 "/archive#top";
+  }
+
+  location /health {
+    return 200 "ok";
+  }
+}
+`
+
+	rules, skipped, err := redirects.ImportNginx(strings.NewReader(conf))
+	assert.NoError(t, err)
+
+	var lines []string
+	for _, r := range rules {
+		lines = append(lines, r.String())
+	}
+
+	assert.Equal(t, []string{
+		"/old-page /new-page 301",
+		"/blog/:p1/* /posts/:p1/:splat 302",
+		"/app/* /index.html 200",
+		"/search /find?q=all 301",
+		"/about /company 301",
+		"/docs/* https://docs.example.com/docs/:splat 308",
+		"/item/:p1 /products/:p1 302",
+		"/legacy/* /archive#top 301",
+	}, lines)
+
+	assert.Len(t, skipped, 3)
+
+	for _, s := range skipped {
+		assert.True(t, errors.Is(s, redirects.ErrUnsupported))
+	}
+
+	assert.Equal(t, `line 10: unsupported: group "(foo|bar)" in "^/(foo|bar)$": "rewrite ^/(foo|bar)$ /baz permanent;"`, skipped[0].Error())
+	assert.Equal(t, 27, skipped[1].Line)
+	assert.Equal(t, "return 301 /bots;", skipped[1].Text)
+	assert.Equal(t, `line 33: unsupported: return 200: "return 200 ok;"`, skipped[2].Error())
+}
+
+func TestImportNginx_malformed(t *testing.T) {
+	_, _, err := redirects.ImportNginx(strings.NewReader("server {\n  return 301 /;\n"))
+	assert.EqualError(t, err, `unclosed "server" block`)
+
+	_, _, err = redirects.ImportNginx(strings.NewReader("}\n"))
+	assert.EqualError(t, err, `line 1: unexpected "}"`)
+}
@@ -0,0 +1,289 @@
+package redirects
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"reflect"
+	"strings"
+)
+
+// OptimizationKind is the kind of change made by Optimize.
+type OptimizationKind string
+
+// Optimization kinds.
+const (
+	// RemoveDuplicate removes a rule identical to an earlier rule.
+	RemoveDuplicate OptimizationKind = "duplicate"
+
+	// CollapseChain points a redirect directly at the final destination
+	// of the redirect it leads to.
+	CollapseChain OptimizationKind = "chain"
+
+	// MergeWildcard replaces adjacent rules with a single wildcard rule,
+	// see WithWildcardMerges.
+	MergeWildcard OptimizationKind = "merge"
+)
+
+// An Optimization describes a single change made by Optimize.
+type Optimization struct {
+	// Kind of change.
+	Kind OptimizationKind
+
+	// Rules are the indexes of the input rules involved.
+	Rules []int
+
+	// Result is the replacement rule, or the zero value for removals.
+	Result Rule
+}
+
+// String returns a description of the change for review.
+func (o Optimization) String() string {
+	switch o.Kind {
+	case RemoveDuplicate:
+		return fmt.Sprintf("removed rule %d, a duplicate of rule %d", o.Rules[1], o.Rules[0])
+	case CollapseChain:
+		return fmt.Sprintf("collapsed chain through rules %v into %q", o.Rules, o.Result)
+	case MergeWildcard:
+		return fmt.Sprintf("merged rules %v into %q", o.Rules, o.Result)
+	default:
+		return string(o.Kind)
+	}
+}
+
+// indexed is a rule along with its index in the input.
+type indexed struct {
+	Rule
+	index int
+}
+
+// An OptimizeOption configures Optimize.
+type OptimizeOption func(*optimizer)
+
+// optimizer is the configuration of Optimize.
+type optimizer struct {
+	merge bool
+}
+
+// WithWildcardMerges replaces adjacent rules such as /a/x → /b/x and
+// /a/y → /b/y with /a/* → /b/:splat. The merged rule also matches every
+// other path under /a, so the result is no longer equivalent: merges are
+// only made when no other rule falls under the directory, and never for
+// rules at the root, which would become a catch-all.
+func WithWildcardMerges() OptimizeOption {
+	return func(o *optimizer) {
+		o.merge = true
+	}
+}
+
+// Optimize returns a smaller rule set, see OptimizeReport.
+func Optimize(rules []Rule, opts ...OptimizeOption) []Rule {
+	v, _ := OptimizeReport(rules, opts...)
+	return v
+}
+
+// OptimizeReport returns a smaller equivalent rule set along with each
+// change made, for review:
+//
+// - exact duplicates of an earlier rule are removed
+// - redirect chains such as /a → /b, /b → /c become /a → /c
+//
+// Chains are only collapsed between unconditional redirects with the same
+// status, when the rule redirected to is the first whose source matches the
+// destination. Adjacent rules are also merged into wildcard rules when
+// configured with WithWildcardMerges, which broadens the matched paths.
+func OptimizeReport(rules []Rule, opts ...OptimizeOption) ([]Rule, []Optimization) {
+	var o optimizer
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var report []Optimization
+
+	list := make([]indexed, len(rules))
+	for i, r := range rules {
+		list[i] = indexed{Rule: r, index: i}
+	}
+
+	list = removeDuplicates(list, &report)
+	list = collapseChains(list, &report)
+	if o.merge {
+		list = mergeWildcards(list, &report)
+	}
+
+	out := make([]Rule, len(list))
+	for i, r := range list {
+		out[i] = r.Rule
+	}
+
+	return out, report
+}
+
+// removeDuplicates removes rules identical to an earlier rule, whether or
+// not their status was written.
+func removeDuplicates(list []indexed, report *[]Optimization) (out []indexed) {
+	for _, r := range list {
+		dup := false
+
+		for _, o := range out {
+			a, b := o.Rule, r.Rule
+			a.StatusImplied, b.StatusImplied = false, false
+			if reflect.DeepEqual(a, b) {
+				*report = append(*report, Optimization{
+					Kind:  RemoveDuplicate,
+					Rules: []int{o.index, r.index},
+				})
+				dup = true
+				break
+			}
+		}
+
+		if !dup {
+			out = append(out, r)
+		}
+	}
+
+	return
+}
+
+// collapseChains points redirects leading to another redirect at its destination.
+func collapseChains(list []indexed, report *[]Optimization) []indexed {
+	for i := range list {
+		chain := []int{list[i].index}
+		seen := map[string]bool{list[i].From: true, list[i].To: true}
+
+		for {
+			next := chained(list, list[i].Rule)
+			if next == nil || seen[next.To] {
+				break
+			}
+
+			seen[next.To] = true
+			chain = append(chain, next.index)
+			list[i].To = next.To
+		}
+
+		if len(chain) > 1 {
+			*report = append(*report, Optimization{
+				Kind:   CollapseChain,
+				Rules:  chain,
+				Result: list[i].Rule,
+			})
+		}
+	}
+
+	return list
+}
+
+// chained returns the rule r redirects to when it is safe to skip it, which
+// is only when it is the first rule whose source matches the destination.
+func chained(list []indexed, r Rule) *indexed {
+	if !isChainable(r) || placeholder.MatchString(r.To) || r.IsProxy() {
+		return nil
+	}
+
+	u, err := url.Parse(r.To)
+	if err != nil || u.RawQuery != "" {
+		return nil
+	}
+
+	req := Request{Host: u.Host, Path: u.Path}
+
+	for i, o := range list {
+		if !matchSource(&list[i].Rule, req) {
+			continue
+		}
+
+		if o.From != r.To || !isChainable(o.Rule) || o.Status != r.Status || o.Force != r.Force || isPattern(o.From) {
+			return nil
+		}
+
+		return &list[i]
+	}
+
+	return nil
+}
+
+// isChainable returns true if r is an unconditional redirect.
+func isChainable(r Rule) bool {
+	return r.Status >= 300 && r.Status < 400 && conditions(r) == 0
+}
+
+// mergeWildcards replaces runs of adjacent rules sharing a source and
+// destination prefix with a single wildcard rule.
+func mergeWildcards(list []indexed, report *[]Optimization) (out []indexed) {
+	for i := 0; i < len(list); {
+		j := i + 1
+		merged, ok := mergeable(list[i].Rule)
+
+		if ok {
+			for j < len(list) {
+				m, ok := mergeable(list[j].Rule)
+				if !ok || !reflect.DeepEqual(m, merged) || list[j].Status != list[i].Status || list[j].Force != list[i].Force {
+					break
+				}
+				j++
+			}
+		}
+
+		if !ok || j-i < 2 || shadowsAny(merged, list[:i]) || shadowsAny(merged, list[j:]) {
+			out = append(out, list[i])
+			i++
+			continue
+		}
+
+		merged.Status = list[i].Status
+		merged.Force = list[i].Force
+
+		var merges []int
+		for _, r := range list[i:j] {
+			merges = append(merges, r.index)
+		}
+
+		*report = append(*report, Optimization{
+			Kind:   MergeWildcard,
+			Rules:  merges,
+			Result: merged,
+		})
+
+		out = append(out, indexed{Rule: merged, index: list[i].index})
+		i = j
+	}
+
+	return
+}
+
+// mergeable returns the wildcard rule r could be merged into, which is
+// possible when the last segment of the source and destination are equal
+// and the source is not at the root.
+func mergeable(r Rule) (Rule, bool) {
+	if conditions(r) > 0 || isPattern(r.From) || placeholder.MatchString(r.To) || !strings.HasPrefix(r.From, "/") {
+		return Rule{}, false
+	}
+
+	dir, name := path.Split(r.From)
+	if name == "" || dir == "/" || !strings.HasSuffix(r.To, "/"+name) {
+		return Rule{}, false
+	}
+
+	return Rule{
+		From: dir + "*",
+		To:   strings.TrimSuffix(r.To, name) + ":splat",
+	}, true
+}
+
+// shadowsAny returns true if r covers any of the rules in list.
+func shadowsAny(r Rule, list []indexed) bool {
+	for _, o := range list {
+		if covers(r, o.Rule) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isPattern returns true if the path contains placeholders or a wildcard.
+func isPattern(p string) bool {
+	return strings.Contains(p, "*") || placeholder.MatchString(p)
+}
@@ -0,0 +1,129 @@
+package redirects_test
+
+import (
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestOptimize(t *testing.T) {
+	t.Run("duplicates", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/a /b
+			/c /d 302
+			/a /b 301
+		`))
+
+		out, report := redirects.OptimizeReport(rules)
+		assert.Equal(t, rules[:2], out)
+		assert.Len(t, report, 1)
+		assert.Equal(t, redirects.RemoveDuplicate, report[0].Kind)
+		assert.Equal(t, []int{0, 2}, report[0].Rules)
+		assert.Equal(t, "removed rule 2, a duplicate of rule 0", report[0].String())
+	})
+
+	t.Run("chains", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/a /b
+			/b /c
+			/c /d
+		`))
+
+		out, report := redirects.OptimizeReport(rules)
+		assert.Equal(t, "/d", out[0].To)
+		assert.Equal(t, "/d", out[1].To)
+		assert.Equal(t, "/d", out[2].To)
+		assert.Len(t, report, 2)
+		assert.Equal(t, redirects.CollapseChain, report[0].Kind)
+		assert.Equal(t, []int{0, 1, 2}, report[0].Rules)
+		assert.Equal(t, `collapsed chain through rules [0 1 2] into "/a /d 301"`, report[0].String())
+	})
+
+	t.Run("chains with differing status", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/a /b 301
+			/b /c 302
+		`))
+
+		assert.Equal(t, rules, redirects.Optimize(rules))
+	})
+
+	t.Run("chains through rewrites and conditions", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/a /b
+			/b /c 200
+			/x /y
+			/y /z 301 Country=au
+		`))
+
+		assert.Equal(t, rules, redirects.Optimize(rules))
+	})
+
+	t.Run("chains through an earlier pattern", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/a /b 301
+			/:page /pages/:page 301
+			/b /c 301
+		`))
+
+		assert.Equal(t, rules, redirects.Optimize(rules))
+	})
+
+	t.Run("cycles", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/a /b
+			/b /a
+		`))
+
+		assert.Equal(t, rules, redirects.Optimize(rules))
+	})
+
+	t.Run("merges", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/about /team
+			/old/a /new/a
+			/old/b /new/b
+			/old/c /new/c
+			/other /else
+		`))
+
+		assert.Equal(t, rules, redirects.Optimize(rules))
+
+		out, report := redirects.OptimizeReport(rules, redirects.WithWildcardMerges())
+		assert.Equal(t, []string{"/about", "/old/*", "/other"}, froms(out))
+		assert.Equal(t, "/new/:splat", out[1].To)
+		assert.Len(t, report, 1)
+		assert.Equal(t, redirects.MergeWildcard, report[0].Kind)
+		assert.Equal(t, []int{1, 2, 3}, report[0].Rules)
+	})
+
+	t.Run("merges that would shadow later rules", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/old/a /new/a
+			/old/b /new/b
+			/old/c /elsewhere
+		`))
+
+		assert.Equal(t, rules, redirects.Optimize(rules, redirects.WithWildcardMerges()))
+	})
+
+	t.Run("merges under a directory with other rules", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/old/z /elsewhere
+			/old/a /new/a
+			/old/b /new/b
+		`))
+
+		assert.Equal(t, rules, redirects.Optimize(rules, redirects.WithWildcardMerges()))
+	})
+
+	t.Run("merges at the root", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/x /new/x 301
+			/y /new/y 301
+		`))
+
+		assert.Equal(t, rules, redirects.Optimize(rules, redirects.WithWildcardMerges()))
+	})
+}
@@ -0,0 +1,311 @@
+package redirects
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+// An Option configures parsing. Parse without options accepts the rules
+// Netlify documents, failing at the first invalid line.
+type Option func(*config)
+
+// A Dialect is a variant of the _redirects format, see WithDialect.
+type Dialect int
+
+// Dialects.
+const (
+	// DialectNetlify is the format as Netlify documents it, the default.
+	DialectNetlify Dialect = iota
+
+	// DialectExtended enables every extension to the format: regular
+	// expression sources, extended wildcards and exclusion rules.
+	DialectExtended
+
+	// DialectIPFS restricts rules to those IPFS gateways support, as
+	// WithIPFSGateway does.
+	DialectIPFS
+)
+
+// UnknownOptionPolicy is how the parser treats unknown conditions.
+type UnknownOptionPolicy int
+
+// Unknown option policies.
+const (
+	// UnknownOptionError fails parsing, the default.
+	UnknownOptionError UnknownOptionPolicy = iota
+
+	// UnknownOptionWarn skips the condition and reports a warning.
+	UnknownOptionWarn
+
+	// UnknownOptionIgnore skips the condition silently.
+	UnknownOptionIgnore
+)
+
+// config is the parser configuration.
+type config struct {
+	allowedProxyHosts   []string
+	unknownOptions      UnknownOptionPolicy
+	warn                func(error)
+	regexRules          bool
+	extendedWildcards   bool
+	exclusionRules      bool
+	ipfs                bool
+	lenient             bool
+	maxRules            int
+	maxLineLength       int
+	maxDecompressedSize int64
+	decompressors       []Decompressor
+	env                 func(string) (string, bool)
+	placeholderStyles   []PlaceholderStyle
+	defaultStatus       int
+	schemes             []string
+
+	// section is called with the name and line number of each section
+	// comment and the index of the rule following it, see ParseDocument.
+	section func(name string, line, index int) error
+}
+
+// newConfig returns the configuration with opts applied.
+func newConfig(opts []Option) *config {
+	c := &config{}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	return c
+}
+
+// WithAllowedProxyHosts rejects proxy rules whose destination host is not one
+// of hosts, preventing a compromised file from turning the server into an
+// open proxy. Hosts may use a leading wildcard such as "*.example.com".
+func WithAllowedProxyHosts(hosts ...string) Option {
+	return func(c *config) {
+		c.allowedProxyHosts = hosts
+	}
+}
+
+// WithSchemes sets the schemes absolute destination URLs may use, defaulting
+// to http, https, ws and wss, such as adding "ftp" and "mailto" for sites
+// linking to them. The javascript, vbscript and data schemes are rejected
+// regardless, and only http, https, ws and wss destinations are proxied.
+func WithSchemes(schemes ...string) Option {
+	return func(c *config) {
+		c.schemes = schemes
+	}
+}
+
+// WithUnknownOptionPolicy sets how conditions this package does not know,
+// such as Netlify's Role or Signed conditions, are treated. Skipped
+// conditions are removed from the rule, which may broaden its matches.
+func WithUnknownOptionPolicy(p UnknownOptionPolicy) Option {
+	return func(c *config) {
+		c.unknownOptions = p
+	}
+}
+
+// WithWarnings calls fn with a *ParseError for each problem which was
+// skipped rather than failing parsing, and for likely mistakes which do not
+// fail it, such as a source placeholder the destination does not use.
+func WithWarnings(fn func(error)) Option {
+	return func(c *config) {
+		c.warn = fn
+	}
+}
+
+// WithRegexRules enables sources written as a regular expression prefixed
+// with "~", such as "~^/blog/(\d+)$", whose destination may reference
+// groups as $1 or ${name}. Expressions use RE2 syntax and match the path.
+func WithRegexRules() Option {
+	return func(c *config) {
+		c.regexRules = true
+	}
+}
+
+// WithExtendedWildcards enables "**" segments, matching zero or more
+// segments, and "*" segments before the end of the source path, matching
+// exactly one. When a source has several wildcards their captures are
+// numbered as :splat1, :splat2 and so on, with :splat the last.
+func WithExtendedWildcards() Option {
+	return func(c *config) {
+		c.extendedWildcards = true
+	}
+}
+
+// WithExclusionRules enables sources prefixed with "!", optionally followed
+// by conditions but no destination, such as "!/api/health". When an
+// exclusion is the first rule matching a request no rule applies, so it
+// exempts paths from the broader rules following it.
+func WithExclusionRules() Option {
+	return func(c *config) {
+		c.exclusionRules = true
+	}
+}
+
+// WithDefaultStatus sets the status of rules written without one, such as
+// 302 for teams whose policy is temporary redirects by default. Defaults to
+// 301. Such rules have StatusImplied set.
+func WithDefaultStatus(code int) Option {
+	return func(c *config) {
+		c.defaultStatus = code
+	}
+}
+
+// status returns the status of rules written without one.
+func (c *config) status() int {
+	if c.defaultStatus == 0 {
+		return 301
+	}
+
+	return c.defaultStatus
+}
+
+// WithDialect configures parsing for a variant of the format, in place of
+// enabling its options individually.
+func WithDialect(d Dialect) Option {
+	return func(c *config) {
+		switch d {
+		case DialectExtended:
+			c.regexRules = true
+			c.extendedWildcards = true
+			c.exclusionRules = true
+		case DialectIPFS:
+			c.ipfs = true
+		}
+	}
+}
+
+// WithLenient skips invalid rules and annotations, reporting them to the
+// function given to WithWarnings, rather than failing parsing. Limits and
+// duplicate sections still fail parsing.
+func WithLenient() Option {
+	return func(c *config) {
+		c.lenient = true
+	}
+}
+
+// WithMaxRules fails parsing files with more than n rules, bounding the
+// memory of files from untrusted sources.
+func WithMaxRules(n int) Option {
+	return func(c *config) {
+		c.maxRules = n
+	}
+}
+
+// WithMaxLineLength fails parsing files with a line longer than n bytes.
+// Lines are limited to 64KiB regardless.
+func WithMaxLineLength(n int) Option {
+	return func(c *config) {
+		c.maxLineLength = n
+	}
+}
+
+// WithEnv expands references such as ${env:API_HOST} in rules with the
+// value returned by lookup, such as os.LookupEnv, so a file may be shared
+// between environments. References lookup does not define fail parsing.
+// Without this option references are left as is.
+func WithEnv(lookup func(name string) (string, bool)) Option {
+	return func(c *config) {
+		c.env = lookup
+	}
+}
+
+// envReference matches a reference to an environment variable.
+var envReference = regexp.MustCompile(`\$\{env:([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv returns line with its environment variable references
+// expanded, or an error for the first which is not defined.
+func (c *config) expandEnv(line string) (string, error) {
+	if c.env == nil {
+		return line, nil
+	}
+
+	var err error
+	line = envReference.ReplaceAllStringFunc(line, func(ref string) string {
+		name := envReference.FindStringSubmatch(ref)[1]
+		v, ok := c.env(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("%w %q", ErrUndefinedVariable, name)
+		}
+		return v
+	})
+
+	return line, err
+}
+
+// skip returns true if the invalid line of err is skipped rather than
+// failing parsing, reporting it as a warning.
+func (c *config) skip(err *ParseError) bool {
+	if !c.lenient {
+		return false
+	}
+
+	if c.warn != nil {
+		c.warn(err)
+	}
+
+	return true
+}
+
+// checkRule returns the problems with r, both as a rule and under the
+// configuration, the most fundamental first.
+func (c *config) checkRule(r Rule) (errs []error) {
+	if err := c.checkExclusion(r); err != nil {
+		errs = append(errs, err)
+	}
+
+	errs = append(errs, validateRule(r, c.schemes)...)
+
+	for _, check := range []func(Rule) error{c.checkRegexp, c.checkWildcards, c.checkProxy, c.checkIPFS} {
+		if err := check(r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return
+}
+
+// checkRegexp returns an error if r has a regular expression source
+// without regex rules being enabled.
+func (c *config) checkRegexp(r Rule) error {
+	if !c.regexRules && isRegexp(sourcePattern(r.From)) {
+		return errorf(CodeExtensionRequired, "regular expression sources require WithRegexRules")
+	}
+
+	return nil
+}
+
+// checkWildcards returns an error if r has a "**" or mid-path wildcard
+// without extended wildcards being enabled.
+func (c *config) checkWildcards(r Rule) error {
+	if !c.extendedWildcards && extendedWildcard(sourcePattern(r.From)) {
+		return errorf(CodeExtensionRequired, "double and mid-path wildcards require WithExtendedWildcards")
+	}
+
+	return nil
+}
+
+// checkExclusion returns an error if r is an exclusion rule without
+// exclusion rules being enabled.
+func (c *config) checkExclusion(r Rule) error {
+	if !c.exclusionRules && isExclusion(r.From) {
+		return errorf(CodeExtensionRequired, "exclusion rules require WithExclusionRules")
+	}
+
+	return nil
+}
+
+// checkProxy returns an error if r proxies to a host which is not allowed.
+func (c *config) checkProxy(r Rule) error {
+	if len(c.allowedProxyHosts) == 0 || !r.IsRewrite() || !r.IsProxy() {
+		return nil
+	}
+
+	u, _ := url.Parse(r.To)
+	if !hostAllowed(u.Hostname(), c.allowedProxyHosts) {
+		return errorf(CodeProxyNotAllowed, "proxy host %q is not allowed", u.Hostname())
+	}
+
+	return nil
+}
@@ -0,0 +1,313 @@
+package redirects_test
+
+import (
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/pkg/errors"
+	"github.com/tj/assert"
+)
+
+func TestWithAllowedProxyHosts(t *testing.T) {
+	opt := redirects.WithAllowedProxyHosts("api.example.com", "*.cdn.example.com")
+
+	t.Run("allowed", func(t *testing.T) {
+		rules, err := redirects.ParseString(`
+			/api/*  https://api.example.com/:splat  200
+			/cdn/*  https://eu.cdn.example.com/:splat  200
+			/home   /
+			/google https://www.google.com
+		`, opt)
+
+		assert.NoError(t, err)
+		assert.Len(t, rules, 4)
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		_, err := redirects.ParseString(`/api/*  https://evil.example/:splat  200`, opt)
+		assert.EqualError(t, err, `line 1: proxy host "evil.example" is not allowed: "/api/*  https://evil.example/:splat  200"`)
+	})
+}
+
+func TestWithSchemes(t *testing.T) {
+	opt := redirects.WithSchemes("https", "ftp", "mailto")
+
+	t.Run("allowed", func(t *testing.T) {
+		rules, err := redirects.ParseString(`
+			/files/*  ftp://files.example.com/:splat
+			/contact  mailto:team@example.com  302
+			/home     https://example.com
+		`, opt)
+
+		assert.NoError(t, err)
+		assert.Len(t, rules, 3)
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		_, err := redirects.ParseString(`/home  http://example.com`, opt)
+		assert.EqualError(t, err, `line 1: invalid destination path: scheme "http" is not allowed: "/home  http://example.com"`)
+
+		_, err = redirects.ParseString(`/x  javascript:alert(1)`, redirects.WithSchemes("javascript"))
+		assert.Equal(t, redirects.CodeInvalidDestination, redirects.ErrorCode(err))
+	})
+
+	t.Run("proxied", func(t *testing.T) {
+		_, err := redirects.ParseString(`/files/*  ftp://files.example.com/:splat  200`, opt)
+		assert.EqualError(t, err, `line 1: ftp destinations can not be proxied: "/files/*  ftp://files.example.com/:splat  200"`)
+	})
+}
+
+func TestWithUnknownOptionPolicy(t *testing.T) {
+	const input = `
+		/admin/*  /login  302  Role=admin  Country=us
+		/docs     /guide
+	`
+
+	t.Run("error", func(t *testing.T) {
+		_, err := redirects.ParseString(input, redirects.WithUnknownOptionPolicy(redirects.UnknownOptionError))
+		assert.True(t, errors.Is(err, redirects.ErrUnknownCondition))
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		var warnings []error
+		rules, err := redirects.ParseString(input,
+			redirects.WithUnknownOptionPolicy(redirects.UnknownOptionWarn),
+			redirects.WithWarnings(func(err error) {
+				warnings = append(warnings, err)
+			}))
+
+		assert.NoError(t, err)
+		assert.Len(t, rules, 2)
+		assert.Equal(t, []string{"us"}, rules[0].Country)
+		assert.Len(t, warnings, 1)
+		assert.EqualError(t, warnings[0], `line 2: unknown condition "Role": "/admin/*  /login  302  Role=admin  Country=us"`)
+		assert.True(t, errors.Is(warnings[0], redirects.ErrUnknownCondition))
+	})
+
+	t.Run("ignore", func(t *testing.T) {
+		var warnings []error
+		rules, err := redirects.ParseString(input,
+			redirects.WithUnknownOptionPolicy(redirects.UnknownOptionIgnore),
+			redirects.WithWarnings(func(err error) {
+				warnings = append(warnings, err)
+			}))
+
+		assert.NoError(t, err)
+		assert.Len(t, rules, 2)
+		assert.Empty(t, warnings)
+	})
+}
+
+func TestWithRegexRules(t *testing.T) {
+	const input = `~^/blog/(\d+)$  /posts/$1  301`
+
+	t.Run("disabled", func(t *testing.T) {
+		_, err := redirects.ParseString(input)
+		assert.EqualError(t, err, `line 1: regular expression sources require WithRegexRules: "~^/blog/(\\d+)$  /posts/$1  301"`)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		rules, err := redirects.ParseString(input, redirects.WithRegexRules())
+		assert.NoError(t, err)
+		assert.Equal(t, `~^/blog/(\d+)$`, rules[0].From)
+		assert.Equal(t, `~^/blog/(\d+)$ /posts/$1 301`, rules[0].String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := redirects.ParseString(`~^/blog/(\d+$  /posts/$1`, redirects.WithRegexRules())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid source regular expression")
+	})
+}
+
+func TestWithExtendedWildcards(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		_, err := redirects.ParseString(`/docs/*/images/*  /assets/:splat2`)
+		assert.EqualError(t, err, `line 1: double and mid-path wildcards require WithExtendedWildcards: "/docs/*/images/*  /assets/:splat2"`)
+
+		_, err = redirects.ParseString(`/docs/**  /assets/:splat`)
+		assert.Error(t, err)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		rules, err := redirects.ParseString(`/docs/*/images/*  /assets/:splat2`, redirects.WithExtendedWildcards())
+		assert.NoError(t, err)
+		assert.Equal(t, `/docs/*/images/* /assets/:splat2 301`, rules[0].String())
+	})
+
+	t.Run("unbound splat", func(t *testing.T) {
+		_, err := redirects.ParseString(`/docs/*/images/*  /assets/:splat3`, redirects.WithExtendedWildcards())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "destination placeholder :splat3 is not bound")
+	})
+}
+
+func TestWithExclusionRules(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		_, err := redirects.ParseString(`!/api/health  /x`)
+		assert.EqualError(t, err, `line 1: exclusion rules require WithExclusionRules: "!/api/health  /x"`)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		rules, err := redirects.ParseString(`!/api/health  Country=us`, redirects.WithExclusionRules())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"us"}, rules[0].Country)
+		assert.Equal(t, `!/api/health Country=us`, rules[0].String())
+	})
+
+	t.Run("destination", func(t *testing.T) {
+		_, err := redirects.ParseString(`!/api/health  /x`, redirects.WithExclusionRules())
+		assert.Error(t, err)
+	})
+}
+
+func TestWithDialect(t *testing.T) {
+	const input = `
+		!/api/health
+		/docs/**/old  /docs/new
+		~^/p/(\d+)$  /posts/$1
+	`
+
+	t.Run("netlify", func(t *testing.T) {
+		_, err := redirects.ParseString(input, redirects.WithDialect(redirects.DialectNetlify))
+		assert.Error(t, err)
+	})
+
+	t.Run("extended", func(t *testing.T) {
+		rules, err := redirects.ParseString(input, redirects.WithDialect(redirects.DialectExtended))
+		assert.NoError(t, err)
+		assert.Len(t, rules, 3)
+	})
+
+	t.Run("ipfs", func(t *testing.T) {
+		_, err := redirects.ParseString(`/a  /b  301!`, redirects.WithDialect(redirects.DialectIPFS))
+		assert.Equal(t, redirects.CodeIPFSUnsupported, redirects.ErrorCode(err))
+	})
+}
+
+func TestWithDefaultStatus(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString("/a  /b\n/c  /d  301\n"))
+		assert.Equal(t, 301, rules[0].Status)
+		assert.True(t, rules[0].StatusImplied)
+		assert.False(t, rules[1].StatusImplied)
+		assert.Equal(t, "/a /b 301", rules[0].String())
+		assert.Equal(t, "/a /b\n/c /d 301\n", string(redirects.Marshal(rules)))
+	})
+
+	t.Run("forced", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString("/a  /b  !  Country=au\n"))
+		assert.Equal(t, 301, rules[0].Status)
+		assert.True(t, rules[0].Force)
+		assert.True(t, rules[0].StatusImplied)
+		assert.Equal(t, []string{"au"}, rules[0].Country)
+		assert.Equal(t, "/a /b 301! Country=au", rules[0].String())
+		assert.Equal(t, "/a /b ! Country=au\n", string(redirects.Marshal(rules)))
+
+		rules, err := redirects.ParseString("/a  /b  !\n", redirects.WithDefaultStatus(302))
+		assert.NoError(t, err)
+		assert.Equal(t, "/a /b 302!\n", string(redirects.Marshal(rules)))
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		rules, err := redirects.ParseString("/a  /b\n/c  /d  301\n", redirects.WithDefaultStatus(302))
+		assert.NoError(t, err)
+		assert.Equal(t, 302, rules[0].Status)
+		assert.True(t, rules[0].StatusImplied)
+		assert.Equal(t, 301, rules[1].Status)
+		assert.Equal(t, "/a /b 302\n/c /d 301\n", string(redirects.Marshal(rules)))
+	})
+}
+
+func TestWithLenient(t *testing.T) {
+	var warnings []error
+	rules, err := redirects.ParseString(`
+		/home  /
+		#@ owner
+		/news
+		/a  /b  3!01
+		#@ owner=seo
+		/blog  /posts
+	`, redirects.WithLenient(), redirects.WithWarnings(func(err error) {
+		warnings = append(warnings, err)
+	}))
+
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2)
+	assert.Equal(t, "/blog", rules[1].From)
+	assert.Equal(t, map[string]string{"owner": "seo"}, rules[1].Meta)
+	assert.Len(t, warnings, 3)
+	assert.True(t, errors.Is(warnings[0], redirects.ErrInvalidAnnotation))
+	assert.True(t, errors.Is(warnings[1], redirects.ErrMissingDestination))
+	assert.True(t, errors.Is(warnings[2], redirects.ErrInvalidStatus))
+}
+
+func TestWithMaxRules(t *testing.T) {
+	const input = "/a  /b\n# comment\n/c  /d\n/e  /f\n"
+
+	_, err := redirects.ParseString(input, redirects.WithMaxRules(3))
+	assert.NoError(t, err)
+
+	_, err = redirects.ParseString(input, redirects.WithMaxRules(2), redirects.WithLenient())
+	assert.EqualError(t, err, `line 4: too many rules, more than 2: "/e  /f"`)
+	assert.True(t, errors.Is(err, redirects.ErrTooManyRules))
+}
+
+func TestWithMaxLineLength(t *testing.T) {
+	_, err := redirects.ParseString("/a  /b\n/long  /destination\n", redirects.WithMaxLineLength(10))
+	assert.EqualError(t, err, `line 2: line too long, longer than 10 bytes: "/long  /destination"`)
+	assert.Equal(t, redirects.CodeLineTooLong, redirects.ErrorCode(err))
+}
+
+func TestWithEnv(t *testing.T) {
+	env := map[string]string{"API_HOST": "api.example.com"}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	t.Run("defined", func(t *testing.T) {
+		rules, err := redirects.ParseString(`/api/*  https://${env:API_HOST}/:splat  200`, redirects.WithEnv(lookup))
+		assert.NoError(t, err)
+		assert.Equal(t, "https://api.example.com/:splat", rules[0].To)
+	})
+
+	t.Run("undefined", func(t *testing.T) {
+		_, err := redirects.ParseString(`/cdn/*  https://${env:CDN_HOST}/:splat  200`, redirects.WithEnv(lookup))
+		assert.EqualError(t, err, `line 1: undefined variable "CDN_HOST": "/cdn/*  https://${env:CDN_HOST}/:splat  200"`)
+		assert.Equal(t, redirects.CodeUndefinedVariable, redirects.ErrorCode(err))
+	})
+
+	t.Run("regexp groups", func(t *testing.T) {
+		rules, err := redirects.ParseString(`~^/p/(?P<id>\d+)$  https://${env:API_HOST}/posts/${id}`, redirects.WithEnv(lookup), redirects.WithRegexRules())
+		assert.NoError(t, err)
+		assert.Equal(t, "https://api.example.com/posts/${id}", rules[0].To)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		env["EMPTY"] = ""
+		defer delete(env, "EMPTY")
+
+		rules, err := redirects.ParseString("#@ owner=web\n${env:EMPTY}\n  ${env:EMPTY} ${env:EMPTY}\n/home  /\n", redirects.WithEnv(lookup))
+		assert.NoError(t, err)
+		assert.Len(t, rules, 1)
+		assert.Equal(t, "/home", rules[0].From)
+		assert.Nil(t, rules[0].Meta)
+	})
+}
+
+func TestWithWarnings(t *testing.T) {
+	var warnings []error
+	rules, err := redirects.ParseString(`
+		/blog/:year/:slug  /posts/:slug
+		/docs/:page  /guide/:page
+	`, redirects.WithWarnings(func(err error) {
+		warnings = append(warnings, err)
+	}))
+
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2)
+	assert.Len(t, warnings, 1)
+	assert.EqualError(t, warnings[0], `line 2: placeholder ":year" is not used by the destination: "/blog/:year/:slug  /posts/:slug"`)
+	assert.Equal(t, redirects.CodeUnusedPlaceholder, redirects.ErrorCode(warnings[0]))
+}
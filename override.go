@@ -0,0 +1,55 @@
+package redirects
+
+import (
+	"net/url"
+)
+
+// Overrides are the query parameters overriding the visitor's country and
+// languages, as with Netlify's ?_country=de&_lang=de, so rules with Country
+// and Language conditions may be tested without a VPN.
+type Overrides struct {
+	// Country is the parameter holding a country code. Defaults to "_country".
+	Country string
+
+	// Language is the parameter holding comma separated languages, most
+	// preferred first. Defaults to "_lang".
+	Language string
+}
+
+// Apply returns req with the country and languages of the override
+// parameters present in its query. The parameters are removed from the
+// query, so they are neither matched by params nor passed through to
+// destinations.
+func (o Overrides) Apply(req Request) Request {
+	country := o.Country
+	if country == "" {
+		country = "_country"
+	}
+
+	language := o.Language
+	if language == "" {
+		language = "_lang"
+	}
+
+	if !req.Query.Has(country) && !req.Query.Has(language) {
+		return req
+	}
+
+	query := make(url.Values, len(req.Query))
+	for k, v := range req.Query {
+		query[k] = v
+	}
+
+	if query.Has(country) {
+		req.Country = query.Get(country)
+		query.Del(country)
+	}
+
+	if query.Has(language) {
+		req.Language = parseAcceptLanguage(query.Get(language))
+		query.Del(language)
+	}
+
+	req.Query = query
+	return req
+}
@@ -0,0 +1,35 @@
+package redirects_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestOverrides_Apply(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		query := url.Values{"_country": {"de"}, "_lang": {"de,en"}, "page": {"2"}}
+		req := redirects.Overrides{}.Apply(redirects.Request{Path: "/", Query: query, Country: "nz", Language: []string{"en"}})
+		assert.Equal(t, "de", req.Country)
+		assert.Equal(t, []string{"de", "en"}, req.Language)
+		assert.Equal(t, url.Values{"page": {"2"}}, req.Query)
+		assert.Len(t, query, 3, "query of the original request")
+	})
+
+	t.Run("names", func(t *testing.T) {
+		o := redirects.Overrides{Country: "geo", Language: "lang"}
+		req := o.Apply(redirects.Request{Query: url.Values{"geo": {"fr"}, "_lang": {"de"}}, Language: []string{"en"}})
+		assert.Equal(t, "fr", req.Country)
+		assert.Equal(t, []string{"en"}, req.Language)
+		assert.Equal(t, url.Values{"_lang": {"de"}}, req.Query)
+	})
+
+	t.Run("none", func(t *testing.T) {
+		query := url.Values{"a": {"b"}}
+		req := redirects.Overrides{}.Apply(redirects.Request{Query: query, Country: "nz"})
+		assert.Equal(t, "nz", req.Country)
+		assert.Equal(t, query, req.Query)
+	})
+}
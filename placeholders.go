@@ -0,0 +1,104 @@
+package redirects
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A PlaceholderStyle is a placeholder syntax of other redirect formats,
+// accepted with WithPlaceholderStyles.
+type PlaceholderStyle int
+
+// Placeholder styles.
+const (
+	// PlaceholderBraces accepts placeholders written as {name}, such as
+	// "/users/{id}  /u/{id}", in sources, destinations and param values.
+	PlaceholderBraces PlaceholderStyle = iota + 1
+
+	// PlaceholderPositional accepts destination references written as $1,
+	// $2 and so on to the placeholders and wildcards of the source in order,
+	// such as "/users/:id/*  /u/$1/$2".
+	PlaceholderPositional
+)
+
+// braces matches a placeholder written as {name}.
+var braces = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// positional matches a positional reference written as $1.
+var positional = regexp.MustCompile(`\$([1-9][0-9]*)`)
+
+// WithPlaceholderStyles accepts placeholders written in the given styles,
+// easing imports from other redirect formats. They are normalized into the
+// :name form, so rules are written back with it. Regular expression sources
+// and their destinations are left as is.
+func WithPlaceholderStyles(styles ...PlaceholderStyle) Option {
+	return func(c *config) {
+		c.placeholderStyles = append(c.placeholderStyles, styles...)
+	}
+}
+
+// normalizePlaceholders returns r with its placeholders in the :name form.
+func (c *config) normalizePlaceholders(r Rule) (Rule, error) {
+	if len(c.placeholderStyles) == 0 || isRegexp(sourcePattern(r.From)) {
+		return r, nil
+	}
+
+	for _, style := range c.placeholderStyles {
+		switch style {
+		case PlaceholderBraces:
+			r.From = braces.ReplaceAllString(r.From, ":$1")
+			r.To = braces.ReplaceAllString(r.To, ":$1")
+
+			for k, v := range r.Params {
+				if s, ok := v.(string); ok {
+					r.Params[k] = braces.ReplaceAllString(s, ":$1")
+				}
+			}
+		case PlaceholderPositional:
+			names := sourceCaptures(r.From)
+
+			var err error
+			r.To = positional.ReplaceAllStringFunc(r.To, func(ref string) string {
+				n, _ := strconv.Atoi(ref[1:])
+				if n > len(names) {
+					if err == nil {
+						err = errorf(CodeUnboundPlaceholder, "destination placeholder %s is not bound by the source path", ref)
+					}
+					return ref
+				}
+				return ":" + names[n-1]
+			})
+
+			if err != nil {
+				return r, err
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// sourceCaptures returns the names of the placeholders and wildcards of the
+// source from, in order.
+func sourceCaptures(from string) (names []string) {
+	ps := segments(sourcePattern(from))
+	total := wildcards(ps)
+	n := 0
+
+	for _, s := range ps {
+		switch {
+		case isWildcard(s):
+			n++
+			if total > 1 {
+				names = append(names, "splat"+strconv.Itoa(n))
+			} else {
+				names = append(names, "splat")
+			}
+		case strings.HasPrefix(s, ":"):
+			names = append(names, placeholder.FindString(s)[1:])
+		}
+	}
+
+	return
+}
@@ -0,0 +1,61 @@
+package redirects_test
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+
+	"github.com/fission-suite/go-redirects"
+)
+
+func TestWithPlaceholderStyles(t *testing.T) {
+	t.Run("braces", func(t *testing.T) {
+		rules, err := redirects.ParseString(`
+			/users/{id}/posts/{slug}  /u/{id}/{slug}
+			/search  q={term}  /find?q={term}  302
+		`, redirects.WithPlaceholderStyles(redirects.PlaceholderBraces))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "/users/:id/posts/:slug /u/:id/:slug\n/search q=:term /find?q=:term 302\n", string(redirects.Marshal(rules)))
+
+		res, ok := redirects.Match(rules, redirects.Request{Path: "/users/7/posts/hello"})
+		assert.True(t, ok)
+		assert.Equal(t, "/u/7/hello", res.To)
+	})
+
+	t.Run("positional", func(t *testing.T) {
+		rules, err := redirects.ParseString(`
+			/users/:id(int)/*  /u/$1/$2
+			/docs/*/images/*   /assets/$1/$2
+		`, redirects.WithPlaceholderStyles(redirects.PlaceholderPositional), redirects.WithExtendedWildcards())
+
+		assert.NoError(t, err)
+		assert.Equal(t, "/u/:id/:splat", rules[0].To)
+		assert.Equal(t, "/assets/:splat1/:splat2", rules[1].To)
+	})
+
+	t.Run("unbound positional", func(t *testing.T) {
+		_, err := redirects.ParseString(`/users/:id  /u/$2`, redirects.WithPlaceholderStyles(redirects.PlaceholderPositional))
+		assert.EqualError(t, err, `line 1: destination placeholder $2 is not bound by the source path: "/users/:id  /u/$2"`)
+		assert.Equal(t, redirects.CodeUnboundPlaceholder, redirects.ErrorCode(err))
+	})
+
+	t.Run("both", func(t *testing.T) {
+		rules, err := redirects.ParseString(`/users/{id}/*  /u/$1/$2`, redirects.WithPlaceholderStyles(redirects.PlaceholderBraces, redirects.PlaceholderPositional))
+		assert.NoError(t, err)
+		assert.Equal(t, "/users/:id/*", rules[0].From)
+		assert.Equal(t, "/u/:id/:splat", rules[0].To)
+	})
+
+	t.Run("regexp", func(t *testing.T) {
+		rules, err := redirects.ParseString(`~^/p/(\d+)$  /posts/$1`, redirects.WithPlaceholderStyles(redirects.PlaceholderPositional), redirects.WithRegexRules())
+		assert.NoError(t, err)
+		assert.Equal(t, "/posts/$1", rules[0].To)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		rules, err := redirects.ParseString(`/price  /cost?amount=$1`)
+		assert.NoError(t, err)
+		assert.Equal(t, "/cost?amount=$1", rules[0].To)
+	})
+}
@@ -5,17 +5,23 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"net/textproto"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
-// Params is a map of key/value pairs.
+// Params is a map of key/value pairs. Params are written, exported and
+// applied in key order, so output does not depend on map iteration.
 type Params map[string]interface{}
 
-const format = "from [a=:save1 b=value] to [code][!]"
+// Conditions is a map of conditions to the value they must have, keyed
+// as written such as "Header:X-Canary" for the X-Canary request header.
+type Conditions map[string]string
 
 // Has returns true if the param is present.
 func (p *Params) Has(key string) bool {
@@ -49,12 +55,18 @@ type Rule struct {
 	//
 	// - 3xx a redirect
 	// - 200 a rewrite
-	// - defaults to 301 redirect
+	// - defaults to 301 redirect, see WithDefaultStatus
 	//
 	// When proxying this field is ignored.
 	//
 	Status int
 
+	// StatusImplied is true when the status was not written in the file
+	// and defaulted, see WithDefaultStatus, telling "/a /b" apart from
+	// "/a /b 301" for formatters and linters. It is not encoded as JSON,
+	// where the status is always written.
+	StatusImplied bool `json:"-"`
+
 	// Force is used to force a rewrite or redirect even
 	// when a response (or static file) is present.
 	Force bool
@@ -69,6 +81,30 @@ type Rule struct {
 	// Language is an optional arbitrary list of redirect options based on lanugage ISO 639-1 codes
 	// source: https://en.wikipedia.org/wiki/List_of_ISO_639-1_codes
 	Language []string
+
+	// Method is an optional list of HTTP methods the rule applies to, such as
+	// POST requests being proxied to an API while GET requests serve content.
+	Method []string
+
+	// Host is an optional list of hosts the rule applies to, so one file can
+	// serve several domains. Hosts may use a leading wildcard such as
+	// "*.example.com" to match any subdomain.
+	Host []string
+
+	// Conditions is an optional map of further conditions which must all be
+	// met, such as request headers.
+	Conditions Conditions
+
+	// ActiveFrom is the optional time from which the rule applies.
+	ActiveFrom time.Time
+
+	// ActiveUntil is the optional time until which the rule applies.
+	ActiveUntil time.Time
+
+	// Meta is optional metadata from annotation comments on the lines above
+	// the rule, such as "#@ owner=seo-team expires=2025-01-01", which does
+	// not affect matching.
+	Meta map[string]string
 }
 
 // IsRewrite returns true if the rule represents a rewrite (status 200).
@@ -76,6 +112,24 @@ func (r *Rule) IsRewrite() bool {
 	return r.Status == 200
 }
 
+// IsContent returns true if the rule responds with the content of its
+// destination rather than redirecting to it, such as a rewrite or a 404,
+// 410 or 451 response.
+func (r *Rule) IsContent() bool {
+	s := r.status()
+	return s < 300 || s >= 400
+}
+
+// status returns the status of the rule, or the default 301 for rules
+// constructed programmatically without one.
+func (r *Rule) status() int {
+	if r.Status == 0 {
+		return 301
+	}
+
+	return r.Status
+}
+
 // IsProxy returns true if it's a proxy rule (aka contains a hostname).
 func (r *Rule) IsProxy() bool {
 	u, err := url.Parse(r.To)
@@ -86,6 +140,104 @@ func (r *Rule) IsProxy() bool {
 	return u.Host != ""
 }
 
+// String returns the rule in the _redirects file format, with its status
+// written even when implied.
+func (r Rule) String() string {
+	r.StatusImplied = false
+	return r.text()
+}
+
+// text returns the rule in the _redirects file format as written, leaving
+// out an implied 301 status.
+func (r Rule) text() string {
+	var fields []string
+	for _, c := range r.columns() {
+		if c != "" {
+			fields = append(fields, c)
+		}
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// columns returns the fields of r in the _redirects file format by column:
+// the source with its params, the destination, the status and the
+// conditions, each empty when absent. An implied 301 status is left out so
+// that the rule parses back the same, leaving a bare "!" when forced.
+func (r Rule) columns() (cols [4]string) {
+	cols[0] = r.From
+	if len(r.Params) > 0 {
+		cols[0] += " " + formatParams(r.Params)
+	}
+
+	if !isExclusion(r.From) {
+		cols[1] = r.To
+		if !r.StatusImplied || r.Status != 301 {
+			cols[2] = strconv.Itoa(r.Status)
+		}
+		if r.Force {
+			cols[2] += "!"
+		}
+	}
+
+	var conds []string
+
+	if len(r.Country) > 0 {
+		conds = append(conds, "Country="+strings.Join(r.Country, ","))
+	}
+
+	if len(r.Language) > 0 {
+		conds = append(conds, "Language="+strings.Join(r.Language, ","))
+	}
+
+	if len(r.Method) > 0 {
+		conds = append(conds, "Method="+strings.Join(r.Method, ","))
+	}
+
+	if len(r.Host) > 0 {
+		conds = append(conds, "Host="+strings.Join(r.Host, ","))
+	}
+
+	if len(r.Conditions) > 0 {
+		conds = append(conds, formatConditions(r.Conditions))
+	}
+
+	if !r.ActiveFrom.IsZero() {
+		conds = append(conds, "From="+r.ActiveFrom.Format(time.RFC3339))
+	}
+
+	if !r.ActiveUntil.IsZero() {
+		conds = append(conds, "Until="+r.ActiveUntil.Format(time.RFC3339))
+	}
+
+	cols[3] = strings.Join(conds, " ")
+	return
+}
+
+// Marshal returns the rules in the _redirects file format, one per line,
+// with an annotation comment above rules with metadata, so that Parse
+// returns the same rules.
+func Marshal(rules []Rule) []byte {
+	var b []byte
+	for _, r := range rules {
+		b = appendRule(b, r)
+	}
+
+	return b
+}
+
+// appendRule appends the lines of r to b, preceded by its annotation.
+func appendRule(b []byte, r Rule) []byte {
+	if len(r.Meta) > 0 {
+		b = append(b, "#@ "...)
+		b = append(b, formatMeta(r.Meta)...)
+		b = append(b, '\n')
+	}
+
+	b = append(b, r.text()...)
+	return append(b, '\n')
+}
+
 // Must parse utility.
 func Must(v []Rule, err error) []Rule {
 	if err != nil {
@@ -95,127 +247,492 @@ func Must(v []Rule, err error) []Rule {
 	return v
 }
 
-// Parse the given reader.
-func Parse(r io.Reader) (rules []Rule, err error) {
+// Parse the given reader. Errors are returned as a *ParseError.
+func Parse(r io.Reader, opts ...Option) ([]Rule, error) {
+	return parse(r, newConfig(opts), nil)
+}
+
+// parse parses the given reader, allocating rules from the arena unless nil.
+func parse(r io.Reader, c *config, a *arena) (rules []Rule, err error) {
+	if r, err = c.decompress(r); err != nil {
+		return nil, err
+	}
+
 	s := bufio.NewScanner(r)
 
-	for s.Scan() {
+	if a != nil {
+		rules = a.rules[:0]
+	}
+
+	// metadata of the annotations preceding the next rule
+	var meta map[string]string
+
+	for n := 1; s.Scan(); n++ {
 		line := strings.TrimSpace(s.Text())
 
+		if c.maxLineLength > 0 && len(s.Text()) > c.maxLineLength {
+			return nil, &ParseError{Line: n, Text: line, Err: fmt.Errorf("%w, longer than %d bytes", ErrLineTooLong, c.maxLineLength)}
+		}
+
 		// empty
 		if line == "" {
+			meta = nil
+			continue
+		}
+
+		// annotation
+		if strings.HasPrefix(line, "#@") {
+			m, err := parseAnnotation(line[2:], meta)
+			if err != nil {
+				perr := &ParseError{Line: n, Text: line, Err: err}
+				if !c.skip(perr) {
+					return nil, perr
+				}
+				continue
+			}
+			meta = m
 			continue
 		}
 
 		// comment
 		if strings.HasPrefix(line, "#") {
+			if c.section == nil {
+				continue
+			}
+
+			if name, ok := parseSection(line); ok {
+				if err := c.section(name, n, len(rules)); err != nil {
+					return nil, &ParseError{Line: n, Text: line, Err: err}
+				}
+			}
 			continue
 		}
 
-		// fields
-		fields := strings.Fields(line)
+		if c.maxRules > 0 && len(rules) == c.maxRules {
+			return nil, &ParseError{Line: n, Text: line, Err: fmt.Errorf("%w, more than %d", ErrTooManyRules, c.maxRules)}
+		}
 
-		// missing dst
-		if len(fields) <= 1 {
-			return nil, fmt.Errorf("missing destination path: %q", line)
+		expanded, err := c.expandEnv(line)
+		if err != nil {
+			perr := &ParseError{Line: n, Text: line, Err: err}
+			if !c.skip(perr) {
+				return nil, perr
+			}
+			meta = nil
+			continue
 		}
 
-		// src and dst
-		rule := Rule{
-			From:   fields[0],
-			Status: 301,
+		// lines expanding to nothing are empty
+		if strings.TrimSpace(expanded) == "" {
+			meta = nil
+			continue
 		}
 
-		// This will continue until all parameters have been grabbed
-		var parameters []string
-		var i int
-		for i = 1; strings.ContainsAny(fields[i], "="); i++ {
-			parameters = append(parameters, fields[i])
+		rule, skipped, err := parseLine(expanded, c, a)
+		if c.warn != nil {
+			for _, w := range skipped {
+				c.warn(&ParseError{Line: n, Text: line, Err: w})
+			}
 		}
 
-		// if there were any paramters, add them to the rules
-		if len(parameters) != 0 {
-			rule.Params = parseParams(parameters)
+		if err != nil {
+			err = fmt.Errorf("%w, was expecting format %s", err, format)
+		} else {
+			rule, err = c.normalizePlaceholders(rule)
 		}
 
-		// if status code or parameters are in `to` place. error out.
-		// else get `to` field
-		if _, err := strconv.Atoi(fields[i]); err != nil {
-			if strings.HasSuffix(fields[i], "!") {
-				return nil, fmt.Errorf("got: %s, was expecting format %s", fields[i], format)
+		if err == nil {
+			if errs := c.checkRule(rule); len(errs) > 0 {
+				err = errs[0]
 			}
-			if strings.ContainsAny(fields[i], "=") {
-				return nil, fmt.Errorf("got: %s, was expecting format %s", fields[i], format)
+		}
+
+		if err != nil {
+			perr := &ParseError{Line: n, Text: line, Err: err}
+			if !c.skip(perr) {
+				return nil, perr
 			}
-			rule.To = fields[i]
+			meta = nil
+			continue
+		}
+
+		if c.warn != nil {
+			for _, w := range placeholderWarnings(rule) {
+				c.warn(&ParseError{Line: n, Text: line, Err: w})
+			}
+		}
+
+		rule.Meta = meta
+		meta = nil
+
+		rules = append(rules, rule)
+	}
+
+	err = s.Err()
+	return
+}
+
+// tokenizer states, in the order tokens may appear.
+const (
+	// params or the destination
+	stateParams = iota
+
+	// the status code or conditions
+	stateStatus
+
+	// conditions
+	stateConditions
+)
+
+// parseLine returns the rule of a single line, consuming its tokens in
+// the order "from [params] to [status][!] [conditions]", along with the
+// problems skipped according to the configured policies.
+func parseLine(line string, c *config, a *arena) (rule Rule, skipped []error, err error) {
+	fields := strings.Fields(line)
+
+	rule = Rule{
+		From:          fields[0],
+		Status:        c.status(),
+		StatusImplied: true,
+	}
+
+	if strings.HasSuffix(rule.From, "!") {
+		return rule, nil, ErrDetachedForce
+	}
+
+	var params []string
+	state := stateParams
+
+	// exclusions are only followed by conditions
+	if c.exclusionRules && isExclusion(rule.From) {
+		rule.Status = 0
+		rule.StatusImplied = false
+		state = stateConditions
+	}
+
+	for _, tok := range fields[1:] {
+		// a bare force flag forces the default status, as in "/a /b !"
+		if tok == "!" && state == stateStatus {
+			rule.Force = true
+			state = stateConditions
+			continue
 		}
 
-		// if there is no status code. then check for anything after
-		if i+1 < len(fields) {
-			i += 1
-			if strings.ContainsAny(fields[i], "=") {
-				// imply that status code is 301
-				// grab the country and or language
+		if tok == "!" {
+			return rule, nil, ErrDetachedForce
+		}
 
-				// if there were any paramters, add them to the rules
-				if len(parameters) != 0 {
-					rule.Params = parseParams(parameters)
+		switch state {
+		case stateParams:
+			if isParam(tok) {
+				if strings.HasPrefix(tok, "=") {
+					return rule, nil, fmt.Errorf("%w %q", ErrInvalidParam, tok)
 				}
-				// return nil, fmt.Errorf("got: %s, was expecting format %s", options[0], format)
+				params = append(params, tok)
+				continue
+			}
+
+			if isStatus(tok) {
+				return rule, nil, ErrMissingDestination
+			}
+
+			if strings.HasSuffix(tok, "!") {
+				return rule, nil, ErrDetachedForce
 			}
 
-			if code, err := strconv.Atoi(fields[i]); err != nil {
-				// not a number, or could be [status code][!]
-				code, force, err := parseStatus(fields[i])
+			rule.To = tok
+			state = stateStatus
+
+		case stateStatus:
+			state = stateConditions
+
+			if !strings.Contains(tok, "=") {
+				code, force, err := parseStatus(tok)
 				if err != nil {
-					return nil, errors.Wrapf(err, "got: %s, was expecting format %s", fields[i], format)
+					return rule, nil, fmt.Errorf("%w %q", ErrInvalidStatus, tok)
 				}
-				// it did have a '!', therefore is the status code
+
 				rule.Status = code
+				rule.StatusImplied = false
 				rule.Force = force
-			} else {
-				rule.Status = code
+				continue
+			}
+
+			// conditions directly after the destination
+			fallthrough
+
+		case stateConditions:
+			if strings.HasSuffix(tok, "!") {
+				return rule, nil, ErrDetachedForce
+			}
+		}
+
+		if state == stateConditions {
+			err := parseCondition(&rule, tok)
+
+			if errors.Is(err, ErrUnknownCondition) && c.unknownOptions != UnknownOptionError {
+				if c.unknownOptions == UnknownOptionWarn {
+					skipped = append(skipped, err)
+				}
+				continue
+			}
+
+			if err != nil {
+				return rule, nil, err
 			}
 		}
-		rules = append(rules, rule)
 	}
-	err = s.Err()
+
+	if state == stateParams {
+		return rule, nil, ErrMissingDestination
+	}
+
+	if len(params) > 0 {
+		if rule.Params, err = parseParams(params, a.newParams()); err != nil {
+			return rule, nil, err
+		}
+	}
+
+	return rule, skipped, nil
+}
+
+// parseCondition sets the condition of a key=value token on rule.
+func parseCondition(rule *Rule, tok string) (err error) {
+	parts := strings.SplitN(tok, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("%w %q", ErrUnexpectedToken, tok)
+	}
+
+	if name := headerCondition(parts[0]); name != "" {
+		if rule.Conditions == nil {
+			rule.Conditions = make(Conditions)
+		}
+		rule.Conditions["Header:"+name] = parts[1]
+		return nil
+	}
+
+	switch strings.ToLower(parts[0]) {
+	case "country":
+		rule.Country = parseList(parts[1])
+	case "language":
+		rule.Language = parseList(parts[1])
+	case "method":
+		rule.Method = parseList(parts[1])
+	case "host":
+		rule.Host = parseList(parts[1])
+	case "from":
+		rule.ActiveFrom, err = parseTime(parts[1])
+	case "until":
+		rule.ActiveUntil, err = parseTime(parts[1])
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownCondition, parts[0])
+	}
+
 	return
 }
 
+// isParam returns true if tok is a key=value query param rather than a
+// destination, which may itself contain "=" in its query string.
+func isParam(tok string) bool {
+	return strings.Contains(tok, "=") && !strings.HasPrefix(tok, "/") && !strings.Contains(tok, "://")
+}
+
 // ParseString parses the given string.
-func ParseString(s string) ([]Rule, error) {
-	return Parse(strings.NewReader(s))
+func ParseString(s string, opts ...Option) ([]Rule, error) {
+	return Parse(strings.NewReader(s), opts...)
 }
 
-// parseParams returns parsed param key/value pairs.
-func parseParams(pairs []string) Params {
-	m := make(Params)
+// parseAnnotation returns the key=value pairs of an annotation comment,
+// without its leading "#@", set on m. Values may contain "=" after the first.
+func parseAnnotation(s string, m map[string]string) (map[string]string, error) {
+	for _, f := range strings.Fields(s) {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("%w %q", ErrInvalidAnnotation, f)
+		}
+
+		if m == nil {
+			m = make(map[string]string)
+		}
+
+		m[k] = v
+	}
+
+	return m, nil
+}
+
+// formatMeta returns metadata as space separated key=value pairs ordered
+// by key, as written in an annotation comment.
+func formatMeta(meta map[string]string) string {
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + meta[k]
+	}
 
+	return strings.Join(pairs, " ")
+}
+
+// parseParams returns parsed param key/value pairs, set on m. Keys and
+// values are percent-decoded, so "redirect=%2Fnext" matches the value
+// "/next", and values may contain "=" after the first.
+func parseParams(pairs []string, m Params) (Params, error) {
 	for _, p := range pairs {
-		parts := strings.Split(p, "=")
-		if len(parts) > 1 {
-			m[parts[0]] = parts[1]
+		k, v, hasValue := strings.Cut(p, "=")
+
+		key, err := url.PathUnescape(k)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q", ErrInvalidParam, p)
+		}
+
+		if !hasValue {
+			m[key] = true
+			continue
+		}
+
+		value, err := url.PathUnescape(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q", ErrInvalidParam, p)
+		}
+
+		m[key] = value
+	}
+
+	return m, nil
+}
+
+// escapeParam returns a param key or value percent-encoded as needed to be
+// read back as a single token by parseParams: percent signs, whitespace and
+// control characters, "=" in keys, and the colon of "://" which would
+// otherwise read as a destination URL.
+func escapeParam(s string, key bool) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '%' || c <= ' ' || c == 0x7f || key && c == '=' ||
+			c == ':' && strings.HasPrefix(s[i+1:], "//"):
+			fmt.Fprintf(&b, "%%%02X", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+// keys returns the sorted param names.
+func (p Params) keys() []string {
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// keys returns the sorted condition names.
+func (c Conditions) keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatParams returns params as sorted space separated key=value pairs.
+func formatParams(p Params) string {
+	keys := p.keys()
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		if v, ok := p[k].(string); ok {
+			pairs[i] = escapeParam(k, true) + "=" + escapeParam(v, false)
 		} else {
-			m[parts[0]] = true
+			pairs[i] = escapeParam(k, true)
+		}
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// formatConditions returns conditions as sorted space separated key=value pairs.
+func formatConditions(c Conditions) string {
+	keys := c.keys()
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + c[k]
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// parseConditions returns parsed key=value condition pairs.
+func parseConditions(pairs []string) Conditions {
+	m := make(Conditions)
+
+	for _, p := range pairs {
+		parts := strings.SplitN(p, "=", 2)
+		if len(parts) == 2 {
+			m[parts[0]] = parts[1]
 		}
 	}
 
 	return m
 }
 
+// headerCondition returns the canonical header name of a "Header:name"
+// condition key, or an empty string for other keys.
+func headerCondition(key string) string {
+	if len(key) <= len("Header:") || !strings.EqualFold(key[:len("Header:")], "Header:") {
+		return ""
+	}
+
+	return textproto.CanonicalMIMEHeaderKey(key[len("Header:"):])
+}
+
+// timeLayouts are the accepted layouts of From and Until conditions.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+}
+
+// parseTime returns the time in one of the accepted layouts.
+func parseTime(s string) (t time.Time, err error) {
+	for _, layout := range timeLayouts {
+		if t, err = time.Parse(layout, s); err == nil {
+			return
+		}
+	}
+
+	return time.Time{}, errorf(CodeInvalidTime, "invalid time %q, was expecting RFC 3339 such as 2006-01-02T15:04Z", s)
+}
+
 // parseStatus returns the status code and force when "!" suffix is present.
 func parseStatus(s string) (code int, force bool, err error) {
 	if strings.HasSuffix(s, "!") {
 		force = true
-		s = strings.Replace(s, "!", "", -1)
+		s = strings.TrimSuffix(s, "!")
 	}
 
 	code, err = strconv.Atoi(s)
 	return
 }
 
-// parseCountry returns a slice of countries
-func parseCountry(s string) []string {
-	return strings.Split(s, ",")[1:] //split comma separated list into slice
+// isStatus returns true if s is a status code, with or without the "!" suffix.
+func isStatus(s string) bool {
+	_, _, err := parseStatus(s)
+	return err == nil
+}
+
+// parseList returns a slice of the comma separated values in s.
+func parseList(s string) []string {
+	return strings.Split(s, ",")
 }
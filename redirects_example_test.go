@@ -8,143 +8,321 @@ import (
 )
 
 func Example() {
-	// from [a=:save1 b=value] to [code][!] [Country=x,y,z] [Language=x,y,z]
 	h := redirects.Must(redirects.ParseString(`
 		# Implicit 301 redirects
-		#/home              /
-		#/blog/my-post.php  /blog/my-post
-		# /news              /blog
-		# /google            https://www.google.com
+		/home              /
+		/blog/my-post.php  /blog/my-post
+		/news              /blog
+		/google            https://www.google.com
 
-		## Redirect with a 301
-		#/home         /              301
-#
-		## Redirect with a 302
-		#/my-redirect  /              302
-#
-		## Rewrite a path
-		#/pass-through /index.html    200
-#
-		## Show a custom 404 for this path
-		#/ecommerce    /store-closed  404
-#
-		## Single page app rewrite
-		#/*    /index.html   200
-#
-		## Proxying
-		#/api/*  https://api.example.com/:splat  200
-#
-		## Forcing
-		#/app/*  /app/index.html  200!
-#
-		## Params
-		#/	/something	302	foo=bar
-		#/	/something	302	foo=bar bar=baz
-		#/	id=:id /blog/:id 302
+		# Redirect with a 301
+		/home         /              301
+
+		# Redirect with a 302
+		/my-redirect  /              302
+
+		# Rewrite a path
+		/pass-through /index.html    200
+
+		# Show a custom 404 for this path
+		/ecommerce    /store-closed  404
+
+		# Single page app rewrite
+		/*    /index.html   200
+
+		# Proxying
+		/api/*  https://api.example.com/:splat  200
+
+		# Forcing
+		/app/*  /app/index.html  200!
+
+		# Params
 		/articles id=:id tag=:tag /posts/:tag/:id 301!
-		#/ 	/auzy		302 Country=au,nz
+
+		# Conditions
+		/  /anz  302  Country=au,nz Language=en
+		/form  https://api.example.com/form  200  Method=POST
+		/      /canary/index.html  200  Header:X-Canary=true
+		/      /shop/index.html    200  Host=shop.example.com,*.shop.example.com
+
+		# Scheduled
+		/sale  /campaigns/winter  302  From=2024-12-01T00:00Z Until=2025-01-01T00:00Z
   `))
 
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
 	enc.Encode(h)
 	// Output:
-	// 	[
+	// [
 	//   {
 	//     "From": "/home",
 	//     "To": "/",
 	//     "Status": 301,
 	//     "Force": false,
-	//     "Params": null
+	//     "Params": null,
+	//     "Country": null,
+	//     "Language": null,
+	//     "Method": null,
+	//     "Host": null,
+	//     "Conditions": null,
+	//     "ActiveFrom": "0001-01-01T00:00:00Z",
+	//     "ActiveUntil": "0001-01-01T00:00:00Z",
+	//     "Meta": null
 	//   },
 	//   {
 	//     "From": "/blog/my-post.php",
 	//     "To": "/blog/my-post",
 	//     "Status": 301,
 	//     "Force": false,
-	//     "Params": null
+	//     "Params": null,
+	//     "Country": null,
+	//     "Language": null,
+	//     "Method": null,
+	//     "Host": null,
+	//     "Conditions": null,
+	//     "ActiveFrom": "0001-01-01T00:00:00Z",
+	//     "ActiveUntil": "0001-01-01T00:00:00Z",
+	//     "Meta": null
 	//   },
 	//   {
 	//     "From": "/news",
 	//     "To": "/blog",
 	//     "Status": 301,
 	//     "Force": false,
-	//     "Params": null
+	//     "Params": null,
+	//     "Country": null,
+	//     "Language": null,
+	//     "Method": null,
+	//     "Host": null,
+	//     "Conditions": null,
+	//     "ActiveFrom": "0001-01-01T00:00:00Z",
+	//     "ActiveUntil": "0001-01-01T00:00:00Z",
+	//     "Meta": null
 	//   },
 	//   {
 	//     "From": "/google",
 	//     "To": "https://www.google.com",
 	//     "Status": 301,
 	//     "Force": false,
-	//     "Params": null
+	//     "Params": null,
+	//     "Country": null,
+	//     "Language": null,
+	//     "Method": null,
+	//     "Host": null,
+	//     "Conditions": null,
+	//     "ActiveFrom": "0001-01-01T00:00:00Z",
+	//     "ActiveUntil": "0001-01-01T00:00:00Z",
+	//     "Meta": null
 	//   },
 	//   {
 	//     "From": "/home",
 	//     "To": "/",
 	//     "Status": 301,
 	//     "Force": false,
-	//     "Params": null
+	//     "Params": null,
+	//     "Country": null,
+	//     "Language": null,
+	//     "Method": null,
+	//     "Host": null,
+	//     "Conditions": null,
+	//     "ActiveFrom": "0001-01-01T00:00:00Z",
+	//     "ActiveUntil": "0001-01-01T00:00:00Z",
+	//     "Meta": null
 	//   },
 	//   {
 	//     "From": "/my-redirect",
 	//     "To": "/",
 	//     "Status": 302,
 	//     "Force": false,
-	//     "Params": null
+	//     "Params": null,
+	//     "Country": null,
+	//     "Language": null,
+	//     "Method": null,
+	//     "Host": null,
+	//     "Conditions": null,
+	//     "ActiveFrom": "0001-01-01T00:00:00Z",
+	//     "ActiveUntil": "0001-01-01T00:00:00Z",
+	//     "Meta": null
 	//   },
 	//   {
 	//     "From": "/pass-through",
 	//     "To": "/index.html",
 	//     "Status": 200,
 	//     "Force": false,
-	//     "Params": null
+	//     "Params": null,
+	//     "Country": null,
+	//     "Language": null,
+	//     "Method": null,
+	//     "Host": null,
+	//     "Conditions": null,
+	//     "ActiveFrom": "0001-01-01T00:00:00Z",
+	//     "ActiveUntil": "0001-01-01T00:00:00Z",
+	//     "Meta": null
 	//   },
 	//   {
 	//     "From": "/ecommerce",
 	//     "To": "/store-closed",
 	//     "Status": 404,
 	//     "Force": false,
-	//     "Params": null
+	//     "Params": null,
+	//     "Country": null,
+	//     "Language": null,
+	//     "Method": null,
+	//     "Host": null,
+	//     "Conditions": null,
+	//     "ActiveFrom": "0001-01-01T00:00:00Z",
+	//     "ActiveUntil": "0001-01-01T00:00:00Z",
+	//     "Meta": null
 	//   },
 	//   {
 	//     "From": "/*",
 	//     "To": "/index.html",
 	//     "Status": 200,
 	//     "Force": false,
-	//     "Params": null
+	//     "Params": null,
+	//     "Country": null,
+	//     "Language": null,
+	//     "Method": null,
+	//     "Host": null,
+	//     "Conditions": null,
+	//     "ActiveFrom": "0001-01-01T00:00:00Z",
+	//     "ActiveUntil": "0001-01-01T00:00:00Z",
+	//     "Meta": null
 	//   },
 	//   {
 	//     "From": "/api/*",
 	//     "To": "https://api.example.com/:splat",
 	//     "Status": 200,
 	//     "Force": false,
-	//     "Params": null
+	//     "Params": null,
+	//     "Country": null,
+	//     "Language": null,
+	//     "Method": null,
+	//     "Host": null,
+	//     "Conditions": null,
+	//     "ActiveFrom": "0001-01-01T00:00:00Z",
+	//     "ActiveUntil": "0001-01-01T00:00:00Z",
+	//     "Meta": null
 	//   },
 	//   {
 	//     "From": "/app/*",
 	//     "To": "/app/index.html",
 	//     "Status": 200,
 	//     "Force": true,
+	//     "Params": null,
+	//     "Country": null,
+	//     "Language": null,
+	//     "Method": null,
+	//     "Host": null,
+	//     "Conditions": null,
+	//     "ActiveFrom": "0001-01-01T00:00:00Z",
+	//     "ActiveUntil": "0001-01-01T00:00:00Z",
+	//     "Meta": null
+	//   },
+	//   {
+	//     "From": "/articles",
+	//     "To": "/posts/:tag/:id",
+	//     "Status": 301,
+	//     "Force": true,
 	//     "Params": {
-	//       "id":":id"
-	//     }
+	//       "id": ":id",
+	//       "tag": ":tag"
+	//     },
+	//     "Country": null,
+	//     "Language": null,
+	//     "Method": null,
+	//     "Host": null,
+	//     "Conditions": null,
+	//     "ActiveFrom": "0001-01-01T00:00:00Z",
+	//     "ActiveUntil": "0001-01-01T00:00:00Z",
+	//     "Meta": null
 	//   },
 	//   {
 	//     "From": "/",
-	//     "To": "/something",
+	//     "To": "/anz",
 	//     "Status": 302,
 	//     "Force": false,
-	//     "Params": {
-	//       "id": ":id"
-	//       "tag": ":tag"
-	//     }
+	//     "Params": null,
+	//     "Country": [
+	//       "au",
+	//       "nz"
+	//     ],
+	//     "Language": [
+	//       "en"
+	//     ],
+	//     "Method": null,
+	//     "Host": null,
+	//     "Conditions": null,
+	//     "ActiveFrom": "0001-01-01T00:00:00Z",
+	//     "ActiveUntil": "0001-01-01T00:00:00Z",
+	//     "Meta": null
+	//   },
+	//   {
+	//     "From": "/form",
+	//     "To": "https://api.example.com/form",
+	//     "Status": 200,
+	//     "Force": false,
+	//     "Params": null,
+	//     "Country": null,
+	//     "Language": null,
+	//     "Method": [
+	//       "POST"
+	//     ],
+	//     "Host": null,
+	//     "Conditions": null,
+	//     "ActiveFrom": "0001-01-01T00:00:00Z",
+	//     "ActiveUntil": "0001-01-01T00:00:00Z",
+	//     "Meta": null
+	//   },
+	//   {
+	//     "From": "/",
+	//     "To": "/canary/index.html",
+	//     "Status": 200,
+	//     "Force": false,
+	//     "Params": null,
+	//     "Country": null,
+	//     "Language": null,
+	//     "Method": null,
+	//     "Host": null,
+	//     "Conditions": {
+	//       "Header:X-Canary": "true"
+	//     },
+	//     "ActiveFrom": "0001-01-01T00:00:00Z",
+	//     "ActiveUntil": "0001-01-01T00:00:00Z",
+	//     "Meta": null
 	//   },
 	//   {
 	//     "From": "/",
-	//     "To": "/something",
+	//     "To": "/shop/index.html",
+	//     "Status": 200,
+	//     "Force": false,
+	//     "Params": null,
+	//     "Country": null,
+	//     "Language": null,
+	//     "Method": null,
+	//     "Host": [
+	//       "shop.example.com",
+	//       "*.shop.example.com"
+	//     ],
+	//     "Conditions": null,
+	//     "ActiveFrom": "0001-01-01T00:00:00Z",
+	//     "ActiveUntil": "0001-01-01T00:00:00Z",
+	//     "Meta": null
+	//   },
+	//   {
+	//     "From": "/sale",
+	//     "To": "/campaigns/winter",
 	//     "Status": 302,
 	//     "Force": false,
-	//     "Params": null
+	//     "Params": null,
+	//     "Country": null,
+	//     "Language": null,
+	//     "Method": null,
+	//     "Host": null,
+	//     "Conditions": null,
+	//     "ActiveFrom": "2024-12-01T00:00:00Z",
+	//     "ActiveUntil": "2025-01-01T00:00:00Z",
+	//     "Meta": null
 	//   }
 	// ]
 }
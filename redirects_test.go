@@ -1,10 +1,14 @@
 package redirects_test
 
 import (
+	"encoding/json"
+	"net/url"
 	"testing"
+	"time"
 
-	"github.com/tj/assert"
 	"github.com/fission-suite/go-redirects"
+	"github.com/pkg/errors"
+	"github.com/tj/assert"
 )
 
 func TestParams_Has(t *testing.T) {
@@ -29,6 +33,22 @@ func TestParams_Get(t *testing.T) {
 	assert.Equal(t, nil, p.Get("baz"))
 }
 
+func TestParams_order(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString("/search  q=:a  term=:a  page=2  /find/:a\n"))
+
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, "/search page=2 q=:a term=:a /find/:a\n", string(redirects.Marshal(rules)))
+
+		b, err := json.Marshal(rules[0].Params)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"page":"2","q":":a","term":":a"}`, string(b))
+
+		res, ok := redirects.Match(rules, redirects.Request{Path: "/search", Query: url.Values{"q": {"x"}, "term": {"y"}, "page": {"2"}}})
+		assert.True(t, ok)
+		assert.Equal(t, "/find/y", res.To)
+	}
+}
+
 func TestRule_IsProxy(t *testing.T) {
 	t.Run("without host", func(t *testing.T) {
 		r := redirects.Rule{
@@ -79,3 +99,293 @@ func TestRule_IsRewrite(t *testing.T) {
 		assert.False(t, r.IsRewrite())
 	})
 }
+
+func TestRule_IsContent(t *testing.T) {
+	for status, content := range map[int]bool{200: true, 301: false, 308: false, 404: true, 410: true, 451: true} {
+		r := redirects.Rule{From: "/a", To: "/b", Status: status}
+		assert.Equal(t, content, r.IsContent(), "status %d", status)
+	}
+}
+
+func TestRule_String(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/store  tag=:tag id=:id  /item/:tag/:id  302!  Country=au,nz  Language=en
+	`))
+
+	assert.Equal(t, "/store id=:id tag=:tag /item/:tag/:id 302! Country=au,nz Language=en", rules[0].String())
+}
+
+func TestParse_force(t *testing.T) {
+	t.Run("attached to status", func(t *testing.T) {
+		rules, err := redirects.ParseString(`/a /b 302!`)
+		assert.NoError(t, err)
+		assert.Equal(t, 302, rules[0].Status)
+		assert.True(t, rules[0].Force)
+	})
+
+	t.Run("attached to status before conditions", func(t *testing.T) {
+		rules, err := redirects.ParseString(`/a /b 200! Country=au`)
+		assert.NoError(t, err)
+		assert.Equal(t, 200, rules[0].Status)
+		assert.True(t, rules[0].Force)
+		assert.Equal(t, []string{"au"}, rules[0].Country)
+	})
+
+	t.Run("separate token", func(t *testing.T) {
+		_, err := redirects.ParseString(`/a /b 301 !`)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "force flag must be attached to a status code")
+	})
+
+	t.Run("attached to destination", func(t *testing.T) {
+		_, err := redirects.ParseString(`/a /b!`)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "force flag must be attached to a status code")
+	})
+
+	t.Run("repeated", func(t *testing.T) {
+		_, err := redirects.ParseString(`/a /b 301!!`)
+		assert.Error(t, err)
+	})
+
+	t.Run("inside status", func(t *testing.T) {
+		_, err := redirects.ParseString(`/a /b 3!01`)
+		assert.Error(t, err)
+	})
+}
+
+func TestParse_conditions(t *testing.T) {
+	t.Run("country and language", func(t *testing.T) {
+		rules, err := redirects.ParseString(`/ /anz 302 Country=au,nz Language=en`)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"au", "nz"}, rules[0].Country)
+		assert.Equal(t, []string{"en"}, rules[0].Language)
+	})
+
+	t.Run("without status", func(t *testing.T) {
+		rules, err := redirects.ParseString(`/ /anz Country=au`)
+		assert.NoError(t, err)
+		assert.Equal(t, 301, rules[0].Status)
+		assert.Equal(t, []string{"au"}, rules[0].Country)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := redirects.ParseString(`/ /anz 302 Role=admin`)
+		assert.Error(t, err)
+	})
+}
+
+func TestParse_method(t *testing.T) {
+	rules, err := redirects.ParseString(`/form https://api.example.com/form 200 Method=POST,PUT`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"POST", "PUT"}, rules[0].Method)
+	assert.Equal(t, "/form https://api.example.com/form 200 Method=POST,PUT", rules[0].String())
+}
+
+func TestParse_header(t *testing.T) {
+	t.Run("canonical name", func(t *testing.T) {
+		rules, err := redirects.ParseString(`/ /canary 200 header:x-canary=true Header:X-Beta=1`)
+		assert.NoError(t, err)
+		assert.Equal(t, redirects.Conditions{"Header:X-Canary": "true", "Header:X-Beta": "1"}, rules[0].Conditions)
+		assert.Equal(t, "/ /canary 200 Header:X-Beta=1 Header:X-Canary=true", rules[0].String())
+	})
+
+	t.Run("missing name", func(t *testing.T) {
+		_, err := redirects.ParseString(`/ /canary 200 Header:=true`)
+		assert.Error(t, err)
+	})
+}
+
+func TestParse_schedule(t *testing.T) {
+	t.Run("from and until", func(t *testing.T) {
+		rules, err := redirects.ParseString(`/sale /winter 302 From=2024-12-01T00:00Z Until=2025-01-01`)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), rules[0].ActiveFrom.UTC())
+		assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), rules[0].ActiveUntil.UTC())
+		assert.Equal(t, "/sale /winter 302 From=2024-12-01T00:00:00Z Until=2025-01-01T00:00:00Z", rules[0].String())
+	})
+
+	t.Run("invalid time", func(t *testing.T) {
+		_, err := redirects.ParseString(`/sale /winter 302 From=tomorrow`)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid time "tomorrow"`)
+	})
+
+	t.Run("empty window", func(t *testing.T) {
+		_, err := redirects.ParseString(`/sale /winter 302 From=2025-01-01 Until=2024-12-01`)
+		assert.Error(t, err)
+	})
+}
+
+func TestParse_missingDestination(t *testing.T) {
+	for _, line := range []string{`/a 301`, `/from a=b`, `/from a=b c=d`} {
+		_, err := redirects.ParseString(line)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "missing destination path")
+	}
+}
+
+func TestParse_malformed(t *testing.T) {
+	cases := []struct {
+		line string
+		err  error
+	}{
+		{`/from`, redirects.ErrMissingDestination},
+		{`/from 301`, redirects.ErrMissingDestination},
+		{`/from 301!`, redirects.ErrMissingDestination},
+		{`/from a=b`, redirects.ErrMissingDestination},
+		{`/from a=b 302`, redirects.ErrMissingDestination},
+		{`/a = /b`, redirects.ErrInvalidParam},
+		{`/a =b /b`, redirects.ErrInvalidParam},
+		{`/a! /b`, redirects.ErrDetachedForce},
+		{`/a /b!`, redirects.ErrDetachedForce},
+		{`/a ! /b`, redirects.ErrDetachedForce},
+		{`/a /b ! !`, redirects.ErrDetachedForce},
+		{`/a /b 301 !`, redirects.ErrDetachedForce},
+		{`/a /b 301 Country=au!`, redirects.ErrDetachedForce},
+		{`/a /b Country=au!`, redirects.ErrDetachedForce},
+		{`/a /b 3!01`, redirects.ErrInvalidStatus},
+		{`/a /b 301!!`, redirects.ErrInvalidStatus},
+		{`/a /b abc`, redirects.ErrInvalidStatus},
+		{`/a /b 301 302`, redirects.ErrUnexpectedToken},
+		{`/a /b Country=au 302`, redirects.ErrUnexpectedToken},
+		{`/a /b 301 Role=admin`, redirects.ErrUnknownCondition},
+	}
+
+	for _, c := range cases {
+		t.Run(c.line, func(t *testing.T) {
+			_, err := redirects.ParseString("# comment\n" + c.line)
+			assert.True(t, errors.Is(err, c.err), "got %v", err)
+
+			var perr *redirects.ParseError
+			assert.True(t, errors.As(err, &perr))
+			assert.Equal(t, 2, perr.Line)
+			assert.Equal(t, c.line, perr.Text)
+		})
+	}
+}
+
+func TestParse_destinationQuery(t *testing.T) {
+	rules, err := redirects.ParseString(`/search q=:q /find?query=:q&sort=new 302`)
+	assert.NoError(t, err)
+	assert.Equal(t, redirects.Params{"q": ":q"}, rules[0].Params)
+	assert.Equal(t, "/find?query=:q&sort=new", rules[0].To)
+	assert.Equal(t, 302, rules[0].Status)
+}
+
+func TestParse_paramEscaping(t *testing.T) {
+	t.Run("decoding", func(t *testing.T) {
+		rules, err := redirects.ParseString(`/a utm_campaign=spring,sale redirect=%2Fnext token=a=b note=hello%20world  /b`)
+		assert.NoError(t, err)
+		assert.Equal(t, redirects.Params{
+			"utm_campaign": "spring,sale",
+			"redirect":     "/next",
+			"token":        "a=b",
+			"note":         "hello world",
+		}, rules[0].Params)
+
+		_, ok := redirects.Match(rules, redirects.Request{
+			Path:  "/a",
+			Query: url.Values{"utm_campaign": {"spring,sale"}, "redirect": {"/next"}, "token": {"a=b"}, "note": {"hello world"}},
+		})
+		assert.True(t, ok)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := redirects.ParseString(`/a q=%zz  /b`)
+		assert.True(t, errors.Is(err, redirects.ErrInvalidParam))
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		rule := redirects.Rule{
+			From:   "/a",
+			To:     "/b",
+			Status: 301,
+			Params: redirects.Params{
+				"next": "https://example.com/x",
+				"note": "50% off\tnow",
+				"a=b":  "c",
+				"flag": "spring,sale",
+			},
+		}
+
+		s := rule.String()
+		assert.Equal(t, `/a a%3Db=c flag=spring,sale next=https%3A//example.com/x note=50%25%20off%09now /b 301`, s)
+
+		rules, err := redirects.ParseString(s)
+		assert.NoError(t, err)
+		assert.Equal(t, rule, rules[0])
+	})
+}
+
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{
+		"/home /",
+		"/blog/:year/* /posts/:year/:splat 302!",
+		"/store id=:id /item/:id 301 Country=au,nz Language=en",
+		"/form https://api.example.com/form 200 Method=POST Header:X-Canary=true",
+		"/sale /winter 302 From=2024-12-01T00:00Z Until=2025-01-01",
+		"/from a=b",
+		"/a = /b",
+		"# comment\n\n/a /b 301 !",
+		"${env:EMPTY}\n/a ${env:EMPTY} /b",
+	} {
+		f.Add(s)
+	}
+
+	// every variable is defined, empty or not, so lines may expand to nothing
+	env := redirects.WithEnv(func(name string) (string, bool) {
+		if name == "EMPTY" {
+			return "", true
+		}
+		return "/" + name, true
+	})
+
+	f.Fuzz(func(t *testing.T, s string) {
+		rules, err := redirects.ParseString(s, env)
+		if err != nil {
+			return
+		}
+
+		if errs := redirects.Validate(rules); len(errs) > 0 {
+			t.Fatalf("parsed invalid rules from %q: %v", s, errs)
+		}
+	})
+}
+
+func TestParse_annotations(t *testing.T) {
+	t.Run("meta", func(t *testing.T) {
+		rules, err := redirects.ParseString(`
+			#@ owner=seo-team expires=2025-01-01
+			# moved in the 2024 redesign
+			#@ ticket=WEB-12 note=a=b
+			/old  /new
+
+			#@ owner=blog
+			/news  /blog
+
+			/about  /team
+		`)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"owner":   "seo-team",
+			"expires": "2025-01-01",
+			"ticket":  "WEB-12",
+			"note":    "a=b",
+		}, rules[0].Meta)
+		assert.Equal(t, map[string]string{"owner": "blog"}, rules[1].Meta)
+		assert.Nil(t, rules[2].Meta)
+	})
+
+	t.Run("detached", func(t *testing.T) {
+		rules, err := redirects.ParseString("#@ owner=seo-team\n\n/old  /new\n")
+		assert.NoError(t, err)
+		assert.Nil(t, rules[0].Meta)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := redirects.ParseString("#@ owner\n/old  /new\n")
+		assert.True(t, errors.Is(err, redirects.ErrInvalidAnnotation))
+		assert.EqualError(t, err, `line 1: invalid annotation "owner": "#@ owner"`)
+	})
+}
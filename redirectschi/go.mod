@@ -0,0 +1,19 @@
+module github.com/fission-suite/go-redirects/redirectschi
+
+go 1.23
+
+require (
+	github.com/fission-suite/go-redirects v0.0.0
+	github.com/go-chi/chi/v5 v5.3.2
+	github.com/tj/assert v0.0.3
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/testify v1.6.1 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200605160147-a5ece683394c // indirect
+)
+
+replace github.com/fission-suite/go-redirects => ../
@@ -0,0 +1,22 @@
+// Package redirectschi serves the rules of a Netlify _redirects file with
+// github.com/fission-suite/go-redirects as chi middleware.
+package redirectschi
+
+import (
+	"net/http"
+
+	"github.com/fission-suite/go-redirects"
+)
+
+// Middleware returns chi middleware serving rules in front of the routes
+// of a router, for its Use method. Chi runs middleware before routing, so
+// rewrites are routed again.
+func Middleware(rules []redirects.Rule) func(http.Handler) http.Handler {
+	return Handler(&redirects.Handler{Rules: rules})
+}
+
+// Handler returns chi middleware serving the rules of h, see Middleware.
+// The Next handler of h is not used.
+func Handler(h *redirects.Handler) func(http.Handler) http.Handler {
+	return h.Middleware
+}
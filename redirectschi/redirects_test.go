@@ -0,0 +1,56 @@
+package redirectschi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/fission-suite/go-redirects/redirectschi"
+	"github.com/go-chi/chi/v5"
+	"github.com/tj/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(redirectschi.Middleware(redirects.Must(redirects.ParseString(`
+		/home   /           302
+		/app/*  /index.html 200
+		/gone   /404.html   404
+	`))))
+
+	for _, path := range []string{"/index.html", "/404.html", "/about"} {
+		path := path
+		r.Get(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("served " + path))
+		})
+	}
+
+	t.Run("redirect", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/home", nil))
+		assert.Equal(t, 302, w.Code)
+		assert.Equal(t, "/", w.Header().Get("Location"))
+	})
+
+	t.Run("rewrite", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/app/settings", nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "served /index.html", w.Body.String())
+	})
+
+	t.Run("content status", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/gone", nil))
+		assert.Equal(t, 404, w.Code)
+		assert.Equal(t, "served /404.html", w.Body.String())
+	})
+
+	t.Run("pass through", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/about", nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "served /about", w.Body.String())
+	})
+}
@@ -0,0 +1,46 @@
+// Package redirectsecho serves the rules of a Netlify _redirects file with
+// github.com/fission-suite/go-redirects as echo middleware.
+package redirectsecho
+
+import (
+	"net/http"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware returns echo middleware serving rules in front of the routes
+// of an instance. Register it with Pre so rewrites are routed again, as
+// middleware registered with Use runs once the route is matched.
+func Middleware(rules []redirects.Rule) echo.MiddlewareFunc {
+	return Handler(&redirects.Handler{Rules: rules})
+}
+
+// Handler returns echo middleware serving the rules of h, see Middleware.
+// The Next handler of h is not used.
+func Handler(h *redirects.Handler) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var err error
+			req, res := c.Request(), c.Response()
+
+			h.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// echo routes the request it received once Pre middleware
+				// returns, as its own Rewrite middleware relies on
+				*req.URL = *r.URL
+				req.RequestURI = r.RequestURI
+				c.SetRequest(r)
+
+				// content rules respond with their status
+				if w != http.ResponseWriter(res) {
+					c.SetResponse(echo.NewResponse(w, c.Echo()))
+					defer c.SetResponse(res)
+				}
+
+				err = next(c)
+			})).ServeHTTP(res, req)
+
+			return err
+		}
+	}
+}
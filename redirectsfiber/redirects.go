@@ -0,0 +1,43 @@
+// Package redirectsfiber serves the rules of a Netlify _redirects file with
+// github.com/fission-suite/go-redirects as fiber middleware.
+package redirectsfiber
+
+import (
+	"net/http"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+)
+
+// Middleware returns fiber middleware serving rules in front of the routes
+// of an app, for its Use method. Requests are converted to net/http with
+// fiber's adaptor, and rewrites continue to the routes matching the
+// rewritten path.
+func Middleware(rules []redirects.Rule) fiber.Handler {
+	return Handler(&redirects.Handler{Rules: rules})
+}
+
+// Handler returns fiber middleware serving the rules of h, see Middleware.
+// The Next handler of h is not used.
+func Handler(h *redirects.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return adaptor.HTTPMiddleware(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(res http.ResponseWriter, r *http.Request) {
+				h.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					// fiber routes by the path parsed from the original
+					// request, which the adaptor does not update
+					c.Path(r.URL.Path)
+
+					// content rules respond with their status, while the
+					// body is written by the next routes
+					if w != res {
+						w.WriteHeader(http.StatusOK)
+					}
+
+					next.ServeHTTP(w, r)
+				})).ServeHTTP(res, r)
+			})
+		})(c)
+	}
+}
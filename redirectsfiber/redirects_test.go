@@ -0,0 +1,63 @@
+package redirectsfiber_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/fission-suite/go-redirects/redirectsfiber"
+	"github.com/gofiber/fiber/v2"
+	"github.com/tj/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(redirectsfiber.Middleware(redirects.Must(redirects.ParseString(`
+		/home   /           302
+		/app/*  /index.html 200
+		/gone   /404.html   404
+	`))))
+
+	for _, path := range []string{"/index.html", "/404.html", "/about"} {
+		path := path
+		app.Get(path, func(c *fiber.Ctx) error {
+			return c.SendString("served " + path)
+		})
+	}
+
+	serve := func(path string) (*http.Response, string) {
+		res, err := app.Test(httptest.NewRequest("GET", path, nil))
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		b, err := io.ReadAll(res.Body)
+		assert.NoError(t, err)
+		return res, string(b)
+	}
+
+	t.Run("redirect", func(t *testing.T) {
+		res, _ := serve("/home")
+		assert.Equal(t, 302, res.StatusCode)
+		assert.Equal(t, "/", res.Header.Get("Location"))
+	})
+
+	t.Run("rewrite", func(t *testing.T) {
+		res, body := serve("/app/settings")
+		assert.Equal(t, 200, res.StatusCode)
+		assert.Equal(t, "served /index.html", body)
+	})
+
+	t.Run("content status", func(t *testing.T) {
+		res, body := serve("/gone")
+		assert.Equal(t, 404, res.StatusCode)
+		assert.Equal(t, "served /404.html", body)
+	})
+
+	t.Run("pass through", func(t *testing.T) {
+		res, body := serve("/about")
+		assert.Equal(t, 200, res.StatusCode)
+		assert.Equal(t, "served /about", body)
+	})
+}
@@ -0,0 +1,68 @@
+// Package redirectsgin serves the rules of a Netlify _redirects file with
+// github.com/fission-suite/go-redirects as gin middleware.
+package redirectsgin
+
+import (
+	"net/http"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware returns gin middleware serving rules in front of the handlers
+// of each route, for the Use method of an engine or group.
+//
+// Gin runs middleware once the route is matched, so rewrites continue to
+// the handlers of the route matching the original path with the rewritten
+// request, such as a static file handler. Wrap the engine with
+// redirects.Middleware instead to route rewrites again.
+func Middleware(rules []redirects.Rule) gin.HandlerFunc {
+	return Handler(&redirects.Handler{Rules: rules})
+}
+
+// Handler returns gin middleware serving the rules of h, see Middleware.
+// The Next handler of h is not used.
+func Handler(h *redirects.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := c.Writer
+		next := false
+
+		h.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next = true
+			c.Request = r
+
+			// content rules respond with their status
+			if w != http.ResponseWriter(writer) {
+				c.Writer = responseWriter{ResponseWriter: writer, w: w}
+				defer func() { c.Writer = writer }()
+			}
+
+			c.Next()
+		})).ServeHTTP(writer, c.Request)
+
+		if !next {
+			c.Abort()
+		}
+	}
+}
+
+// responseWriter is a gin.ResponseWriter writing the response through w.
+type responseWriter struct {
+	gin.ResponseWriter
+	w http.ResponseWriter
+}
+
+// WriteHeader implementation.
+func (rw responseWriter) WriteHeader(code int) {
+	rw.w.WriteHeader(code)
+}
+
+// Write implementation.
+func (rw responseWriter) Write(b []byte) (int, error) {
+	return rw.w.Write(b)
+}
+
+// WriteString implementation.
+func (rw responseWriter) WriteString(s string) (int, error) {
+	return rw.w.Write([]byte(s))
+}
@@ -0,0 +1,59 @@
+package redirectsgin_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/fission-suite/go-redirects/redirectsgin"
+	"github.com/gin-gonic/gin"
+	"github.com/tj/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(redirectsgin.Middleware(redirects.Must(redirects.ParseString(`
+		/home   /           302
+		/app/*  /index.html 200
+		/gone   /404.html   404
+	`))))
+
+	r.GET("/about", func(c *gin.Context) {
+		c.String(200, "about")
+	})
+
+	// rewrites are served by the handlers of the original path
+	r.NoRoute(func(c *gin.Context) {
+		c.String(200, "served "+c.Request.URL.Path)
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/home", nil))
+		assert.Equal(t, 302, w.Code)
+		assert.Equal(t, "/", w.Header().Get("Location"))
+	})
+
+	t.Run("rewrite", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/app/settings", nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "served /index.html", w.Body.String())
+	})
+
+	t.Run("content status", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/gone", nil))
+		assert.Equal(t, 404, w.Code)
+		assert.Equal(t, "served /404.html", w.Body.String())
+	})
+
+	t.Run("pass through", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/about", nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "about", w.Body.String())
+	})
+}
@@ -0,0 +1,112 @@
+// Package redirectstest provides assertions for testing _redirects files,
+// so projects can write readable regression tests against their own rules:
+//
+//	func TestRedirects(t *testing.T) {
+//		rules := redirectstest.Load(t, "public/_redirects")
+//		redirectstest.AssertRedirect(t, rules, "/old", "/new", 301)
+//		redirectstest.AssertNoMatch(t, rules, "/about")
+//	}
+//
+// Requests are given as a path with an optional query string, and are
+// evaluated with redirects.Apply. Use Request for other request properties.
+package redirectstest
+
+import (
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+)
+
+// Load returns the rules of the file at path, failing the test when it
+// cannot be read or parsed.
+func Load(t testing.TB, path string, opts ...redirects.Option) []redirects.Rule {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening rules: %s", err)
+	}
+	defer f.Close()
+
+	rules, err := redirects.Parse(f, opts...)
+	if err != nil {
+		t.Fatalf("parsing %s: %s", path, err)
+	}
+
+	return rules
+}
+
+// Request returns the request for a path with an optional query string,
+// such as "/search?q=go", failing the test when it is malformed.
+func Request(t testing.TB, path string) redirects.Request {
+	t.Helper()
+
+	u, err := url.ParseRequestURI(path)
+	if err != nil {
+		t.Fatalf("invalid request path %q: %s", path, err)
+	}
+
+	return redirects.Request{
+		Method: "GET",
+		Path:   u.Path,
+		Query:  u.Query(),
+	}
+}
+
+// AssertRedirect asserts that a request for path redirects to to with status.
+func AssertRedirect(t testing.TB, rules []redirects.Rule, path, to string, status int) {
+	t.Helper()
+	AssertResponse(t, rules, Request(t, path), redirects.ActionRedirect, to, status)
+}
+
+// AssertRewrite asserts that a request for path serves the content of to
+// with status, such as 200 for a rewrite or 404 for a custom not found page.
+func AssertRewrite(t testing.TB, rules []redirects.Rule, path, to string, status int) {
+	t.Helper()
+	AssertResponse(t, rules, Request(t, path), redirects.ActionRewrite, to, status)
+}
+
+// AssertProxy asserts that a request for path is proxied to the URL to.
+func AssertProxy(t testing.TB, rules []redirects.Rule, path, to string) {
+	t.Helper()
+	AssertResponse(t, rules, Request(t, path), redirects.ActionProxy, to, 0)
+}
+
+// AssertNoMatch asserts that no rule applies to a request for path.
+func AssertNoMatch(t testing.TB, rules []redirects.Rule, path string) {
+	t.Helper()
+
+	res := redirects.Apply(rules, Request(t, path))
+	if res.Action != redirects.ActionNone {
+		t.Errorf("%s: expected no rule to apply, got rule %d (%s) to %s %d", path, res.Index, res.Rule, res.To, res.Status)
+	}
+}
+
+// AssertResponse asserts the action, destination and status of the
+// response to req, for requests with conditions such as a country or an
+// existing file. The status of proxies is zero.
+func AssertResponse(t testing.TB, rules []redirects.Rule, req redirects.Request, action redirects.Action, to string, status int) {
+	t.Helper()
+
+	res := redirects.Apply(rules, req)
+
+	if res.Action != action {
+		if res.Action == redirects.ActionNone {
+			t.Errorf("%s: expected %s to %s, no rule applies", req.Path, action, to)
+			return
+		}
+
+		t.Errorf("%s: expected %s to %s, got %s to %s by rule %d (%s)", req.Path, action, to, res.Action, res.To, res.Index, res.Rule)
+		return
+	}
+
+	if res.To != to {
+		t.Errorf("%s: expected %s to %s, got %s by rule %d (%s)", req.Path, action, to, res.To, res.Index, res.Rule)
+	}
+
+	if res.Status != status {
+		t.Errorf("%s: expected status %d, got %d by rule %d (%s)", req.Path, status, res.Status, res.Index, res.Rule)
+	}
+}
@@ -0,0 +1,114 @@
+package redirectstest_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/fission-suite/go-redirects/redirectstest"
+	"github.com/tj/assert"
+)
+
+// recorder is a testing.TB recording failures.
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+// Helper implementation.
+func (r *recorder) Helper() {}
+
+// Errorf implementation.
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// Fatalf implementation.
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	r.fatal = true
+	runtime.Goexit()
+}
+
+// record runs fn with a recorder, returning it once fn returns or fails.
+func record(fn func(t testing.TB)) *recorder {
+	r := &recorder{}
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		fn(r)
+	}()
+
+	<-done
+	return r
+}
+
+var rules = redirects.Must(redirects.ParseString(`
+	/old      /new
+	/search   q=:q  /find/:q  302
+	/api/*    https://api.example.com/:splat  200
+	/app/*    /app/index.html  200
+	/*        /404.html  404
+`))
+
+func TestAssertions(t *testing.T) {
+	t.Run("passing", func(t *testing.T) {
+		r := record(func(t testing.TB) {
+			redirectstest.AssertRedirect(t, rules, "/old", "/new", 301)
+			redirectstest.AssertRedirect(t, rules, "/old?a=b", "/new?a=b", 301)
+			redirectstest.AssertRedirect(t, rules, "/search?q=go", "/find/go", 302)
+			redirectstest.AssertProxy(t, rules, "/api/users", "https://api.example.com/users")
+			redirectstest.AssertRewrite(t, rules, "/app/settings", "/app/index.html", 200)
+			redirectstest.AssertRewrite(t, rules, "/missing", "/404.html", 404)
+			redirectstest.AssertNoMatch(t, rules[:1], "/about")
+			redirectstest.AssertResponse(t, rules, redirects.Request{Path: "/old", FileExists: true}, redirects.ActionNone, "", 0)
+		})
+
+		assert.Empty(t, r.errors)
+	})
+
+	t.Run("failing", func(t *testing.T) {
+		r := record(func(t testing.TB) {
+			redirectstest.AssertRedirect(t, rules, "/old", "/other", 301)
+			redirectstest.AssertRedirect(t, rules, "/old", "/new", 302)
+			redirectstest.AssertRedirect(t, rules, "/app/x", "/new", 301)
+			redirectstest.AssertRedirect(t, rules[:1], "/about", "/team", 301)
+			redirectstest.AssertNoMatch(t, rules, "/old")
+		})
+
+		assert.Equal(t, []string{
+			"/old: expected redirect to /other, got /new by rule 0 (/old /new 301)",
+			"/old: expected status 302, got 301 by rule 0 (/old /new 301)",
+			"/app/x: expected redirect to /new, got rewrite to /app/index.html by rule 3 (/app/* /app/index.html 200)",
+			"/about: expected redirect to /team, no rule applies",
+			"/old: expected no rule to apply, got rule 0 (/old /new 301) to /new 301",
+		}, r.errors)
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		r := record(func(t testing.TB) {
+			redirectstest.AssertNoMatch(t, rules, "old")
+		})
+
+		assert.True(t, r.fatal)
+		assert.Len(t, r.errors, 1)
+	})
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "_redirects")
+	assert.NoError(t, os.WriteFile(path, []byte("/old  /new\n"), 0644))
+
+	loaded := redirectstest.Load(t, path)
+	assert.Len(t, loaded, 1)
+
+	r := record(func(t testing.TB) {
+		redirectstest.Load(t, filepath.Join(filepath.Dir(path), "missing"))
+	})
+	assert.True(t, r.fatal)
+}
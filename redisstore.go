@@ -0,0 +1,110 @@
+package redirects
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// A RedisClient is the subset of a Redis client used by RedisStore, such as
+// a thin wrapper of github.com/redis/go-redis.
+type RedisClient interface {
+	// Get returns the value of key.
+	Get(ctx context.Context, key string) (string, error)
+
+	// Set sets the value of key.
+	Set(ctx context.Context, key, value string) error
+
+	// Publish publishes a message to channel.
+	Publish(ctx context.Context, channel, message string) error
+
+	// Subscribe returns the messages published to channel until ctx is done.
+	Subscribe(ctx context.Context, channel string) (<-chan string, error)
+}
+
+// RedisStore is a RuleStore keeping rules as a JSON array in a Redis key,
+// publishing to a channel on each save so that watchers reload them.
+type RedisStore struct {
+	// Client is the Redis client.
+	Client RedisClient
+
+	// Key is the key holding the rules. Defaults to "redirects".
+	Key string
+
+	// Channel is the channel notified of changes. Defaults to the key.
+	Channel string
+}
+
+// Load implementation.
+func (s *RedisStore) Load(ctx context.Context) ([]Rule, error) {
+	v, err := s.Client.Get(ctx, s.key())
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRules([]byte(v))
+}
+
+// Save implementation.
+func (s *RedisStore) Save(ctx context.Context, rules []Rule) error {
+	if rules == nil {
+		rules = []Rule{}
+	}
+
+	b, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Client.Set(ctx, s.key(), string(b)); err != nil {
+		return err
+	}
+
+	return s.Client.Publish(ctx, s.channel(), s.key())
+}
+
+// Watch implementation. Messages which fail to load are skipped.
+func (s *RedisStore) Watch(ctx context.Context) (<-chan []Rule, error) {
+	messages, err := s.Client.Subscribe(ctx, s.channel())
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan []Rule)
+
+	go func() {
+		defer close(ch)
+
+		for range messages {
+			rules, err := s.Load(ctx)
+			if err != nil {
+				continue
+			}
+
+			select {
+			case ch <- rules:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// key returns the key holding the rules.
+func (s *RedisStore) key() string {
+	if s.Key == "" {
+		return "redirects"
+	}
+
+	return s.Key
+}
+
+// channel returns the channel notified of changes.
+func (s *RedisStore) channel() string {
+	if s.Channel == "" {
+		return s.key()
+	}
+
+	return s.Channel
+}
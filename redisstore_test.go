@@ -0,0 +1,101 @@
+package redirects_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+// redisClient is an in-memory RedisClient.
+type redisClient struct {
+	mu          sync.Mutex
+	values      map[string]string
+	subscribers map[string][]chan string
+}
+
+func (c *redisClient) Get(ctx context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key], nil
+}
+
+func (c *redisClient) Set(ctx context.Context, key, value string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+	return nil
+}
+
+func (c *redisClient) Publish(ctx context.Context, channel, message string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ch := range c.subscribers[channel] {
+		ch <- message
+	}
+	return nil
+}
+
+func (c *redisClient) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan string, 10)
+	c.subscribers[channel] = append(c.subscribers[channel], ch)
+	go func() {
+		<-ctx.Done()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		subs := c.subscribers[channel][:0]
+		for _, s := range c.subscribers[channel] {
+			if s != ch {
+				subs = append(subs, s)
+			}
+		}
+		c.subscribers[channel] = subs
+		close(ch)
+	}()
+	return ch, nil
+}
+
+func TestRedisStore(t *testing.T) {
+	client := &redisClient{values: map[string]string{}, subscribers: map[string][]chan string{}}
+	store := &redirects.RedisStore{Client: client, Key: "site:redirects"}
+	ctx := context.Background()
+
+	t.Run("save and load", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`/home  /  301`))
+		assert.NoError(t, store.Save(ctx, rules))
+		assert.Contains(t, client.values["site:redirects"], `"From":"/home"`)
+
+		loaded, err := store.Load(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, rules, loaded)
+	})
+
+	t.Run("default status", func(t *testing.T) {
+		client.values["site:redirects"] = `[{"From":"/a","To":"/b"}]`
+
+		rules, err := store.Load(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 301, rules[0].Status)
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		compiled := redirects.Compile(nil)
+		go redirects.Sync(ctx, store, compiled)
+
+		assert.Eventually(t, func() bool { return len(compiled.Rules()) == 1 }, time.Second, time.Millisecond)
+
+		assert.NoError(t, store.Save(ctx, redirects.Must(redirects.ParseString(`
+			/docs  /guide
+			/news  /blog
+		`))))
+		assert.Eventually(t, func() bool { return len(compiled.Rules()) == 2 }, time.Second, time.Millisecond)
+	})
+}
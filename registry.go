@@ -0,0 +1,178 @@
+package redirects
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A Registry holds independent rule sets for many sites keyed by host, so a
+// single gateway process serves the redirects of each. Hosts may use a
+// leading wildcard such as "*.example.com", matching any subdomain unless a
+// more specific host is registered. The registry is a Matcher, matching
+// requests against the rules of their host, so a Handler serves every site.
+//
+// Registry is safe for concurrent use, though its quotas must be set before
+// rules are loaded.
+type Registry struct {
+	// Quota limits the rules of each site without an entry in Quotas,
+	// protecting a shared gateway from one site's pathological rules.
+	// The zero value is unlimited.
+	Quota Quota
+
+	// Quotas are the quotas of sites by registered host, in lower case.
+	Quotas map[string]Quota
+
+	opts []CompileOption
+
+	mu    sync.RWMutex
+	sites map[string]*CompiledRules
+}
+
+// A Quota limits the rules of a site in a Registry, checked as they are
+// loaded.
+type Quota struct {
+	// MaxRules is the maximum number of rules, or zero for no limit.
+	MaxRules int
+
+	// MaxMemory is the maximum estimated memory of the compiled rules in
+	// bytes, or zero for no limit.
+	MaxMemory int64
+
+	// NoProxy rejects proxy rules, so sites can not use the gateway to
+	// reach other hosts.
+	NoProxy bool
+}
+
+// check returns an error wrapping ErrQuotaExceeded if rules exceed q.
+func (q Quota) check(rules []Rule) error {
+	if q.MaxRules > 0 && len(rules) > q.MaxRules {
+		return fmt.Errorf("%w: %d rules, more than %d", ErrQuotaExceeded, len(rules), q.MaxRules)
+	}
+
+	var size int64
+	for i := range rules {
+		r := &rules[i]
+
+		if q.NoProxy && r.IsRewrite() && r.IsProxy() {
+			return fmt.Errorf("%w: rule %d (%s) proxies, which is not allowed", ErrQuotaExceeded, i, r.From)
+		}
+
+		size += ruleSize(r)
+	}
+
+	if q.MaxMemory > 0 && size > q.MaxMemory {
+		return fmt.Errorf("%w: rules use an estimated %d bytes, more than %d", ErrQuotaExceeded, size, q.MaxMemory)
+	}
+
+	return nil
+}
+
+// NewRegistry returns an empty registry, compiling rules with opts.
+func NewRegistry(opts ...CompileOption) *Registry {
+	return &Registry{
+		opts:  opts,
+		sites: make(map[string]*CompiledRules),
+	}
+}
+
+// Load compiles the rules of host, or reloads them when host is registered,
+// leaving the rules of other hosts untouched. Rules exceeding the quota of
+// host are rejected with an error wrapping ErrQuotaExceeded, keeping any
+// rules already loaded.
+func (r *Registry) Load(host string, rules []Rule) (*CompiledRules, error) {
+	key, err := registryKey(host)
+	if err != nil {
+		return nil, err
+	}
+
+	quota, ok := r.Quotas[key]
+	if !ok {
+		quota = r.Quota
+	}
+
+	if err := quota.check(rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", host, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.sites[key]; ok {
+		c.Reload(rules)
+		return c, nil
+	}
+
+	c := Compile(rules, r.opts...)
+	r.sites[key] = c
+	return c, nil
+}
+
+// Remove removes the rules of host, as registered.
+func (r *Registry) Remove(host string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.sites, strings.ToLower(host))
+}
+
+// Hosts returns the registered hosts, sorted.
+func (r *Registry) Hosts() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hosts := make([]string, 0, len(r.sites))
+	for h := range r.sites {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+
+	return hosts
+}
+
+// Lookup returns the rules serving the requested host, which may include a
+// port: those of the host itself, or else of the most specific wildcard
+// matching it.
+func (r *Registry) Lookup(host string) (*CompiledRules, bool) {
+	host = strings.TrimSuffix(strings.ToLower(hostname(host)), ".")
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if c, ok := r.sites[host]; ok {
+		return c, true
+	}
+
+	for {
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			return nil, false
+		}
+
+		host = host[i+1:]
+		if c, ok := r.sites["*."+host]; ok {
+			return c, true
+		}
+	}
+}
+
+// Match returns the result of the first rule of the request's host matching
+// req, and false when none match or the host has no rules.
+func (r *Registry) Match(req Request) (Result, bool) {
+	c, ok := r.Lookup(req.Host)
+	if !ok {
+		return Result{}, false
+	}
+
+	return c.Match(req)
+}
+
+// registryKey returns the key of the host name in a registry.
+func registryKey(name string) (string, error) {
+	if !host.MatchString(name) {
+		return "", fmt.Errorf("invalid host %q", name)
+	}
+
+	return strings.ToLower(name), nil
+}
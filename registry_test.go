@@ -0,0 +1,126 @@
+package redirects_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/tj/assert"
+
+	"github.com/fission-suite/go-redirects"
+)
+
+func TestRegistry(t *testing.T) {
+	r := redirects.NewRegistry()
+
+	_, err := r.Load("example.com", redirects.Must(redirects.ParseString(`/old  /new`)))
+	assert.NoError(t, err)
+
+	_, err = r.Load("*.example.com", redirects.Must(redirects.ParseString(`/old  /tenant`)))
+	assert.NoError(t, err)
+
+	_, err = r.Load("docs.example.com", redirects.Must(redirects.ParseString(`/old  /docs`)))
+	assert.NoError(t, err)
+
+	match := func(host string) string {
+		res, ok := r.Match(redirects.Request{Host: host, Path: "/old"})
+		if !ok {
+			return ""
+		}
+		return res.To
+	}
+
+	t.Run("hosts", func(t *testing.T) {
+		assert.Equal(t, []string{"*.example.com", "docs.example.com", "example.com"}, r.Hosts())
+	})
+
+	t.Run("exact", func(t *testing.T) {
+		assert.Equal(t, "/new", match("example.com"))
+		assert.Equal(t, "/new", match("Example.com:8080"))
+		assert.Equal(t, "/docs", match("docs.example.com"))
+	})
+
+	t.Run("wildcard", func(t *testing.T) {
+		assert.Equal(t, "/tenant", match("blog.example.com"))
+		assert.Equal(t, "/tenant", match("eu.blog.example.com"))
+		assert.Equal(t, "", match("example.org"))
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		before, _ := r.Lookup("docs.example.com")
+
+		after, err := r.Load("docs.example.com", redirects.Must(redirects.ParseString(`/old  /guide`)))
+		assert.NoError(t, err)
+		assert.True(t, before == after)
+		assert.Equal(t, "/guide", match("docs.example.com"))
+		assert.Equal(t, "/new", match("example.com"))
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		r.Remove("docs.example.com")
+		assert.Equal(t, "/tenant", match("docs.example.com"))
+	})
+
+	t.Run("invalid host", func(t *testing.T) {
+		_, err := r.Load("exa mple.com", nil)
+		assert.EqualError(t, err, `invalid host "exa mple.com"`)
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		h := &redirects.Handler{Matcher: r, Next: files}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "http://shop.example.com/old", nil))
+		assert.Equal(t, 301, w.Code)
+		assert.Equal(t, "/tenant", w.Header().Get("Location"))
+	})
+}
+
+func TestRegistry_Quota(t *testing.T) {
+	r := redirects.NewRegistry()
+	r.Quota = redirects.Quota{MaxRules: 2, NoProxy: true}
+	r.Quotas = map[string]redirects.Quota{
+		"big.example.com":  {MaxMemory: 1 << 20},
+		"tiny.example.com": {MaxMemory: 100},
+	}
+
+	rules := redirects.Must(redirects.ParseString(`
+		/a  /b
+		/c  /d
+		/api/*  https://api.example.com/:splat  200
+	`))
+
+	t.Run("max rules", func(t *testing.T) {
+		_, err := r.Load("example.com", rules)
+		assert.EqualError(t, err, "example.com: quota exceeded: 3 rules, more than 2")
+		assert.True(t, errors.Is(err, redirects.ErrQuotaExceeded))
+	})
+
+	t.Run("no proxy", func(t *testing.T) {
+		_, err := r.Load("example.com", rules[1:])
+		assert.EqualError(t, err, "example.com: quota exceeded: rule 1 (/api/*) proxies, which is not allowed")
+	})
+
+	t.Run("max memory", func(t *testing.T) {
+		_, err := r.Load("tiny.example.com", rules[:1])
+		assert.True(t, errors.Is(err, redirects.ErrQuotaExceeded))
+		assert.Contains(t, err.Error(), "more than 100")
+	})
+
+	t.Run("host quota", func(t *testing.T) {
+		_, err := r.Load("big.example.com", rules)
+		assert.NoError(t, err)
+	})
+
+	t.Run("reload keeps rules", func(t *testing.T) {
+		_, err := r.Load("example.com", rules[:1])
+		assert.NoError(t, err)
+
+		_, err = r.Load("example.com", rules)
+		assert.Error(t, err)
+
+		res, ok := r.Match(redirects.Request{Host: "example.com", Path: "/a"})
+		assert.True(t, ok)
+		assert.Equal(t, "/b", res.To)
+	})
+}
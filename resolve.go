@@ -0,0 +1,79 @@
+package redirects
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// A Hop is a single step of a redirect chain.
+type Hop struct {
+	// Path is the requested path, including any query string.
+	Path string
+
+	// Result is the outcome of matching Path.
+	Result
+}
+
+// Resolve follows the redirects of rules from path, which may include a
+// query string, until reaching a path matching no rule, a content response
+// such as a rewrite, or an absolute destination. It returns a hop for each
+// matched rule, the last being the terminal destination, and no hops when
+// path matches no rule.
+//
+// ErrRedirectLoop is returned when the chain redirects to a path it has
+// visited, and ErrTooManyHops when it would follow more than maxHops
+// redirects, along with the hops so far.
+func Resolve(rules []Rule, path string, maxHops int) (hops []Hop, err error) {
+	start := path
+	seen := map[string]bool{path: true}
+
+	for {
+		req, err := pathRequest(path)
+		if err != nil {
+			return hops, err
+		}
+
+		res, ok := Match(rules, req)
+
+		if !ok {
+			return hops, nil
+		}
+
+		terminal := res.Rule.IsContent() || isAbsolute(res.To)
+		if !terminal && len(hops) == maxHops {
+			return hops, fmt.Errorf("%w: more than %d from %q", ErrTooManyHops, maxHops, start)
+		}
+
+		hops = append(hops, Hop{Path: path, Result: res})
+		if terminal {
+			return hops, nil
+		}
+
+		if seen[res.To] {
+			return hops, fmt.Errorf("%w: %q redirects back to %q", ErrRedirectLoop, path, res.To)
+		}
+
+		seen[res.To] = true
+		path = res.To
+	}
+}
+
+// isAbsolute returns true if to has a host, such as "https://example.com/"
+// or "//example.com/".
+func isAbsolute(to string) bool {
+	u, err := url.Parse(to)
+	return err == nil && u.Host != ""
+}
+
+// pathRequest returns the request for path, which may include a query string.
+func pathRequest(path string) (Request, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return Request{}, fmt.Errorf("invalid path %q: %w", path, err)
+	}
+
+	return Request{
+		Path:  u.Path,
+		Query: u.Query(),
+	}, nil
+}
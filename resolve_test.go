@@ -0,0 +1,75 @@
+package redirects_test
+
+import (
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/pkg/errors"
+	"github.com/tj/assert"
+)
+
+// tos returns the destinations of hops.
+func tos(hops []redirects.Hop) (v []string) {
+	for _, h := range hops {
+		v = append(v, h.To)
+	}
+	return
+}
+
+func TestResolve(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/old/:slug    /legacy/:slug
+		/legacy/:slug /blog/:slug  302
+		/blog/*       /index.html  200
+		/docs         https://docs.example.com/
+		/gone         /410.html  410
+		/a            /b
+		/b            /a
+		/1            /2
+		/2            /3
+		/3            /4
+	`))
+
+	t.Run("chain to rewrite", func(t *testing.T) {
+		hops, err := redirects.Resolve(rules, "/old/hello?ref=x", 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"/legacy/hello?ref=x", "/blog/hello?ref=x", "/index.html?ref=x"}, tos(hops))
+		assert.Equal(t, "/old/hello?ref=x", hops[0].Path)
+		assert.Equal(t, 200, hops[2].Rule.Status)
+	})
+
+	t.Run("absolute destination", func(t *testing.T) {
+		hops, err := redirects.Resolve(rules, "/docs", 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"https://docs.example.com/"}, tos(hops))
+	})
+
+	t.Run("content response", func(t *testing.T) {
+		hops, err := redirects.Resolve(rules, "/gone", 10)
+		assert.NoError(t, err)
+		assert.Len(t, hops, 1)
+		assert.Equal(t, 410, hops[0].Rule.Status)
+	})
+
+	t.Run("unmatched", func(t *testing.T) {
+		hops, err := redirects.Resolve(rules, "/about", 10)
+		assert.NoError(t, err)
+		assert.Empty(t, hops)
+	})
+
+	t.Run("loop", func(t *testing.T) {
+		hops, err := redirects.Resolve(rules, "/a", 10)
+		assert.True(t, errors.Is(err, redirects.ErrRedirectLoop), "got %v", err)
+		assert.Equal(t, []string{"/b", "/a"}, tos(hops))
+	})
+
+	t.Run("hop limit", func(t *testing.T) {
+		hops, err := redirects.Resolve(rules, "/1", 2)
+		assert.True(t, errors.Is(err, redirects.ErrTooManyHops), "got %v", err)
+		assert.Equal(t, []string{"/2", "/3"}, tos(hops))
+
+		hops, err = redirects.Resolve(rules, "/1", 3)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"/2", "/3", "/4"}, tos(hops))
+	})
+}
@@ -0,0 +1,148 @@
+package redirects_test
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+// ruleSet is a generated set of valid rules.
+type ruleSet []redirects.Rule
+
+// Generate implementation.
+func (ruleSet) Generate(r *rand.Rand, size int) reflect.Value {
+	rules := make(ruleSet, r.Intn(size+1))
+	for i := range rules {
+		for {
+			rules[i] = generateRule(r)
+			if redirects.Validate(rules[i:i+1]) == nil {
+				break
+			}
+		}
+	}
+
+	return reflect.ValueOf(rules)
+}
+
+// pick returns one of choices.
+func pick(r *rand.Rand, choices ...string) string {
+	return choices[r.Intn(len(choices))]
+}
+
+// some returns up to n of choices, or nil.
+func some(r *rand.Rand, n int, choices ...string) (list []string) {
+	for i := r.Intn(n + 1); i > 0; i-- {
+		list = append(list, pick(r, choices...))
+	}
+	return
+}
+
+// generateRule returns a random rule, which may not be valid.
+func generateRule(r *rand.Rand) redirects.Rule {
+	rule := redirects.Rule{
+		From:     pick(r, "", "https://example.com", "http://*.example.org") + "/" + strings.Join(some(r, 3, "blog", "news", ":id", ":slug", "a-b", "%C3%A9"), "/") + pick(r, "", "", "/", "/*"),
+		To:       pick(r, "/", "/:id", "/posts/:splat", "/a?b=c", "https://api.example.com/:splat", "/search?q=:q"),
+		Status:   []int{200, 301, 302, 303, 307, 308, 404, 410, 451}[r.Intn(9)],
+		Force:    r.Intn(2) == 0,
+		Country:  some(r, 2, "au", "nz", "DE"),
+		Language: some(r, 2, "en", "fr", "zh-Hant"),
+		Method:   some(r, 2, "GET", "POST"),
+		Host:     some(r, 2, "example.com", "*.example.net"),
+	}
+
+	for _, k := range some(r, 3, "q", "id", "50%", "a=b", "utm source", "next") {
+		if rule.Params == nil {
+			rule.Params = make(redirects.Params)
+		}
+
+		rule.Params[k] = pick(r, ":q", "value", "a=b", "50% off", "https://example.com/x", "tab\tchar", "")
+	}
+
+	for _, k := range some(r, 2, "Header:X-Canary", "Header:Accept") {
+		if rule.Conditions == nil {
+			rule.Conditions = make(redirects.Conditions)
+		}
+		rule.Conditions[k] = pick(r, "true", "text/html", "a=b")
+	}
+
+	if r.Intn(4) == 0 {
+		rule.ActiveFrom = time.Unix(r.Int63n(2e9), 0).UTC()
+		rule.ActiveUntil = rule.ActiveFrom.Add(time.Duration(r.Intn(1e6)+1) * time.Second)
+	}
+
+	for _, k := range some(r, 2, "owner", "expires", "ticket") {
+		if rule.Meta == nil {
+			rule.Meta = make(map[string]string)
+		}
+		rule.Meta[k] = pick(r, "seo-team", "2025-01-01", "a=b", "")
+	}
+
+	return rule
+}
+
+func TestMarshal_roundTrip(t *testing.T) {
+	roundTrip := func(rules ruleSet) bool {
+		parsed, err := redirects.Parse(strings.NewReader(string(redirects.Marshal(rules))))
+		if err != nil {
+			t.Logf("parsing: %s", err)
+			return false
+		}
+
+		if len(rules) == 0 {
+			return len(parsed) == 0
+		}
+
+		if !reflect.DeepEqual([]redirects.Rule(rules), parsed) {
+			for i := range parsed {
+				if !reflect.DeepEqual(rules[i], parsed[i]) {
+					t.Logf("rule %d\n  %#v\n  %#v", i, rules[i], parsed[i])
+					break
+				}
+			}
+			return false
+		}
+
+		return true
+	}
+
+	err := quick.Check(roundTrip, &quick.Config{
+		MaxCount: 500,
+		Rand:     rand.New(rand.NewSource(1)),
+	})
+	assert.NoError(t, err)
+}
+
+func FuzzMarshal(f *testing.F) {
+	for _, s := range []string{
+		"/home  /",
+		"/store id=:id tag=:tag  /item/:tag/:id  302!",
+		"/a q=50%25%20off  /b  Country=au,nz Language=en",
+		"#@ owner=seo-team\n/old  /new  301  Header:X-Canary=true",
+		"/sale  /winter  302  From=2024-12-01T00:00Z Until=2025-01-01",
+		"https://example.com/*  https://www.example.com/:splat  301!  Host=example.com",
+	} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		rules, err := redirects.ParseString(s)
+		if err != nil {
+			return
+		}
+
+		again, err := redirects.ParseString(string(redirects.Marshal(rules)))
+		if err != nil {
+			t.Fatalf("parsing marshalled rules: %s", err)
+		}
+
+		if len(rules) > 0 && !reflect.DeepEqual(rules, again) {
+			t.Fatalf("rules differ\n%#v\n%#v", rules, again)
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package redirects
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// An S3RoutingRule is an Amazon S3 static website routing rule.
+type S3RoutingRule struct {
+	Condition *S3Condition `xml:",omitempty" json:",omitempty"`
+	Redirect  S3Redirect
+}
+
+// An S3Condition is the condition of an S3 routing rule.
+type S3Condition struct {
+	KeyPrefixEquals string `xml:",omitempty" json:",omitempty"`
+}
+
+// An S3Redirect is the redirect of an S3 routing rule.
+type S3Redirect struct {
+	HostName             string `xml:",omitempty" json:",omitempty"`
+	HttpRedirectCode     string `xml:",omitempty" json:",omitempty"`
+	Protocol             string `xml:",omitempty" json:",omitempty"`
+	ReplaceKeyPrefixWith string `xml:",omitempty" json:",omitempty"`
+	ReplaceKeyWith       string `xml:",omitempty" json:",omitempty"`
+}
+
+// S3RoutingRules returns the S3 website routing rules equivalent to rules.
+// S3 only redirects, matching keys by prefix, so rules are supported when
+// they redirect a static path, or a wildcard to a destination ending with
+// :splat, without query params or conditions. As matching is by prefix a
+// static source also redirects the keys it prefixes, so order longer
+// sources first. Other rules are skipped and reported.
+func S3RoutingRules(rules []Rule) (out []S3RoutingRule, skipped []Unsupported) {
+	for i, r := range rules {
+		v, reason := s3RoutingRule(r)
+		if reason != "" {
+			skipped = append(skipped, Unsupported{Index: i, Rule: r, Reason: reason})
+			continue
+		}
+
+		out = append(out, v)
+	}
+
+	return
+}
+
+// WriteS3RoutingRulesXML writes the S3 routing rules of rules as the
+// RoutingRules element of a website configuration, see S3RoutingRules.
+func WriteS3RoutingRulesXML(w io.Writer, rules []Rule) ([]Unsupported, error) {
+	v, skipped := S3RoutingRules(rules)
+
+	doc := struct {
+		XMLName xml.Name        `xml:"RoutingRules"`
+		Rules   []S3RoutingRule `xml:"RoutingRule"`
+	}{Rules: v}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(doc); err != nil {
+		return skipped, err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return skipped, err
+}
+
+// WriteS3RoutingRulesJSON writes the S3 routing rules of rules as the JSON
+// array accepted by the S3 console, see S3RoutingRules.
+func WriteS3RoutingRulesJSON(w io.Writer, rules []Rule) ([]Unsupported, error) {
+	v, skipped := S3RoutingRules(rules)
+	if v == nil {
+		v = []S3RoutingRule{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return skipped, enc.Encode(v)
+}
+
+// s3RoutingRule returns the routing rule of r, or the reason it is unsupported.
+func s3RoutingRule(r Rule) (v S3RoutingRule, reason string) {
+	if isExclusion(r.From) {
+		return v, "exclusion rules are not supported"
+	}
+
+	if r.IsContent() {
+		return v, "only redirects are supported"
+	}
+
+	if reason := unsupportedCondition(r); reason != "" {
+		return v, reason
+	}
+
+	if extendedWildcard(r.From) {
+		return v, "double and mid-path wildcards are not supported"
+	}
+
+	to, err := url.Parse(r.To)
+	if err != nil {
+		return v, "invalid destination"
+	}
+
+	if to.RawQuery != "" {
+		return v, "destination query strings are not supported"
+	}
+
+	v.Redirect.HttpRedirectCode = strconv.Itoa(r.Status)
+	v.Redirect.HostName = to.Host
+	v.Redirect.Protocol = to.Scheme
+
+	from := strings.TrimPrefix(r.From, "/")
+	path := strings.TrimPrefix(to.Path, "/")
+	wildcard := strings.HasSuffix(from, "*")
+
+	if wildcard {
+		if !strings.HasSuffix(path, ":splat") {
+			return v, "wildcards must redirect to a destination ending with :splat"
+		}
+		from = strings.TrimSuffix(from, "*")
+		path = strings.TrimSuffix(path, ":splat")
+	}
+
+	if placeholder.MatchString(from) || placeholder.MatchString(path) {
+		return v, "placeholders are not supported"
+	}
+
+	if !wildcard && from == "" {
+		return v, "the root path cannot be matched exactly"
+	}
+
+	if !wildcard && path == "" {
+		return v, "redirects to the root path are not supported"
+	}
+
+	if from != "" {
+		v.Condition = &S3Condition{KeyPrefixEquals: from}
+	}
+
+	if wildcard {
+		v.Redirect.ReplaceKeyPrefixWith = path
+	} else {
+		v.Redirect.ReplaceKeyWith = path
+	}
+
+	return v, ""
+}
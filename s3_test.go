@@ -0,0 +1,92 @@
+package redirects_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestS3RoutingRules(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/old-page     /new-page
+		/docs/*       /guide/:splat  302
+		/blog/*       https://blog.example.com/:splat
+		/app/*        /index.html  200
+		/posts/:slug  /blog/:slug
+		/home         /
+		/             /home
+		/fr/*         /fr/:splat  302  Language=fr
+	`))
+
+	v, skipped := redirects.S3RoutingRules(rules)
+
+	assert.Equal(t, []redirects.S3RoutingRule{
+		{
+			Condition: &redirects.S3Condition{KeyPrefixEquals: "old-page"},
+			Redirect:  redirects.S3Redirect{HttpRedirectCode: "301", ReplaceKeyWith: "new-page"},
+		},
+		{
+			Condition: &redirects.S3Condition{KeyPrefixEquals: "docs/"},
+			Redirect:  redirects.S3Redirect{HttpRedirectCode: "302", ReplaceKeyPrefixWith: "guide/"},
+		},
+		{
+			Condition: &redirects.S3Condition{KeyPrefixEquals: "blog/"},
+			Redirect:  redirects.S3Redirect{HostName: "blog.example.com", Protocol: "https", HttpRedirectCode: "301"},
+		},
+	}, v)
+
+	var reasons []string
+	for _, s := range skipped {
+		reasons = append(reasons, s.String())
+	}
+
+	assert.Equal(t, []string{
+		"rule 3 (/app/*): only redirects are supported",
+		"rule 4 (/posts/:slug): placeholders are not supported",
+		"rule 5 (/home): redirects to the root path are not supported",
+		"rule 6 (/): the root path cannot be matched exactly",
+		"rule 7 (/fr/*): conditions are not supported",
+	}, reasons)
+}
+
+func TestWriteS3RoutingRulesXML(t *testing.T) {
+	var b strings.Builder
+	skipped, err := redirects.WriteS3RoutingRulesXML(&b, redirects.Must(redirects.ParseString(`/docs/* /guide/:splat 302`)))
+	assert.NoError(t, err)
+	assert.Empty(t, skipped)
+
+	assert.Equal(t, `<RoutingRules>
+  <RoutingRule>
+    <Condition>
+      <KeyPrefixEquals>docs/</KeyPrefixEquals>
+    </Condition>
+    <Redirect>
+      <HttpRedirectCode>302</HttpRedirectCode>
+      <ReplaceKeyPrefixWith>guide/</ReplaceKeyPrefixWith>
+    </Redirect>
+  </RoutingRule>
+</RoutingRules>
+`, b.String())
+}
+
+func TestWriteS3RoutingRulesJSON(t *testing.T) {
+	var b strings.Builder
+	skipped, err := redirects.WriteS3RoutingRulesJSON(&b, redirects.Must(redirects.ParseString(`/docs/* /guide/:splat 302`)))
+	assert.NoError(t, err)
+	assert.Empty(t, skipped)
+
+	assert.Equal(t, `[
+  {
+    "Condition": {
+      "KeyPrefixEquals": "docs/"
+    },
+    "Redirect": {
+      "HttpRedirectCode": "302",
+      "ReplaceKeyPrefixWith": "guide/"
+    }
+  }
+]
+`, b.String())
+}
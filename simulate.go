@@ -0,0 +1,185 @@
+package redirects
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// A Sample is a requested path, which may include a query string, along
+// with the number of times it was requested, such as in an access log.
+type Sample struct {
+	// Path is the requested path.
+	Path string
+
+	// Count is the number of requests.
+	Count uint64
+}
+
+// ReadSamples returns the samples of a traffic sample with a request per
+// line, in order of first appearance with the counts of identical paths
+// summed. Lines are either a path optionally followed or preceded by a
+// count, such as the output of "uniq -c", or an access log line in the
+// Common or Combined Log Format, whose request line is quoted. Blank lines
+// and lines without a path are skipped.
+func ReadSamples(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+	index := make(map[string]int)
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		path, count, ok := parseSample(s.Text())
+		if !ok {
+			continue
+		}
+
+		if i, ok := index[path]; ok {
+			samples[i].Count += count
+			continue
+		}
+
+		index[path] = len(samples)
+		samples = append(samples, Sample{Path: path, Count: count})
+	}
+
+	return samples, s.Err()
+}
+
+// parseSample returns the path and count of a sample line.
+func parseSample(line string) (path string, count uint64, ok bool) {
+	if i := strings.IndexByte(line, '"'); i >= 0 {
+		request := line[i+1:]
+		if j := strings.IndexByte(request, '"'); j >= 0 {
+			request = request[:j]
+		}
+
+		fields := strings.Fields(request)
+		if len(fields) < 2 || !strings.HasPrefix(fields[1], "/") {
+			return "", 0, false
+		}
+
+		return fields[1], 1, true
+	}
+
+	fields := strings.Fields(line)
+	count = 1
+
+	for _, f := range fields {
+		switch {
+		case strings.HasPrefix(f, "/"):
+			path = f
+		default:
+			n, err := strconv.ParseUint(f, 10, 64)
+			if err != nil {
+				return "", 0, false
+			}
+			count = n
+		}
+	}
+
+	return path, count, path != ""
+}
+
+// A Change is a sampled path served differently by two rule sets.
+type Change struct {
+	Sample
+
+	// Before is the response of the current rules.
+	Before Response
+
+	// After is the response of the candidate rules.
+	After Response
+}
+
+// A Simulation estimates the effect of changing rules on traffic.
+type Simulation struct {
+	// Requests is the number of sampled requests.
+	Requests uint64
+
+	// Changed is the number of sampled requests served differently.
+	Changed uint64
+
+	// Changes are the paths served differently, most requested first.
+	Changes []Change
+}
+
+// Simulate returns the sampled GET requests which the candidate rules serve
+// with a different action, destination or status than the current rules,
+// to estimate the effect of a change before shipping it. Only the path and
+// query string of samples are known, so rules with other conditions match
+// as they would for a request without a country, language, host or headers.
+func Simulate(current, candidate []Rule, samples []Sample) (s Simulation) {
+	for _, sample := range samples {
+		s.Requests += sample.Count
+
+		req, err := pathRequest(sample.Path)
+		if err != nil {
+			continue
+		}
+		req.Method = "GET"
+
+		before := Apply(current, req)
+		after := Apply(candidate, req)
+		if sameResponse(before, after) {
+			continue
+		}
+
+		s.Changed += sample.Count
+		s.Changes = append(s.Changes, Change{Sample: sample, Before: before, After: after})
+	}
+
+	sort.SliceStable(s.Changes, func(i, j int) bool {
+		return s.Changes[i].Count > s.Changes[j].Count
+	})
+
+	return
+}
+
+// sameResponse returns true if a and b serve a request identically.
+func sameResponse(a, b Response) bool {
+	return a.Action == b.Action && a.To == b.To && a.Status == b.Status
+}
+
+// describeResponse returns the action, status and destination of res.
+func describeResponse(res Response) string {
+	switch res.Action {
+	case ActionNone:
+		return "none"
+	case ActionProxy:
+		return "proxy " + res.To
+	default:
+		return fmt.Sprintf("%s %d %s", res.Action, res.Status, res.To)
+	}
+}
+
+// WriteTo writes a human-readable report, with the changed requests as a
+// share of all sampled requests followed by each change.
+func (s Simulation) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+
+	share := 0.0
+	if s.Requests > 0 {
+		share = float64(s.Changed) / float64(s.Requests) * 100
+	}
+
+	fmt.Fprintf(&b, "requests  %d\n", s.Requests)
+	fmt.Fprintf(&b, "changed   %d (%.1f%%)\n", s.Changed, share)
+
+	if len(s.Changes) > 0 {
+		b.WriteString("\n")
+
+		tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "count\tpath\tbefore\tafter")
+		for _, c := range s.Changes {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", c.Count, c.Path, describeResponse(c.Before), describeResponse(c.After))
+		}
+		tw.Flush()
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
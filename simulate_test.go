@@ -0,0 +1,57 @@
+package redirects_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestReadSamples(t *testing.T) {
+	samples, err := redirects.ReadSamples(strings.NewReader(strings.Join([]string{
+		"/a",
+		"/b 10",
+		"   3 /a",
+		"",
+		`127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /c?x=1 HTTP/1.1" 200 2326 "-" "curl/8.0"`,
+		`127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "-" 400 0`,
+		"total",
+	}, "\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, []redirects.Sample{
+		{Path: "/a", Count: 4},
+		{Path: "/b", Count: 10},
+		{Path: "/c?x=1", Count: 1},
+	}, samples)
+}
+
+func TestSimulate(t *testing.T) {
+	current := redirects.Must(redirects.ParseString("/blog/*  /posts/:splat\n/old  /new  302\n"))
+	candidate := redirects.Must(redirects.ParseString("/blog/*  /articles/:splat\n/old  /new  302\n/api/*  https://api.example.com/:splat  200\n"))
+
+	s := redirects.Simulate(current, candidate, []redirects.Sample{
+		{Path: "/blog/a", Count: 5},
+		{Path: "/old", Count: 50},
+		{Path: "/api/users", Count: 20},
+		{Path: "/about", Count: 25},
+	})
+
+	assert.Equal(t, uint64(100), s.Requests)
+	assert.Equal(t, uint64(25), s.Changed)
+	assert.Len(t, s.Changes, 2)
+	assert.Equal(t, "/api/users", s.Changes[0].Path)
+	assert.Equal(t, redirects.ActionNone, s.Changes[0].Before.Action)
+	assert.Equal(t, redirects.ActionProxy, s.Changes[0].After.Action)
+
+	var b strings.Builder
+	_, err := s.WriteTo(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, `requests  100
+changed   25 (25.0%)
+
+count  path        before                 after
+20     /api/users  none                   proxy https://api.example.com/users
+5      /blog/a     redirect 301 /posts/a  redirect 301 /articles/a
+`, b.String())
+}
@@ -0,0 +1,75 @@
+package redirects
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// A SitemapConflict reports a rule applying to a page listed in a sitemap,
+// which risks the page being de-indexed.
+type SitemapConflict struct {
+	// Index is the position of the rule in the audited slice.
+	Index int
+
+	// Rule is the flagged rule.
+	Rule Rule
+
+	// URL is the sitemap URL the rule applies to.
+	URL string
+
+	// Reason describes the problem.
+	Reason string
+}
+
+// String returns a description of the finding.
+func (s SitemapConflict) String() string {
+	return fmt.Sprintf("rule %d (%s): %s", s.Index, s.Rule.From, s.Reason)
+}
+
+// AuditSitemap returns the rules conflicting with the pages of a sitemap,
+// ordered by rule, to prevent accidental de-indexing during migrations:
+//
+// - forced rules hiding a page, as they apply although the page exists
+// - redirects which are not forced matching a page, which never apply while
+// the page exists, and otherwise redirect a URL the sitemap still lists
+//
+// Pages are requested with GET, with the host of their URL.
+func AuditSitemap(rules []Rule, sitemap io.Reader) (found []SitemapConflict, err error) {
+	urls, err := readSitemap(sitemap)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading sitemap")
+	}
+
+	for _, u := range urls {
+		req := Request{Method: "GET", Host: u.Host, Path: cleanPath(u.Path), Query: u.Query(), FileExists: true}
+
+		if res := Apply(rules, req); res.Action != ActionNone {
+			found = append(found, SitemapConflict{
+				Index:  res.Index,
+				Rule:   *res.Rule,
+				URL:    u.String(),
+				Reason: fmt.Sprintf("forced rule hides the live page %s", u.Path),
+			})
+			continue
+		}
+
+		req.FileExists = false
+		if res := Apply(rules, req); res.Action == ActionRedirect {
+			found = append(found, SitemapConflict{
+				Index:  res.Index,
+				Rule:   *res.Rule,
+				URL:    u.String(),
+				Reason: fmt.Sprintf("redirects %s listed in the sitemap, yet never applies while the page exists", u.Path),
+			})
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].Index < found[j].Index
+	})
+
+	return found, nil
+}
@@ -0,0 +1,36 @@
+package redirects_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestAuditSitemap(t *testing.T) {
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/</loc></url>
+  <url><loc>https://example.com/pricing/</loc></url>
+  <url><loc>https://example.com/blog/launch</loc></url>
+  <url><loc>https://example.com/docs/start</loc></url>
+</urlset>`
+
+	rules := redirects.Must(redirects.ParseString(`
+		/blog/*   /posts/:splat  301
+		/pricing  /plans         302!
+		/docs/*   /docs/index.html  200
+		/old      /
+	`))
+
+	found, err := redirects.AuditSitemap(rules, strings.NewReader(sitemap))
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+	assert.Equal(t, "rule 0 (/blog/*): redirects /blog/launch listed in the sitemap, yet never applies while the page exists", found[0].String())
+	assert.Equal(t, "rule 1 (/pricing): forced rule hides the live page /pricing/", found[1].String())
+	assert.Equal(t, "https://example.com/pricing/", found[1].URL)
+
+	_, err = redirects.AuditSitemap(rules, strings.NewReader("<urlset"))
+	assert.Contains(t, err.Error(), "reading sitemap")
+}
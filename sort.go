@@ -0,0 +1,303 @@
+package redirects
+
+import (
+	"sort"
+	"strings"
+)
+
+// segment kinds ordered by specificity.
+const (
+	splatSegment = iota
+	placeholderSegment
+	staticSegment
+)
+
+// A Shadow reports a rule which never matches because an earlier,
+// broader rule always matches first.
+type Shadow struct {
+	// Rule is the index of the broader rule.
+	Rule int
+
+	// Shadowed is the index of the narrower rule which never matches.
+	Shadowed int
+}
+
+// SortBySpecificity returns a copy of rules ordered from most to least
+// specific source path. Static segments are more specific than placeholders,
+// which are more specific than a wildcard, and rules with conditions or
+// params are more specific than those without. Ties keep their input order.
+//
+// Rules are matched in order, so sorting changes which rule applies; see
+// SuggestOrder for a reordering which only fixes shadowed rules.
+func SortBySpecificity(rules []Rule) []Rule {
+	sorted := make([]Rule, len(rules))
+	copy(sorted, rules)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return compareSpecificity(sorted[i], sorted[j]) > 0
+	})
+
+	return sorted
+}
+
+// Shadows returns the rules which never match because an earlier rule
+// matches every request they would, for example "/blog/*" before "/blog/news".
+// Rules with more Country and Language conditions than an earlier rule with
+// the same source are not shadowed, as they apply first, see Match.
+func Shadows(rules []Rule) (shadows []Shadow) {
+	for j := range rules {
+		for i := 0; i < j; i++ {
+			if covers(rules[i], rules[j]) && !localeVariant(&rules[i], &rules[j]) {
+				shadows = append(shadows, Shadow{Rule: i, Shadowed: j})
+				break
+			}
+		}
+	}
+
+	return
+}
+
+// SuggestOrder returns a copy of rules where each shadowed rule is moved
+// directly before the first rule shadowing it, leaving the relative order
+// of all other rules untouched, along with the shadows which were found.
+// The rules are returned unchanged when nothing is shadowed.
+func SuggestOrder(rules []Rule) ([]Rule, []Shadow) {
+	found := Shadows(rules)
+
+	var order []Rule
+	for _, r := range rules {
+		at := len(order)
+		for i := range order {
+			if covers(order[i], r) && !localeVariant(&order[i], &r) {
+				at = i
+				break
+			}
+		}
+
+		order = append(order, Rule{})
+		copy(order[at+1:], order[at:])
+		order[at] = r
+	}
+
+	return order, found
+}
+
+// covers returns true if rule a matches every request rule b matches.
+func covers(a, b Rule) bool {
+	if !coversPath(sourcePattern(a.From), sourcePattern(b.From)) {
+		return false
+	}
+
+	for k, v := range a.Params {
+		if bv, ok := b.Params[k]; !ok || bv != v {
+			return false
+		}
+	}
+
+	for k, v := range a.Conditions {
+		if bv, ok := b.Conditions[k]; !ok || bv != v {
+			return false
+		}
+	}
+
+	if !a.ActiveFrom.IsZero() && (b.ActiveFrom.IsZero() || b.ActiveFrom.Before(a.ActiveFrom)) {
+		return false
+	}
+
+	if !a.ActiveUntil.IsZero() && (b.ActiveUntil.IsZero() || b.ActiveUntil.After(a.ActiveUntil)) {
+		return false
+	}
+
+	return coversCountries(a.Country, b.Country) && coversLanguages(a.Language, b.Language) && coversList(a.Method, b.Method) && coversList(a.Host, b.Host)
+}
+
+// coversPath returns true if pattern a matches every path pattern b matches.
+func coversPath(a, b string) bool {
+	if !strings.HasPrefix(a, "/") || !strings.HasPrefix(b, "/") {
+		return a == b
+	}
+
+	as := segments(a)
+	bs := segments(b)
+
+	for i, s := range as {
+		if s == "*" && i == len(as)-1 {
+			return true
+		}
+
+		if i >= len(bs) {
+			return false
+		}
+
+		switch segmentKind(s) {
+		case placeholderSegment:
+			_, c := placeholderName(s)
+
+			switch segmentKind(bs[i]) {
+			case splatSegment:
+				return false
+			case placeholderSegment:
+				if _, bc := placeholderName(bs[i]); c != "" && c != bc {
+					return false
+				}
+			default:
+				if !matchConstraint(c, bs[i]) {
+					return false
+				}
+			}
+		default:
+			if s != bs[i] {
+				return false
+			}
+		}
+	}
+
+	return len(as) == len(bs)
+}
+
+// coversList returns true if condition values a allow everything b allows.
+func coversList(a, b []string) bool {
+	if len(a) == 0 {
+		return true
+	}
+
+	if len(b) == 0 {
+		return false
+	}
+
+	for _, v := range b {
+		if !containsFold(a, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// coversCountries returns true if every country allowed by b is allowed
+// by a, either of which may be negated as in "Country=!cn".
+func coversCountries(a, b []string) bool {
+	if !negated(a) {
+		return coversList(a, b)
+	}
+
+	if len(b) == 0 {
+		return false
+	}
+
+	for _, c := range a {
+		name := strings.TrimPrefix(c, "!")
+		if name == "" || name == c {
+			continue
+		}
+
+		// a negated b must exclude as well, a listed b must not list
+		if containsFold(b, c) != negated(b) || containsFold(b, name) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// coversLanguages returns true if every language allowed by b is matched
+// by one allowed by a, as "pt" matches "pt-br".
+func coversLanguages(a, b []string) bool {
+	if len(a) == 0 {
+		return true
+	}
+
+	for _, l := range b {
+		if !matchLanguage(a, []string{l}) {
+			return false
+		}
+	}
+
+	return len(b) > 0
+}
+
+// compareSpecificity returns a positive number when a is more specific than b,
+// negative when less specific, and zero when they are equally specific.
+func compareSpecificity(a, b Rule) int {
+	as := segments(sourcePattern(a.From))
+	bs := segments(sourcePattern(b.From))
+
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if d := segmentKind(as[i]) - segmentKind(bs[i]); d != 0 {
+			return d
+		}
+	}
+
+	// a trailing wildcard also matches the shorter path
+	switch {
+	case len(as) > len(bs):
+		if segmentKind(as[len(bs)]) == splatSegment {
+			return -1
+		}
+		return 1
+	case len(as) < len(bs):
+		if segmentKind(bs[len(as)]) == splatSegment {
+			return 1
+		}
+		return -1
+	}
+
+	return conditions(a) - conditions(b)
+}
+
+// conditions returns the number of conditions and params of r.
+func conditions(r Rule) int {
+	n := len(r.Params) + len(r.Conditions)
+
+	if len(r.Country) > 0 {
+		n++
+	}
+
+	if len(r.Language) > 0 {
+		n++
+	}
+
+	if len(r.Method) > 0 {
+		n++
+	}
+
+	if len(r.Host) > 0 {
+		n++
+	}
+
+	if !r.ActiveFrom.IsZero() || !r.ActiveUntil.IsZero() {
+		n++
+	}
+
+	return n
+}
+
+// segments returns the non-empty slash separated segments of path p.
+func segments(p string) []string {
+	return strings.FieldsFunc(p, func(r rune) bool {
+		return r == '/'
+	})
+}
+
+// segmentKind returns the kind of path segment s.
+func segmentKind(s string) int {
+	switch {
+	case isWildcard(s):
+		return splatSegment
+	case strings.HasPrefix(s, ":"):
+		return placeholderSegment
+	default:
+		return staticSegment
+	}
+}
+
+// containsFold returns true if list contains s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+
+	return false
+}
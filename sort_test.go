@@ -0,0 +1,169 @@
+package redirects_test
+
+import (
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+// froms returns the source paths of rules.
+func froms(rules []redirects.Rule) (s []string) {
+	for _, r := range rules {
+		s = append(s, r.From)
+	}
+	return
+}
+
+func TestSortBySpecificity(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/*              /index.html  200
+		/blog/*         /posts/:splat
+		/blog/:year     /posts/:year
+		/blog/featured  /posts/featured
+		/blog/:year/:id /posts/:year/:id
+		/               /anz  302  Country=au
+		/               /home
+	`))
+
+	sorted := redirects.SortBySpecificity(rules)
+
+	assert.Equal(t, []string{
+		"/blog/featured",
+		"/blog/:year/:id",
+		"/blog/:year",
+		"/blog/*",
+		"/",
+		"/",
+		"/*",
+	}, froms(sorted))
+	assert.Equal(t, "/anz", sorted[4].To)
+	assert.Equal(t, "/*", rules[0].From, "input is not modified")
+}
+
+func TestShadows(t *testing.T) {
+	t.Run("wildcard before narrower rules", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/blog/*        /posts/:splat
+			/blog/news     /news
+			/about         /team
+			/blog/:year/:m /archive/:year/:m
+		`))
+
+		assert.Equal(t, []redirects.Shadow{
+			{Rule: 0, Shadowed: 1},
+			{Rule: 0, Shadowed: 3},
+		}, redirects.Shadows(rules))
+	})
+
+	t.Run("placeholder does not shadow wildcard", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/blog/:year /posts/:year
+			/blog/*     /posts/:splat
+		`))
+
+		assert.Empty(t, redirects.Shadows(rules))
+	})
+
+	t.Run("conditions", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/  /anz  302  Country=au,nz
+			/  /au   302  Country=au
+			/  /en   302  Language=en
+			/  /home
+		`))
+
+		assert.Equal(t, []redirects.Shadow{
+			{Rule: 0, Shadowed: 1},
+		}, redirects.Shadows(rules))
+	})
+
+	t.Run("language regions", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/a  /pt     302  Language=pt
+			/a  /pt-br  302  Language=pt-br
+			/b  /pt-br  302  Language=pt-br
+			/b  /pt     302  Language=pt
+			/*  /all    302  Language=pt
+			/c  /pt-br  302  Language=pt-BR
+		`))
+
+		assert.Equal(t, []redirects.Shadow{
+			{Rule: 4, Shadowed: 5},
+		}, redirects.Shadows(rules))
+	})
+
+	t.Run("negated countries", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/  /global  302  Country=!cn
+			/  /us      302  Country=us
+			/  /ru      302  Country=!cn,!ru
+			/  /cn      302  Country=cn
+			/  /any     302  Country=!ru
+		`))
+
+		assert.Equal(t, []redirects.Shadow{
+			{Rule: 0, Shadowed: 1},
+			{Rule: 0, Shadowed: 2},
+		}, redirects.Shadows(rules))
+
+		rules = []redirects.Rule{
+			{From: "/", To: "/global", Status: 302, Country: []string{"!cn", ""}},
+			{From: "/", To: "/us", Status: 302, Country: []string{"us"}},
+		}
+
+		assert.Equal(t, []redirects.Shadow{
+			{Rule: 0, Shadowed: 1},
+		}, redirects.Shadows(rules))
+	})
+
+	t.Run("locale fallback", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/  /home
+			/  /anz  302  Country=au,nz
+			/  /en   302  Language=en
+			/  /au   302  Country=au
+		`))
+
+		assert.Equal(t, []redirects.Shadow{
+			{Rule: 1, Shadowed: 3},
+		}, redirects.Shadows(rules))
+	})
+
+	t.Run("params", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/store         /shop
+			/store id=:id  /item/:id
+		`))
+
+		assert.Equal(t, []redirects.Shadow{
+			{Rule: 0, Shadowed: 1},
+		}, redirects.Shadows(rules))
+	})
+}
+
+func TestSuggestOrder(t *testing.T) {
+	t.Run("shadowed", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/about     /team
+			/blog/*    /posts/:splat
+			/contact   /team/contact
+			/blog/news /news
+		`))
+
+		order, shadows := redirects.SuggestOrder(rules)
+		assert.Equal(t, []string{"/about", "/blog/news", "/blog/*", "/contact"}, froms(order))
+		assert.Len(t, shadows, 1)
+	})
+
+	t.Run("nothing shadowed", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/blog/news /news
+			/blog/*    /posts/:splat
+		`))
+
+		order, shadows := redirects.SuggestOrder(rules)
+		assert.Equal(t, rules, order)
+		assert.Empty(t, shadows)
+	})
+}
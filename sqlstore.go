@@ -0,0 +1,158 @@
+package redirects
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// SQLStore is a RuleStore keeping rules in a database/sql table with an
+// integer "position" column and a text "rule" column holding each rule as
+// JSON, such as:
+//
+//	CREATE TABLE redirects (position INTEGER PRIMARY KEY, rule TEXT NOT NULL)
+type SQLStore struct {
+	// DB is the database.
+	DB *sql.DB
+
+	// Table is the table of rules. Defaults to "redirects".
+	Table string
+
+	// Numbered uses numbered placeholders such as $1, as PostgreSQL
+	// requires, rather than "?".
+	Numbered bool
+
+	// Interval is how often Watch polls the table for changes, retrying
+	// failed polls at the next interval. Defaults to 10 seconds.
+	Interval time.Duration
+}
+
+// Load implementation.
+func (s *SQLStore) Load(ctx context.Context) ([]Rule, error) {
+	rows, err := s.DB.QueryContext(ctx, fmt.Sprintf("SELECT rule FROM %s ORDER BY position", s.table()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []json.RawMessage
+
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+
+		if !json.Valid(b) {
+			return nil, fmt.Errorf("rule %d: invalid JSON", len(list))
+		}
+
+		list = append(list, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// decoded as the JSON array of the other stores
+	b, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRules(b)
+}
+
+// Save implementation.
+func (s *SQLStore) Save(ctx context.Context, rules []Rule) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", s.table())); err != nil {
+		return err
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (position, rule) VALUES (%s, %s)", s.table(), s.placeholder(1), s.placeholder(2))
+
+	for i, r := range rules {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, insert, i, string(b)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Watch implementation.
+func (s *SQLStore) Watch(ctx context.Context) (<-chan []Rule, error) {
+	rules, err := s.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := s.Interval
+	if interval == 0 {
+		interval = 10 * time.Second
+	}
+
+	ch := make(chan []Rule)
+
+	go func() {
+		defer close(ch)
+
+		hash := rulesHash(rules)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+
+			rules, err := s.Load(ctx)
+			if err != nil || rulesHash(rules) == hash {
+				continue
+			}
+			hash = rulesHash(rules)
+
+			select {
+			case ch <- rules:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// table returns the table name.
+func (s *SQLStore) table() string {
+	if s.Table == "" {
+		return "redirects"
+	}
+
+	return s.Table
+}
+
+// placeholder returns the query placeholder of argument n.
+func (s *SQLStore) placeholder(n int) string {
+	if s.Numbered {
+		return "$" + strconv.Itoa(n)
+	}
+
+	return "?"
+}
@@ -0,0 +1,163 @@
+package redirects_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+// table is an in-memory table of rules served by a fake database driver.
+type table struct {
+	mu      sync.Mutex
+	rows    map[int64]string
+	queries []string
+}
+
+func (t *table) Connect(context.Context) (driver.Conn, error) { return &conn{t}, nil }
+func (t *table) Driver() driver.Driver                        { return nil }
+
+type conn struct{ t *table }
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) { return &stmt{c.t, query}, nil }
+func (c *conn) Close() error                              { return nil }
+func (c *conn) Begin() (driver.Tx, error)                 { return c, nil }
+func (c *conn) Commit() error                             { return nil }
+func (c *conn) Rollback() error                           { return nil }
+
+type stmt struct {
+	t     *table
+	query string
+}
+
+func (s *stmt) Close() error  { return nil }
+func (s *stmt) NumInput() int { return -1 }
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+
+	s.t.queries = append(s.t.queries, s.query)
+	switch {
+	case strings.HasPrefix(s.query, "DELETE"):
+		s.t.rows = map[int64]string{}
+	case strings.HasPrefix(s.query, "INSERT"):
+		s.t.rows[args[0].(int64)] = args[1].(string)
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+
+	s.t.queries = append(s.t.queries, s.query)
+
+	var positions []int64
+	for p := range s.t.rows {
+		positions = append(positions, p)
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+
+	r := &rows{}
+	for _, p := range positions {
+		r.values = append(r.values, s.t.rows[p])
+	}
+
+	return r, nil
+}
+
+type rows struct{ values []string }
+
+func (r *rows) Columns() []string { return []string{"rule"} }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+func TestSQLStore(t *testing.T) {
+	tbl := &table{rows: map[int64]string{}}
+	store := &redirects.SQLStore{
+		DB:       sql.OpenDB(tbl),
+		Table:    "rules",
+		Numbered: true,
+		Interval: time.Millisecond,
+	}
+
+	ctx := context.Background()
+
+	t.Run("save and load", func(t *testing.T) {
+		rules := redirects.Must(redirects.ParseString(`
+			/home  /  301
+			/news  /blog  302
+		`))
+
+		assert.NoError(t, store.Save(ctx, rules))
+		assert.Contains(t, tbl.queries, "INSERT INTO rules (position, rule) VALUES ($1, $2)")
+
+		loaded, err := store.Load(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, rules, loaded)
+		assert.Contains(t, tbl.queries, "SELECT rule FROM rules ORDER BY position")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		tbl.mu.Lock()
+		tbl.rows[5] = `{"From":"home","To":"/"}`
+		tbl.mu.Unlock()
+
+		_, err := store.Load(ctx)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "rule 2")
+
+		tbl.mu.Lock()
+		tbl.rows[5] = `{"From":`
+		tbl.mu.Unlock()
+
+		_, err = store.Load(ctx)
+		assert.EqualError(t, err, "rule 2: invalid JSON")
+
+		tbl.mu.Lock()
+		delete(tbl.rows, 5)
+		tbl.mu.Unlock()
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		compiled := redirects.Compile(nil)
+		done := make(chan error)
+		go func() { done <- redirects.Sync(ctx, store, compiled) }()
+
+		assert.Eventually(t, func() bool { return len(compiled.Rules()) == 2 }, time.Second, time.Millisecond)
+
+		assert.NoError(t, store.Save(ctx, redirects.Must(redirects.ParseString(`/docs  /guide`))))
+		assert.Eventually(t, func() bool {
+			rules := compiled.Rules()
+			return len(rules) == 1 && rules[0].From == "/docs"
+		}, time.Second, time.Millisecond)
+
+		assert.NoError(t, store.Save(ctx, redirects.Must(redirects.ParseString("#@ owner=docs-team\n/docs  /guide"))))
+		assert.Eventually(t, func() bool {
+			return compiled.Rules()[0].Meta["owner"] == "docs-team"
+		}, time.Second, time.Millisecond)
+
+		cancel()
+		assert.Equal(t, context.Canceled, <-done)
+	})
+}
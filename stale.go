@@ -0,0 +1,82 @@
+package redirects
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// A StaleRule reports a rule which may be removed, as it has expired or
+// no request has matched it.
+type StaleRule struct {
+	// Index is the position of the rule in the audited slice.
+	Index int
+
+	// Rule is the flagged rule.
+	Rule Rule
+
+	// Reason describes the problem.
+	Reason string
+}
+
+// String returns a description of the finding.
+func (s StaleRule) String() string {
+	return fmt.Sprintf("rule %d (%s): %s", s.Index, s.Rule.From, s.Reason)
+}
+
+// AuditStale returns the rules whose "expires" annotation, such as
+// "#@ expires=2025-01-01", is not after now, along with rules which have no
+// hits when hits is not nil. Hits are keyed by the rule in _redirects format
+// as returned by Rule.String, so a rule missing from hits, such as one added
+// since the hits were recorded, is not reported.
+func AuditStale(rules []Rule, now time.Time, hits map[string]uint64) (found []StaleRule) {
+	for i, r := range rules {
+		if reason := auditStale(r, now, hits); reason != "" {
+			found = append(found, StaleRule{
+				Index:  i,
+				Rule:   r,
+				Reason: reason,
+			})
+		}
+	}
+
+	return
+}
+
+// auditStale returns the reason r is stale, or an empty string.
+func auditStale(r Rule, now time.Time, hits map[string]uint64) string {
+	if s, ok := r.Meta["expires"]; ok {
+		t, err := parseTime(s)
+		if err != nil {
+			return fmt.Sprintf("invalid expires annotation %q", s)
+		}
+
+		if !now.Before(t) {
+			return fmt.Sprintf("expired %s", s)
+		}
+	}
+
+	if n, ok := hits[r.String()]; ok && n == 0 {
+		return "no hits"
+	}
+
+	return ""
+}
+
+// ReadHits returns the hit counts of rules, keyed by the rule in _redirects
+// format, from the JSON output of a DebugHandler. Counts of identical rules
+// are summed.
+func ReadHits(r io.Reader) (map[string]uint64, error) {
+	var info debugInfo
+	if err := json.NewDecoder(r).Decode(&info); err != nil {
+		return nil, err
+	}
+
+	hits := make(map[string]uint64, len(info.Rules))
+	for _, r := range info.Rules {
+		hits[r.Rule] += r.Hits
+	}
+
+	return hits, nil
+}
@@ -0,0 +1,76 @@
+package redirects_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestAuditStale(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		#@ owner=seo-team expires=2025-01-01
+		/old-pricing  /pricing
+
+		#@ expires=2030-01-01
+		/summer  /sale  302
+
+		#@ expires=soon
+		/spring  /sale  302
+
+		/home  /
+		/news  /blog
+	`))
+
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("expiry", func(t *testing.T) {
+		found := redirects.AuditStale(rules, now, nil)
+		assert.Len(t, found, 2)
+		assert.Equal(t, "rule 0 (/old-pricing): expired 2025-01-01", found[0].String())
+		assert.Equal(t, `rule 2 (/spring): invalid expires annotation "soon"`, found[1].String())
+	})
+
+	t.Run("hits", func(t *testing.T) {
+		hits := map[string]uint64{
+			"/summer /sale 302": 0,
+			"/home / 301":       12,
+			"/news /blog 301":   0,
+		}
+
+		var reasons []string
+		for _, s := range redirects.AuditStale(rules, now, hits) {
+			reasons = append(reasons, s.String())
+		}
+
+		assert.Equal(t, []string{
+			"rule 0 (/old-pricing): expired 2025-01-01",
+			"rule 1 (/summer): no hits",
+			`rule 2 (/spring): invalid expires annotation "soon"`,
+			"rule 4 (/news): no hits",
+		}, reasons)
+	})
+}
+
+func TestReadHits(t *testing.T) {
+	c := redirects.Compile(redirects.Must(redirects.ParseString(`
+		/home  /
+		/news  /blog
+		/home  /
+	`)))
+	c.Match(redirects.Request{Path: "/home"})
+	c.Match(redirects.Request{Path: "/home"})
+
+	w := httptest.NewRecorder()
+	(&redirects.DebugHandler{Rules: c}).ServeHTTP(w, httptest.NewRequest("GET", "/?format=json", nil))
+
+	hits, err := redirects.ReadHits(w.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"/home / 301": 2, "/news /blog 301": 0}, hits)
+
+	_, err = redirects.ReadHits(strings.NewReader("nope"))
+	assert.Error(t, err)
+}
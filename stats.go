@@ -0,0 +1,173 @@
+package redirects
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// RuleStats summarizes a set of rules.
+type RuleStats struct {
+	// Rules is the number of rules.
+	Rules int
+
+	// Status is the number of rules by status code.
+	Status map[int]int
+
+	// Redirects is the number of 3xx rules.
+	Redirects int
+
+	// Rewrites is the number of 200 rules serving local content.
+	Rewrites int
+
+	// Proxies is the number of 200 rules with an absolute destination.
+	Proxies int
+
+	// Exclusions is the number of exclusion rules, which have no status.
+	Exclusions int
+
+	// Forced is the number of rules with the force flag.
+	Forced int
+
+	// Wildcards is the number of rules whose source ends with a splat.
+	Wildcards int
+
+	// Placeholders is the number of rules with a path placeholder.
+	Placeholders int
+
+	// Params is the number of rules matching query params.
+	Params int
+
+	// Conditions is the number of rules using each condition, such as
+	// "Country", "From" or "Header:X-Canary".
+	Conditions map[string]int
+}
+
+// Stats returns the statistics of rules.
+func Stats(rules []Rule) RuleStats {
+	s := RuleStats{
+		Rules:      len(rules),
+		Status:     make(map[int]int),
+		Conditions: make(map[string]int),
+	}
+
+	for _, r := range rules {
+		if !isExclusion(r.From) {
+			s.Status[r.Status]++
+		}
+
+		switch {
+		case isExclusion(r.From):
+			s.Exclusions++
+		case r.IsRewrite() && r.IsProxy():
+			s.Proxies++
+		case r.IsRewrite():
+			s.Rewrites++
+		case r.Status >= 300 && r.Status < 400:
+			s.Redirects++
+		}
+
+		if r.Force {
+			s.Forced++
+		}
+
+		if strings.HasSuffix(r.From, "*") {
+			s.Wildcards++
+		}
+
+		for _, seg := range segments(r.From) {
+			if segmentKind(seg) == placeholderSegment {
+				s.Placeholders++
+				break
+			}
+		}
+
+		if len(r.Params) > 0 {
+			s.Params++
+		}
+
+		for _, c := range ruleConditions(r) {
+			s.Conditions[c]++
+		}
+	}
+
+	return s
+}
+
+// WriteTo writes a plain text report of the statistics.
+func (s RuleStats) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "rules         %d\n", s.Rules)
+	fmt.Fprintf(&b, "redirects     %d\n", s.Redirects)
+	fmt.Fprintf(&b, "rewrites      %d\n", s.Rewrites)
+	fmt.Fprintf(&b, "proxies       %d\n", s.Proxies)
+	fmt.Fprintf(&b, "exclusions    %d\n", s.Exclusions)
+	fmt.Fprintf(&b, "forced        %d\n", s.Forced)
+	fmt.Fprintf(&b, "wildcards     %d\n", s.Wildcards)
+	fmt.Fprintf(&b, "placeholders  %d\n", s.Placeholders)
+	fmt.Fprintf(&b, "params        %d\n", s.Params)
+
+	codes := make([]int, 0, len(s.Status))
+	for code := range s.Status {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	if len(codes) > 0 {
+		fmt.Fprintf(&b, "\nstatus\n")
+	}
+	for _, code := range codes {
+		fmt.Fprintf(&b, "  %-20d%d\n", code, s.Status[code])
+	}
+
+	names := make([]string, 0, len(s.Conditions))
+	for name := range s.Conditions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) > 0 {
+		fmt.Fprintf(&b, "\nconditions\n")
+	}
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %-20s%d\n", name, s.Conditions[name])
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ruleConditions returns the names of the conditions used by r.
+func ruleConditions(r Rule) (names []string) {
+	if len(r.Country) > 0 {
+		names = append(names, "Country")
+	}
+
+	if len(r.Language) > 0 {
+		names = append(names, "Language")
+	}
+
+	if len(r.Method) > 0 {
+		names = append(names, "Method")
+	}
+
+	if len(r.Host) > 0 {
+		names = append(names, "Host")
+	}
+
+	for k := range r.Conditions {
+		names = append(names, k)
+	}
+
+	if !r.ActiveFrom.IsZero() {
+		names = append(names, "From")
+	}
+
+	if !r.ActiveUntil.IsZero() {
+		names = append(names, "Until")
+	}
+
+	return
+}
@@ -0,0 +1,63 @@
+package redirects_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestStats(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/home           /
+		/blog/:year/*   /posts/:year/:splat  302
+		/api/*          https://api.example.com/:splat  200!
+		/*              /index.html  200
+		/store id=:id   /products/:id  301  Country=us,ca
+		/beta           /new  302  Header:X-Beta=1  Until=2030-01-01T00:00Z
+		/gone           /404.html  404
+	`))
+
+	s := redirects.Stats(rules)
+
+	assert.Equal(t, 7, s.Rules)
+	assert.Equal(t, map[int]int{200: 2, 301: 2, 302: 2, 404: 1}, s.Status)
+	assert.Equal(t, 4, s.Redirects)
+	assert.Equal(t, 1, s.Rewrites)
+	assert.Equal(t, 1, s.Proxies)
+	assert.Equal(t, 1, s.Forced)
+	assert.Equal(t, 3, s.Wildcards)
+	assert.Equal(t, 1, s.Placeholders)
+	assert.Equal(t, 1, s.Params)
+	assert.Equal(t, map[string]int{"Country": 1, "Header:X-Beta": 1, "Until": 1}, s.Conditions)
+}
+
+func TestRuleStats_WriteTo(t *testing.T) {
+	rules := redirects.Must(redirects.ParseString(`
+		/home  /
+		/app/*  /index.html  200  Country=us
+	`))
+
+	var b strings.Builder
+	_, err := redirects.Stats(rules).WriteTo(&b)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `rules         2
+redirects     1
+rewrites      1
+proxies       0
+exclusions    0
+forced        0
+wildcards     1
+placeholders  0
+params        0
+
+status
+  200                 1
+  301                 1
+
+conditions
+  Country             1
+`, b.String())
+}
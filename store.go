@@ -0,0 +1,68 @@
+package redirects
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// A RuleStore persists rules outside of _redirects files, such as in a
+// database, so they can be managed at runtime.
+type RuleStore interface {
+	// Load returns the stored rules.
+	Load(ctx context.Context) ([]Rule, error)
+
+	// Save replaces the stored rules.
+	Save(ctx context.Context, rules []Rule) error
+
+	// Watch returns a channel receiving the rules each time they change,
+	// which is closed when ctx is done.
+	Watch(ctx context.Context) (<-chan []Rule, error)
+}
+
+// Sync loads the rules of store into c, then reloads c each time they
+// change until ctx is done.
+func Sync(ctx context.Context, store RuleStore, c *CompiledRules) error {
+	rules, err := store.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("loading: %w", err)
+	}
+
+	c.Reload(rules)
+
+	updates, err := store.Watch(ctx)
+	if err != nil {
+		return fmt.Errorf("watching: %w", err)
+	}
+
+	for rules := range updates {
+		c.Reload(rules)
+	}
+
+	return ctx.Err()
+}
+
+// decodeRules returns the validated rules of a JSON array, with missing
+// statuses defaulting to 301.
+func decodeRules(b []byte) ([]Rule, error) {
+	var rules []Rule
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, err
+	}
+
+	for i := range rules {
+		defaultStatus(&rules[i])
+		if errs := validateRule(rules[i], nil); len(errs) > 0 {
+			return nil, fmt.Errorf("rule %d: %w", i, errs[0])
+		}
+	}
+
+	return rules, nil
+}
+
+// defaultStatus sets the status of a decoded rule without one to 301.
+func defaultStatus(r *Rule) {
+	if r.Status == 0 && !isExclusion(r.From) {
+		r.Status = 301
+	}
+}
@@ -0,0 +1,84 @@
+package redirects
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// A Template renders a response body, such as a *html/template.Template or
+// a *text/template.Template.
+type Template interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// TemplateData is the data response body templates are executed with.
+type TemplateData struct {
+	// Path is the requested path.
+	Path string
+
+	// URL is the destination of redirects, made absolute as in the
+	// Location header, and empty for error pages.
+	URL string
+
+	// Status is the response status, such as 301.
+	Status int
+
+	// StatusText is the text of the status, such as "Moved Permanently".
+	StatusText string
+
+	// Rule is the matched rule, whose Meta holds its annotations.
+	Rule *Rule
+}
+
+// redirect redirects to the destination of res with the given status,
+// with a body rendered by RedirectTemplate for GET requests when set.
+func (h *Handler) redirect(w http.ResponseWriter, r *http.Request, res Result, status int) {
+	if h.RedirectTemplate == nil || r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Redirect(w, r, res.To, status)
+		return
+	}
+
+	// a Content-Type stops http.Redirect writing its own body
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	http.Redirect(w, r, res.To, status)
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	var b bytes.Buffer
+	err := h.RedirectTemplate.Execute(&b, TemplateData{
+		Path:       r.URL.Path,
+		URL:        w.Header().Get("Location"),
+		Status:     status,
+		StatusText: http.StatusText(status),
+		Rule:       res.Rule,
+	})
+
+	if err == nil {
+		w.Write(b.Bytes())
+	}
+}
+
+// errorPage returns the body rendered by ErrorTemplate for the content rule
+// of res served with an error status, or nil.
+func (h *Handler) errorPage(r *http.Request, res Result, status int) []byte {
+	if h.ErrorTemplate == nil || status < 400 {
+		return nil
+	}
+
+	var b bytes.Buffer
+	err := h.ErrorTemplate.Execute(&b, TemplateData{
+		Path:       r.URL.Path,
+		Status:     status,
+		StatusText: http.StatusText(status),
+		Rule:       res.Rule,
+	})
+
+	if err != nil {
+		return nil
+	}
+
+	return b.Bytes()
+}
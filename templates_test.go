@@ -0,0 +1,98 @@
+package redirects_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/tj/assert"
+
+	"github.com/fission-suite/go-redirects"
+)
+
+func TestHandler_RedirectTemplate(t *testing.T) {
+	h := &redirects.Handler{
+		Rules: redirects.Must(redirects.ParseString(`
+			#@ owner=seo
+			/old/*  /new/:splat
+		`)),
+		RedirectTemplate: template.Must(template.New("redirect").Parse(`<p>{{.StatusText}}: <a href="{{.URL}}">{{.URL}}</a> ({{index .Rule.Meta "owner"}})</p>`)),
+	}
+
+	t.Run("get", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/old/a?b=1&c=2", nil))
+		assert.Equal(t, 301, w.Code)
+		assert.Equal(t, "/new/a?b=1&c=2", w.Header().Get("Location"))
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, `<p>Moved Permanently: <a href="/new/a?b=1&amp;c=2">/new/a?b=1&amp;c=2</a> (seo)</p>`, w.Body.String())
+	})
+
+	t.Run("head", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("HEAD", "/old/a", nil))
+		assert.Equal(t, 301, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("post", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", "/old/a", nil))
+		assert.Equal(t, 301, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Type"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("default", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		(&redirects.Handler{Rules: h.Rules}).ServeHTTP(w, httptest.NewRequest("GET", "/old/a", nil))
+		assert.Equal(t, "<a href=\"/new/a\">Moved Permanently</a>.\n\n", w.Body.String())
+	})
+}
+
+func TestHandler_ErrorTemplate(t *testing.T) {
+	h := &redirects.Handler{
+		Rules: redirects.Must(redirects.ParseString(`
+			/blog/*   /404.html       404
+			/gone/*   /gone.html      410
+			#@ reason=court-order
+			/legal/*  /451.html       451
+			/app/*    /missing.html   200
+		`)),
+		Next: http.FileServer(http.FS(fstest.MapFS{
+			"404.html": {Data: []byte("not found page")},
+		})),
+		ErrorTemplate: template.Must(template.New("error").Parse(`{{.Status}} {{.StatusText}}: {{.Path}}{{with .Rule.Meta.reason}} ({{.}}){{end}}`)),
+	}
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	t.Run("destination exists", func(t *testing.T) {
+		w := serve("/blog/a")
+		assert.Equal(t, 404, w.Code)
+		assert.Equal(t, "not found page", w.Body.String())
+	})
+
+	t.Run("destination missing", func(t *testing.T) {
+		w := serve("/gone/a")
+		assert.Equal(t, 410, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "410 Gone: /gone/a", w.Body.String())
+
+		w = serve("/legal/a")
+		assert.Equal(t, 451, w.Code)
+		assert.Equal(t, "451 Unavailable For Legal Reasons: /legal/a (court-order)", w.Body.String())
+	})
+
+	t.Run("rewrite", func(t *testing.T) {
+		w := serve("/app/a")
+		assert.Equal(t, 404, w.Code)
+		assert.NotContains(t, w.Body.String(), "/app/a")
+	})
+}
@@ -0,0 +1,259 @@
+// Package tjredirects is a compatibility layer providing the API of
+// github.com/tj/go-redirects on top of github.com/fission-suite/go-redirects,
+// so code may switch imports with an alias and migrate gradually:
+//
+//	import redirects "github.com/fission-suite/go-redirects/tjredirects"
+//
+// Rules convert to and from this package's rules with ToRule and FromRule.
+package tjredirects
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fission-suite/go-redirects"
+)
+
+// Params is a map of key/value pairs.
+type Params = redirects.Params
+
+// A Rule represents a single redirection or rewrite rule, with the fields
+// of github.com/tj/go-redirects.
+type Rule struct {
+	// From is the path which is matched to perform the rule.
+	From string
+
+	// To is the destination which may be relative, or absolute
+	// in order to proxy the request to another URL.
+	To string
+
+	// Status is one of the following:
+	//
+	// - 3xx a redirect
+	// - 200 a rewrite
+	// - defaults to 301 redirect
+	//
+	// When proxying this field is ignored.
+	//
+	Status int
+
+	// Force is used to force a rewrite or redirect even
+	// when a response (or static file) is present.
+	Force bool
+
+	// Params is an optional arbitrary map of key/value pairs.
+	Params Params
+}
+
+// IsRewrite returns true if the rule represents a rewrite (status 200).
+func (r *Rule) IsRewrite() bool {
+	rule := ToRule(*r)
+	return rule.IsRewrite()
+}
+
+// IsProxy returns true if it's a proxy rule (aka contains a hostname).
+func (r *Rule) IsProxy() bool {
+	rule := ToRule(*r)
+	return rule.IsProxy()
+}
+
+// ToRule returns r as a rule of github.com/fission-suite/go-redirects.
+func ToRule(r Rule) redirects.Rule {
+	return redirects.Rule{
+		From:   r.From,
+		To:     r.To,
+		Status: r.Status,
+		Force:  r.Force,
+		Params: r.Params,
+	}
+}
+
+// FromRule returns r as a rule of github.com/tj/go-redirects, or an error
+// wrapping redirects.ErrUnsupported when r has conditions the old rule
+// can not represent, as dropping them would broaden its matches. Metadata
+// is dropped.
+func FromRule(r redirects.Rule) (Rule, error) {
+	var conditions []string
+
+	if len(r.Country) > 0 {
+		conditions = append(conditions, "Country")
+	}
+
+	if len(r.Language) > 0 {
+		conditions = append(conditions, "Language")
+	}
+
+	if len(r.Method) > 0 {
+		conditions = append(conditions, "Method")
+	}
+
+	if len(r.Host) > 0 {
+		conditions = append(conditions, "Host")
+	}
+
+	if len(r.Conditions) > 0 {
+		conditions = append(conditions, "Header")
+	}
+
+	if !r.ActiveFrom.IsZero() || !r.ActiveUntil.IsZero() {
+		conditions = append(conditions, "From and Until")
+	}
+
+	if len(conditions) > 0 {
+		return Rule{}, fmt.Errorf("%w: %s conditions", redirects.ErrUnsupported, strings.Join(conditions, ", "))
+	}
+
+	return Rule{
+		From:   r.From,
+		To:     r.To,
+		Status: r.Status,
+		Force:  r.Force,
+		Params: r.Params,
+	}, nil
+}
+
+// Must parse utility.
+func Must(v []Rule, err error) []Rule {
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+// Parse the given reader. Errors are returned as a *redirects.ParseError,
+// including for rules with conditions, see FromRule. Params may follow the
+// status as github.com/tj/go-redirects allows, such as
+// "/  /something  302  foo=bar".
+func Parse(r io.Reader) ([]Rule, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := redirects.Parse(strings.NewReader(leadingParams(string(b))))
+
+	var perr *redirects.ParseError
+	if errors.As(err, &perr) {
+		perr.Text = originalLine(string(b), perr.Line)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	lines := ruleLines(string(b))
+	rules := make([]Rule, len(parsed))
+
+	for i, p := range parsed {
+		if rules[i], err = FromRule(p); err != nil {
+			return nil, &redirects.ParseError{Line: lines[i].n, Text: lines[i].text, Err: err}
+		}
+	}
+
+	return rules, nil
+}
+
+// ParseString parses the given string.
+func ParseString(s string) ([]Rule, error) {
+	return Parse(strings.NewReader(s))
+}
+
+// conditions are the lowercase keys of conditions, which are not params
+// when following the status.
+var conditions = map[string]bool{
+	"country":  true,
+	"language": true,
+	"method":   true,
+	"host":     true,
+	"from":     true,
+	"until":    true,
+}
+
+// leadingParams returns the rules of s with the key=value params following
+// their status moved before their destination, where this package's parser
+// expects them. Line numbers are unchanged.
+func leadingParams(s string) string {
+	lines := strings.Split(s, "\n")
+
+	for i, l := range lines {
+		fields := strings.Fields(l)
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		// the destination is the first field after the source which is not a param
+		to := 1
+		for to < len(fields) && isParam(fields[to]) {
+			to++
+		}
+
+		if to == len(fields) {
+			continue
+		}
+
+		var params, rest []string
+		for j, f := range fields[to+1:] {
+			if j == 0 && !strings.Contains(f, "=") {
+				rest = append(rest, f)
+				continue
+			}
+
+			key, _, _ := strings.Cut(f, "=")
+			if isParam(f) && !conditions[strings.ToLower(key)] && !strings.HasPrefix(strings.ToLower(key), "header:") {
+				params = append(params, f)
+			} else {
+				rest = append(rest, f)
+			}
+		}
+
+		if len(params) == 0 {
+			continue
+		}
+
+		out := append(append([]string{}, fields[:to]...), params...)
+		out = append(append(out, fields[to]), rest...)
+		lines[i] = strings.Join(out, " ")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// isParam returns true if f is a key=value param rather than a destination.
+func isParam(f string) bool {
+	return strings.Contains(f, "=") && !strings.HasPrefix(f, "/") && !strings.Contains(f, "://")
+}
+
+// originalLine returns line n of s, as written.
+func originalLine(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if n < 1 || n > len(lines) {
+		return ""
+	}
+
+	return strings.TrimSpace(lines[n-1])
+}
+
+// line is a numbered line.
+type line struct {
+	n    int
+	text string
+}
+
+// ruleLines returns the lines of s holding a rule, those which are neither
+// empty nor a comment.
+func ruleLines(s string) (lines []line) {
+	sc := bufio.NewScanner(strings.NewReader(s))
+
+	for n := 1; sc.Scan(); n++ {
+		text := strings.TrimSpace(sc.Text())
+		if text != "" && !strings.HasPrefix(text, "#") {
+			lines = append(lines, line{n: n, text: text})
+		}
+	}
+
+	return
+}
@@ -0,0 +1,86 @@
+package tjredirects_test
+
+import (
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/pkg/errors"
+	"github.com/tj/assert"
+
+	"github.com/fission-suite/go-redirects/tjredirects"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		rules := tjredirects.Must(tjredirects.ParseString(`
+			# comment
+			/blog/*  /posts/:splat
+			/search  q=:q  /find/:q  302!
+			/api/*   https://api.example.com/:splat  200
+		`))
+
+		assert.Equal(t, []tjredirects.Rule{
+			{From: "/blog/*", To: "/posts/:splat", Status: 301},
+			{From: "/search", To: "/find/:q", Status: 302, Force: true, Params: tjredirects.Params{"q": ":q"}},
+			{From: "/api/*", To: "https://api.example.com/:splat", Status: 200},
+		}, rules)
+
+		assert.True(t, rules[1].Params.Has("q"))
+		assert.True(t, rules[2].IsRewrite())
+		assert.True(t, rules[2].IsProxy())
+		assert.False(t, rules[0].IsProxy())
+	})
+
+	t.Run("trailing params", func(t *testing.T) {
+		rules := tjredirects.Must(tjredirects.ParseString(`
+			/	/something	302	foo=bar
+			/	/something	302	foo=bar bar=baz
+			/a  q=:q  /b/:q  301!  page=%2F1
+		`))
+
+		assert.Equal(t, []tjredirects.Rule{
+			{From: "/", To: "/something", Status: 302, Params: tjredirects.Params{"foo": "bar"}},
+			{From: "/", To: "/something", Status: 302, Params: tjredirects.Params{"foo": "bar", "bar": "baz"}},
+			{From: "/a", To: "/b/:q", Status: 301, Force: true, Params: tjredirects.Params{"q": ":q", "page": "/1"}},
+		}, rules)
+
+		_, err := tjredirects.ParseString("/a  /b  302  foo=bar  Country=au\n")
+		assert.EqualError(t, err, `line 1: unsupported: Country conditions: "/a  /b  302  foo=bar  Country=au"`)
+
+		_, err = tjredirects.ParseString("/a  a=b  c=d\n")
+		assert.True(t, errors.Is(err, redirects.ErrMissingDestination))
+
+		_, err = tjredirects.ParseString("/a  /b  302  foo=bar  999\n")
+		assert.Contains(t, err.Error(), `"/a  /b  302  foo=bar  999"`)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := tjredirects.ParseString("/home  /\n/news\n")
+		assert.True(t, errors.Is(err, redirects.ErrMissingDestination))
+	})
+
+	t.Run("conditions", func(t *testing.T) {
+		_, err := tjredirects.ParseString("/home  /\n\n/sale  /au/sale  302  Country=au  Language=en\n")
+		assert.EqualError(t, err, `line 3: unsupported: Country, Language conditions: "/sale  /au/sale  302  Country=au  Language=en"`)
+		assert.True(t, errors.Is(err, redirects.ErrUnsupported))
+	})
+}
+
+func TestToRule(t *testing.T) {
+	r := tjredirects.Rule{From: "/a", To: "/b", Status: 302, Force: true, Params: tjredirects.Params{"q": "x"}}
+	rule := tjredirects.ToRule(r)
+	assert.Equal(t, redirects.Rule{From: "/a", To: "/b", Status: 302, Force: true, Params: redirects.Params{"q": "x"}}, rule)
+
+	back, err := tjredirects.FromRule(rule)
+	assert.NoError(t, err)
+	assert.Equal(t, r, back)
+}
+
+func TestFromRule(t *testing.T) {
+	_, err := tjredirects.FromRule(redirects.Rule{From: "/a", To: "/b", Status: 301, Method: []string{"POST"}})
+	assert.EqualError(t, err, "unsupported: Method conditions")
+
+	r, err := tjredirects.FromRule(redirects.Rule{From: "/a", To: "/b", Status: 301, Meta: map[string]string{"owner": "seo"}})
+	assert.NoError(t, err)
+	assert.Equal(t, tjredirects.Rule{From: "/a", To: "/b", Status: 301}, r)
+}
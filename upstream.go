@@ -0,0 +1,149 @@
+package redirects
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// errCircuitOpen is returned for requests to an upstream whose circuit
+// breaker is open.
+var errCircuitOpen = errors.New("circuit open")
+
+// errUpstreamStatus is returned for requests failing with an error status
+// when a fallback is served instead.
+var errUpstreamStatus = errors.New("upstream error status")
+
+// An UpstreamPolicy configures how requests to a proxy upstream are retried
+// and when the upstream is considered down.
+type UpstreamPolicy struct {
+	// Retries is the number of times a failed request without a body is
+	// retried. Requests fail with a network error or a 502, 503 or 504.
+	Retries int
+
+	// Backoff is the delay before the first retry, doubling for each
+	// further retry.
+	Backoff time.Duration
+
+	// Threshold is the number of consecutive failed requests after which
+	// the circuit opens and requests fail immediately. Zero disables the
+	// circuit breaker.
+	Threshold int
+
+	// Cooldown is how long the circuit stays open before a request is
+	// tried again, closing it when that request succeeds.
+	Cooldown time.Duration
+
+	// Fallback is the local path served from Next with a 502 status when a
+	// request fails or the circuit is open, such as "/502.html". Defaults to
+	// the upstream's response, or an empty 502 for network errors.
+	Fallback string
+
+	// Limits caps the requests sent to the upstream. Proxy rules may also
+	// be limited with "max-concurrent", "rate" and "burst" annotations,
+	// applying to the requests of that rule in addition.
+	Limits Limits
+}
+
+// upstream is the circuit breaker state of a proxy upstream.
+type upstream struct {
+	policy UpstreamPolicy
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+}
+
+// allow returns true if a request may be sent at now.
+func (u *upstream) allow(now time.Time) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.policy.Threshold == 0 || u.failures < u.policy.Threshold {
+		return true
+	}
+
+	if now.Sub(u.openedAt) < u.policy.Cooldown {
+		return false
+	}
+
+	// half-open, a failure reopens the circuit for another cooldown
+	u.openedAt = now
+	return true
+}
+
+// record records the outcome of a request at now.
+func (u *upstream) record(failed bool, now time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if !failed {
+		u.failures = 0
+		return
+	}
+
+	u.failures++
+	if u.failures == u.policy.Threshold {
+		u.openedAt = now
+	}
+}
+
+// upstreamTransport sends proxied requests according to the policy of
+// their upstream.
+type upstreamTransport struct {
+	upstream *upstream
+	clock    Clock
+	next     http.RoundTripper
+}
+
+// RoundTrip implementation.
+func (t *upstreamTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	u := t.upstream
+
+	if !u.allow(t.clock.Now()) {
+		return nil, errCircuitOpen
+	}
+
+	attempts := 1
+	if r.Body == nil || r.Body == http.NoBody {
+		attempts += u.policy.Retries
+	}
+
+	backoff := u.policy.Backoff
+
+	for i := 1; ; i++ {
+		res, err := t.next.RoundTrip(r)
+		failed := err != nil || failureStatus(res.StatusCode)
+
+		if !failed || i == attempts {
+			u.record(failed, t.clock.Now())
+
+			if failed && err == nil && u.policy.Fallback != "" {
+				res.Body.Close()
+				return nil, errUpstreamStatus
+			}
+
+			return res, err
+		}
+
+		if res != nil {
+			res.Body.Close()
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-r.Context().Done():
+			u.record(true, t.clock.Now())
+			return nil, r.Context().Err()
+		}
+
+		backoff *= 2
+	}
+}
+
+// failureStatus returns true if an upstream responding with code is down.
+func failureStatus(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
@@ -0,0 +1,100 @@
+package redirects_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/tj/assert"
+)
+
+func TestHandler_Upstreams(t *testing.T) {
+	var hits, failures int32
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		if n <= atomic.LoadInt32(&failures) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "upstream")
+	}))
+	defer upstream.Close()
+
+	u, _ := url.Parse(upstream.URL)
+	rules := redirects.Must(redirects.ParseString(fmt.Sprintf(`/api/*  %s/:splat  200`, upstream.URL)))
+
+	reset := func(n int32) {
+		atomic.StoreInt32(&hits, 0)
+		atomic.StoreInt32(&failures, n)
+	}
+
+	t.Run("retries", func(t *testing.T) {
+		reset(2)
+		h := &redirects.Handler{
+			Rules:     rules,
+			Upstreams: map[string]redirects.UpstreamPolicy{u.Host: {Retries: 2, Backoff: time.Millisecond}},
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/api/users", nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "upstream", w.Body.String())
+		assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+	})
+
+	t.Run("requests with a body are not retried", func(t *testing.T) {
+		reset(1)
+		h := &redirects.Handler{
+			Rules:     rules,
+			Upstreams: map[string]redirects.UpstreamPolicy{u.Host: {Retries: 2}},
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", "/api/users", strings.NewReader("data")))
+		assert.Equal(t, 503, w.Code)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+	})
+
+	t.Run("circuit breaker", func(t *testing.T) {
+		reset(100)
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		h := &redirects.Handler{
+			Rules: rules,
+			Next:  files,
+			Clock: clock(now),
+			Upstreams: map[string]redirects.UpstreamPolicy{u.Host: {
+				Threshold: 2,
+				Cooldown:  time.Minute,
+				Fallback:  "/502.html",
+			}},
+		}
+
+		serve := func() *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/api/users", nil))
+			return w
+		}
+
+		for i := 0; i < 3; i++ {
+			w := serve()
+			assert.Equal(t, 502, w.Code)
+			assert.Equal(t, "file /502.html", w.Body.String())
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&hits), "open circuit skips the upstream")
+
+		h.Clock = clock(now.Add(2 * time.Minute))
+		atomic.StoreInt32(&failures, 0)
+
+		w := serve()
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "upstream", w.Body.String())
+		assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+	})
+}
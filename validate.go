@@ -0,0 +1,334 @@
+package redirects
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// placeholder matches :name placeholders within a path or URL.
+var placeholder = regexp.MustCompile(`:[A-Za-z_][A-Za-z0-9_]*`)
+
+// country matches ISO 3166-1 alpha-2 codes.
+var country = regexp.MustCompile(`^[A-Za-z]{2}$`)
+
+// language matches ISO 639-1 codes with an optional region subtag.
+var language = regexp.MustCompile(`^[A-Za-z]{2}(-[A-Za-z0-9]{2,8})*$`)
+
+// method matches HTTP method tokens.
+var method = regexp.MustCompile(`^[A-Za-z]+$`)
+
+// host matches host names with an optional leading wildcard.
+var host = regexp.MustCompile(`^(\*\.)?[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)*$`)
+
+// defaultSchemes are the schemes of absolute destination URLs accepted
+// unless WithSchemes configures others.
+var defaultSchemes = []string{"http", "https", "ws", "wss"}
+
+// unsafeSchemes are schemes running or embedding content in the browser,
+// rejected even when configured.
+var unsafeSchemes = map[string]bool{
+	"javascript": true,
+	"vbscript":   true,
+	"data":       true,
+}
+
+// proxySchemes are the schemes of destinations which may be proxied.
+var proxySchemes = map[string]bool{
+	"http":  true,
+	"https": true,
+	"ws":    true,
+	"wss":   true,
+}
+
+// supportedStatus returns true if code is a status rules may respond with,
+// any 2xx to 5xx status, as Netlify documents rules such as
+// "/admin/*  /login  401!".
+func supportedStatus(code int) bool {
+	return code >= 200 && code < 600
+}
+
+// A ValidationError describes a problem with a single rule.
+type ValidationError struct {
+	// Index is the position of the rule in the validated slice.
+	Index int
+
+	// From is the path of the invalid rule.
+	From string
+
+	// Err is the underlying problem.
+	Err error
+}
+
+// Error implementation.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("rule %d (%s): %s", e.Index, e.From, e.Err)
+}
+
+// Unwrap returns the underlying problem.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Code returns the code of the underlying problem.
+func (e *ValidationError) Code() Code {
+	return ErrorCode(e.Err)
+}
+
+// Validate performs the checks the parser applies to every rule, for rules
+// which were constructed programmatically. All problems are returned, each
+// as a *ValidationError, or nil when the rules are valid.
+func Validate(rules []Rule) (errs []error) {
+	for i, r := range rules {
+		for _, err := range validateRule(r, nil) {
+			errs = append(errs, &ValidationError{
+				Index: i,
+				From:  r.From,
+				Err:   err,
+			})
+		}
+	}
+
+	return
+}
+
+// validateRule returns the problems with a single rule, whose absolute
+// destination URL may use one of schemes, or of the defaults when nil.
+func validateRule(r Rule, schemes []string) (errs []error) {
+	if from := sourcePattern(r.From); isRegexp(from) {
+		if _, err := compileRegexp(from); err != nil {
+			errs = append(errs, errorf(CodeInvalidSource, "invalid source regular expression: %s", err))
+		}
+	} else if err := validatePath(from); err != nil {
+		errs = append(errs, errorf(CodeInvalidSource, "invalid source path: %s", err))
+	} else {
+		errs = append(errs, validateConstraints(from)...)
+	}
+
+	if isExclusion(r.From) {
+		if r.To != "" {
+			errs = append(errs, errorf(CodeExclusionDestination, "exclusion rules have no destination"))
+		}
+	} else {
+		if err := validateDestination(r.To, schemes); err != nil {
+			errs = append(errs, errorf(CodeInvalidDestination, "invalid destination path: %s", err))
+		} else if u, _ := url.Parse(r.To); r.IsRewrite() && u.Host != "" && !proxySchemes[u.Scheme] {
+			errs = append(errs, errorf(CodeInvalidDestination, "%s destinations can not be proxied", u.Scheme))
+		}
+
+		if !supportedStatus(r.Status) {
+			errs = append(errs, errorf(CodeUnsupportedStatus, "unsupported status code %d", r.Status))
+		}
+	}
+
+	for _, k := range r.Params.keys() {
+		if k == "" {
+			errs = append(errs, errorf(CodeInvalidParam, "empty param name"))
+		}
+
+		// wildcards bind :splat, which a param would silently override
+		if s, ok := r.Params[k].(string); ok && splatName(s) {
+			errs = append(errs, errorf(CodeInvalidParam, "param %q can not bind %s, which is reserved for wildcards", k, s))
+		}
+	}
+
+	bound := placeholders(r)
+	for _, name := range placeholder.FindAllString(withoutScheme(r.To), -1) {
+		if !bound[name] {
+			errs = append(errs, errorf(CodeUnboundPlaceholder, "destination placeholder %s is not bound by the source path or params", name))
+		}
+	}
+
+	for _, c := range r.Country {
+		if !country.MatchString(strings.TrimPrefix(c, "!")) {
+			errs = append(errs, errorf(CodeInvalidCountry, "invalid country code %q", c))
+		} else if strings.HasPrefix(c, "!") != negated(r.Country) {
+			errs = append(errs, errorf(CodeInvalidCountry, "country %q can not be listed alongside excluded countries", c))
+		}
+	}
+
+	for _, l := range r.Language {
+		if !language.MatchString(l) {
+			errs = append(errs, errorf(CodeInvalidLanguage, "invalid language code %q", l))
+		}
+	}
+
+	if !r.ActiveFrom.IsZero() && !r.ActiveUntil.IsZero() && !r.ActiveUntil.After(r.ActiveFrom) {
+		errs = append(errs, errorf(CodeInvalidSchedule, "active until %s is not after active from %s", r.ActiveUntil.Format(time.RFC3339), r.ActiveFrom.Format(time.RFC3339)))
+	}
+
+	for _, h := range r.Host {
+		if !host.MatchString(asciiHost(h)) {
+			errs = append(errs, errorf(CodeInvalidHost, "invalid host %q", h))
+		}
+	}
+
+	for _, k := range r.Conditions.keys() {
+		if headerCondition(k) == "" {
+			errs = append(errs, errorf(CodeUnknownCondition, "unknown condition %q", k))
+		}
+	}
+
+	for _, m := range r.Method {
+		if !method.MatchString(m) {
+			errs = append(errs, errorf(CodeInvalidMethod, "invalid method %q", m))
+		}
+	}
+
+	return
+}
+
+// validatePath checks that p is an absolute path or an absolute URL.
+func validatePath(p string) error {
+	if p == "" {
+		return fmt.Errorf("empty")
+	}
+
+	if strings.HasPrefix(p, "/") {
+		return nil
+	}
+
+	u, err := url.Parse(p)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%q must start with / or be an absolute URL", p)
+	}
+
+	return nil
+}
+
+// validateDestination checks that to is an absolute path or an absolute URL
+// with one of schemes, or of the defaults when nil. URLs carrying credentials
+// are rejected, as "https://example.com@evil.example" disguises its host.
+func validateDestination(to string, schemes []string) error {
+	if to == "" || strings.HasPrefix(to, "/") {
+		return validatePath(to)
+	}
+
+	if schemes == nil {
+		schemes = defaultSchemes
+	}
+
+	u, err := url.Parse(to)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case u.Scheme == "":
+		return fmt.Errorf("%q must start with / or be an absolute URL", to)
+	case unsafeSchemes[u.Scheme] || !containsFold(schemes, u.Scheme):
+		return fmt.Errorf("scheme %q is not allowed", u.Scheme)
+	case u.User != nil:
+		return fmt.Errorf("%q must not carry credentials", to)
+	case u.Host == "" && (u.Opaque == "" || proxySchemes[u.Scheme]):
+		// opaque URLs such as mailto:team@example.com have no host
+		return fmt.Errorf("%q must start with / or be an absolute URL", to)
+	}
+
+	if p := u.Port(); p != "" {
+		if n, err := strconv.Atoi(p); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("invalid port %q", p)
+		}
+	}
+
+	return nil
+}
+
+// withoutScheme returns the destination to without its URL scheme, whose
+// colon does not start a placeholder as in "mailto:team@example.com".
+func withoutScheme(to string) string {
+	if u, err := url.Parse(to); err == nil && u.Scheme != "" {
+		return to[len(u.Scheme)+1:]
+	}
+
+	return to
+}
+
+// placeholders returns the set of placeholders bound by the source path
+// and params of r, including :splat when the source path has a wildcard.
+func placeholders(r Rule) map[string]bool {
+	bound := make(map[string]bool)
+
+	for _, name := range placeholder.FindAllString(r.From, -1) {
+		bound[name] = true
+	}
+
+	for _, v := range r.Params {
+		if s, ok := v.(string); ok && s != "" && placeholder.FindString(s) == s {
+			bound[s] = true
+		}
+	}
+
+	if strings.HasSuffix(r.From, "*") {
+		bound[":splat"] = true
+	}
+
+	if n := wildcards(segments(r.From)); n > 0 {
+		bound[":splat"] = true
+		for i := 1; i <= n && n > 1; i++ {
+			bound[":splat"+strconv.Itoa(i)] = true
+		}
+	}
+
+	return bound
+}
+
+// placeholderWarnings returns likely mistakes in the source placeholders of
+// r: names reserved for wildcards such as :splat, names used more than once,
+// whose capture is the last, and placeholders the destination does not use.
+func placeholderWarnings(r Rule) (warnings []error) {
+	from := sourcePattern(r.From)
+	if isRegexp(from) || isExclusion(r.From) {
+		return
+	}
+
+	used := make(map[string]bool)
+	for _, name := range placeholder.FindAllString(r.To, -1) {
+		used[name] = true
+	}
+
+	seen := make(map[string]bool)
+
+	for _, s := range segments(from) {
+		if !strings.HasPrefix(s, ":") {
+			continue
+		}
+
+		name := placeholder.FindString(s)
+		if name == "" {
+			continue
+		}
+
+		switch {
+		case splatName(name):
+			warnings = append(warnings, errorf(CodeReservedPlaceholder, "placeholder %q is reserved for wildcards", name))
+		case seen[name]:
+			warnings = append(warnings, errorf(CodeDuplicatePlaceholder, "placeholder %q is used more than once", name))
+		case !used[name]:
+			warnings = append(warnings, errorf(CodeUnusedPlaceholder, "placeholder %q is not used by the destination", name))
+		}
+
+		seen[name] = true
+	}
+
+	return
+}
+
+// splatName returns true if the placeholder name is that of a wildcard
+// capture, such as :splat or :splat2.
+func splatName(name string) bool {
+	rest := strings.TrimPrefix(name, ":splat")
+	if rest == name {
+		return false
+	}
+
+	_, err := strconv.Atoi(rest)
+	return rest == "" || err == nil
+}
@@ -0,0 +1,128 @@
+package redirects_test
+
+import (
+	"testing"
+
+	"github.com/fission-suite/go-redirects"
+	"github.com/pkg/errors"
+	"github.com/tj/assert"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		errs := redirects.Validate([]redirects.Rule{
+			{From: "/blog/:year/*", To: "/posts/:year/:splat", Status: 301},
+			{From: "/store", To: "/item/:id", Status: 302, Params: redirects.Params{"id": ":id"}},
+			{From: "/api/*", To: "https://api.example.com/:splat", Status: 200},
+			{From: "/", To: "/anz", Status: 302, Country: []string{"au", "nz"}, Language: []string{"en", "pt-BR"}},
+		})
+
+		assert.Empty(t, errs)
+	})
+
+	t.Run("path shape", func(t *testing.T) {
+		errs := redirects.Validate([]redirects.Rule{
+			{From: "blog", To: "/", Status: 301},
+			{From: "/blog", To: "", Status: 301},
+		})
+
+		assert.Len(t, errs, 2)
+		assert.Contains(t, errs[0].Error(), "invalid source path")
+		assert.Contains(t, errs[1].Error(), "invalid destination path")
+	})
+
+	t.Run("destination URLs", func(t *testing.T) {
+		errs := redirects.Validate([]redirects.Rule{
+			{From: "/a", To: "https://example.com:8443/path", Status: 301},
+			{From: "/a", To: "wss://ws.example.com/socket", Status: 200},
+			{From: "/a", To: "javascript:alert(1)", Status: 301},
+			{From: "/a", To: "DATA:text/html,<script>", Status: 301},
+			{From: "/a", To: "https://example.com@evil.example/", Status: 301},
+			{From: "/a", To: "https://example.com:99999/", Status: 301},
+			{From: "/a", To: "ftp://files.example.com/", Status: 301},
+		})
+
+		assert.Len(t, errs, 5)
+		assert.Contains(t, errs[0].Error(), `scheme "javascript" is not allowed`)
+		assert.Contains(t, errs[1].Error(), `scheme "data" is not allowed`)
+		assert.Contains(t, errs[2].Error(), "must not carry credentials")
+		assert.Contains(t, errs[3].Error(), `invalid port "99999"`)
+		assert.Contains(t, errs[4].Error(), `scheme "ftp" is not allowed`)
+		assert.Equal(t, redirects.CodeInvalidDestination, redirects.ErrorCode(errs[4]))
+	})
+
+	t.Run("status code", func(t *testing.T) {
+		errs := redirects.Validate([]redirects.Rule{
+			{From: "/blog", To: "/", Status: 999},
+			{From: "/admin/*", To: "/login", Status: 401, Force: true},
+			{From: "/private", To: "/403.html", Status: 403},
+			{From: "/outage", To: "/500.html", Status: 500},
+			{From: "/blog", To: "/", Status: 199},
+		})
+
+		assert.Len(t, errs, 2)
+		assert.Contains(t, errs[0].Error(), "unsupported status code 999")
+		assert.Contains(t, errs[1].Error(), "unsupported status code 199")
+	})
+
+	t.Run("placeholders", func(t *testing.T) {
+		errs := redirects.Validate([]redirects.Rule{
+			{From: "/blog", To: "/posts/:splat", Status: 301},
+			{From: "/blog/:year", To: "/posts/:month", Status: 301},
+		})
+
+		assert.Len(t, errs, 2)
+		assert.Contains(t, errs[0].Error(), ":splat")
+		assert.Contains(t, errs[1].Error(), ":month")
+	})
+
+	t.Run("conditions", func(t *testing.T) {
+		errs := redirects.Validate([]redirects.Rule{
+			{From: "/", To: "/anz", Status: 302, Country: []string{"aus"}, Language: []string{"english"}, Method: []string{"GET HEAD"}, Conditions: redirects.Conditions{"Cookie:a": "b"}, Host: []string{"shop example"}},
+		})
+
+		assert.Len(t, errs, 5)
+	})
+
+	t.Run("reserved params", func(t *testing.T) {
+		errs := redirects.Validate([]redirects.Rule{
+			{From: "/a", To: "/b/:splat", Status: 301, Params: redirects.Params{"splat": ":splat"}},
+			{From: "/a/*/*", To: "/b/:splat2", Status: 301, Params: redirects.Params{"page": ":splat2"}},
+			{From: "/a", To: "/b/:splatter", Status: 301, Params: redirects.Params{"q": ":splatter"}},
+		})
+
+		assert.Len(t, errs, 2)
+		assert.Contains(t, errs[0].Error(), `param "splat" can not bind :splat, which is reserved for wildcards`)
+		assert.Equal(t, redirects.CodeInvalidParam, redirects.ErrorCode(errs[1]))
+	})
+
+	t.Run("negated countries", func(t *testing.T) {
+		errs := redirects.Validate([]redirects.Rule{
+			{From: "/", To: "/global", Status: 302, Country: []string{"!cn", "!ru"}},
+			{From: "/", To: "/global", Status: 302, Country: []string{"!chn"}},
+			{From: "/", To: "/global", Status: 302, Country: []string{"us", "!cn"}},
+		})
+
+		assert.Len(t, errs, 2)
+		assert.Contains(t, errs[0].Error(), `invalid country code "!chn"`)
+		assert.Contains(t, errs[1].Error(), `country "!cn" can not be listed alongside excluded countries`)
+	})
+
+	t.Run("error details", func(t *testing.T) {
+		errs := redirects.Validate([]redirects.Rule{
+			{From: "/", To: "/", Status: 301},
+			{From: "/blog", To: "/", Status: 999},
+		})
+
+		var verr *redirects.ValidationError
+		assert.True(t, errors.As(errs[0], &verr))
+		assert.Equal(t, 1, verr.Index)
+		assert.Equal(t, "/blog", verr.From)
+	})
+}
+
+func TestParse_validation(t *testing.T) {
+	_, err := redirects.ParseString(`/blog /posts 999`)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported status code 999")
+}
@@ -0,0 +1,130 @@
+//go:build js && wasm
+
+// Command wasm exposes parsing and matching to JavaScript as a global
+// redirects object, so edge platforms and browsers evaluate rules with this
+// exact implementation:
+//
+//	redirects.parse(source)          // { rules } or { error }
+//	redirects.match(source, request) // result, null, or { error }
+//
+// Requests are objects with the fields of Request in lower case, such as
+// { path: "/news", query: "page=2", country: "nz" }.
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"syscall/js"
+
+	"github.com/fission-suite/go-redirects"
+)
+
+// cache holds the compiled rules of recently matched sources.
+var cache = redirects.NewMatcherCache(16)
+
+// request is a request from JavaScript.
+type request struct {
+	Host     string            `json:"host"`
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	Query    string            `json:"query"`
+	Headers  map[string]string `json:"headers"`
+	Country  string            `json:"country"`
+	Language []string          `json:"language"`
+}
+
+// result is a match result for JavaScript.
+type result struct {
+	Index      int               `json:"index"`
+	Rule       redirects.Rule    `json:"rule"`
+	To         string            `json:"to"`
+	Status     int               `json:"status"`
+	Captures   map[string]string `json:"captures"`
+	Conditions []string          `json:"conditions"`
+	Force      bool              `json:"force"`
+}
+
+func main() {
+	js.Global().Set("redirects", map[string]interface{}{
+		"parse": js.FuncOf(parse),
+		"match": js.FuncOf(match),
+	})
+
+	select {}
+}
+
+// parse parses a _redirects source.
+func parse(this js.Value, args []js.Value) interface{} {
+	rules, err := redirects.ParseString(args[0].String())
+	if err != nil {
+		return fail(err)
+	}
+
+	return value(map[string]interface{}{"rules": rules})
+}
+
+// match matches a request against a _redirects source.
+func match(this js.Value, args []js.Value) interface{} {
+	src := args[0].String()
+
+	compiled, err := cache.Load(redirects.ContentHash([]byte(src)), func() ([]redirects.Rule, error) {
+		return redirects.ParseString(src)
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	var req request
+	if err := json.Unmarshal([]byte(js.Global().Get("JSON").Call("stringify", args[1]).String()), &req); err != nil {
+		return fail(err)
+	}
+
+	query, err := url.ParseQuery(req.Query)
+	if err != nil {
+		return fail(err)
+	}
+
+	r := redirects.Request{
+		Host:     req.Host,
+		Method:   req.Method,
+		Path:     req.Path,
+		Query:    query,
+		Header:   make(map[string][]string),
+		Country:  req.Country,
+		Language: req.Language,
+	}
+
+	for k, v := range req.Headers {
+		r.Header.Set(k, v)
+	}
+
+	res, ok := compiled.Match(r)
+	if !ok {
+		return nil
+	}
+
+	return value(result{
+		Index:      res.Index,
+		Rule:       *res.Rule,
+		To:         res.To,
+		Status:     res.Rule.Status,
+		Captures:   res.Captures.Map(),
+		Conditions: res.Conditions,
+		Force:      res.Force,
+	})
+}
+
+// value returns v as a JavaScript value.
+func value(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fail(err)
+	}
+
+	return js.Global().Get("JSON").Call("parse", string(b))
+}
+
+// fail returns the JavaScript value of an error.
+func fail(err error) interface{} {
+	return map[string]interface{}{"error": err.Error()}
+}